@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"vector-triage/internal/engine"
+	"vector-triage/internal/store"
+)
+
+type evalOptions struct {
+	DBPath     string
+	LabelsPath string
+	Format     string
+	Depth      int
+	Thresholds []float64
+	Fusion     store.FusionStrategy
+}
+
+// labelLine is one JSONL row in a labels file. Items are ids ("issue/12") or numbers ("#12").
+type labelLine struct {
+	A       string `json:"a"`
+	B       string `json:"b"`
+	Verdict string `json:"verdict"`
+}
+
+// runEvalCommand runs eval from argv. eval reads a local index.db and labels
+// file, so it is rejected when it only arrives through INPUT_COMMAND.
+func runEvalCommand(ctx context.Context, args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("eval is not an action command; run `triage eval -db index.db` against a downloaded index")
+	}
+	return runEval(ctx, commandArgs(args), stdout)
+}
+
+// runEval scores retrieval against labeled pairs from a local index. Without
+// -labels it uses the maintainer verdicts already recorded in the index.
+func runEval(ctx context.Context, args []string, stdout io.Writer) error {
+	opts, err := parseEvalArgs(args)
+	if err != nil {
+		return err
+	}
+
+	s, err := store.Open(ctx, opts.DBPath)
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer s.Close()
+
+	var pairs []engine.LabeledPair
+	if opts.LabelsPath != "" {
+		f, err := os.Open(opts.LabelsPath)
+		if err != nil {
+			return fmt.Errorf("open labels: %w", err)
+		}
+		defer f.Close()
+		pairs, err = loadLabeledPairs(ctx, f, s.LookupItemIDByNumber)
+		if err != nil {
+			return err
+		}
+	} else {
+		pairs, err = storedLabeledPairs(ctx, s)
+		if err != nil {
+			return err
+		}
+	}
+	if len(pairs) == 0 {
+		return errors.New("no labeled pairs to evaluate")
+	}
+
+	report, err := engine.Evaluate(ctx, s, pairs, engine.EvalOptions{
+		Thresholds: opts.Thresholds,
+		Depth:      opts.Depth,
+		Fusion:     opts.Fusion,
+	})
+	if err != nil {
+		return fmt.Errorf("evaluate: %w", err)
+	}
+
+	if opts.Format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	return writeEvalTable(stdout, report)
+}
+
+func parseEvalArgs(args []string) (evalOptions, error) {
+	var (
+		opts          evalOptions
+		thresholds    string
+		fusion        string
+		fusionWeights string
+		rrfK          int
+	)
+	fs := flag.NewFlagSet(commandEval, flag.ContinueOnError)
+	fs.StringVar(&opts.DBPath, "db", "", "path to index.db")
+	fs.StringVar(&opts.LabelsPath, "labels", "", "JSONL file of {\"a\",\"b\",\"verdict\"} pairs (default: verdicts stored in the index)")
+	fs.StringVar(&opts.Format, "format", "table", "output format: table or json")
+	fs.IntVar(&opts.Depth, "depth", 20, "candidates fetched from each backend per item")
+	fs.StringVar(&thresholds, "thresholds", "", "comma-separated thresholds to sweep (default 0.50-0.98 step 0.02)")
+	fs.StringVar(&fusion, "fusion", store.FusionRRF, "fusion strategy: "+strings.Join(store.FusionStrategyNames(), ", "))
+	fs.StringVar(&fusionWeights, "fusion-weights", "", "per-source weights such as vector=1,fts=0.5,near=1")
+	fs.IntVar(&rrfK, "rrf-k", 60, "k constant for the rrf strategy")
+	if err := fs.Parse(args); err != nil {
+		return evalOptions{}, err
+	}
+
+	if strings.TrimSpace(opts.DBPath) == "" {
+		return evalOptions{}, errors.New("eval requires -db")
+	}
+	opts.Format = strings.ToLower(strings.TrimSpace(opts.Format))
+	if opts.Format != "table" && opts.Format != "json" {
+		return evalOptions{}, fmt.Errorf("unknown eval format %q", opts.Format)
+	}
+	if opts.Depth < 1 {
+		return evalOptions{}, errors.New("-depth must be at least 1")
+	}
+	if rrfK < 1 {
+		return evalOptions{}, errors.New("-rrf-k must be at least 1")
+	}
+	weights, err := store.ParseFusionWeights(fusionWeights)
+	if err != nil {
+		return evalOptions{}, fmt.Errorf("parse -fusion-weights: %w", err)
+	}
+	if opts.Fusion, err = store.NewFusionStrategy(fusion, weights, rrfK); err != nil {
+		return evalOptions{}, err
+	}
+
+	for _, raw := range strings.Split(thresholds, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return evalOptions{}, fmt.Errorf("parse -thresholds: %w", err)
+		}
+		if value < 0 || value > 1 {
+			return evalOptions{}, fmt.Errorf("threshold %v must be between 0 and 1", value)
+		}
+		opts.Thresholds = append(opts.Thresholds, value)
+	}
+
+	return opts, nil
+}
+
+// storedLabeledPairs turns the maintainer verdicts recorded in the index into labeled pairs.
+func storedLabeledPairs(ctx context.Context, s *store.Store) ([]engine.LabeledPair, error) {
+	feedback, err := s.ListPairFeedback(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pairs := make([]engine.LabeledPair, 0, len(feedback))
+	for _, fb := range feedback {
+		pairs = append(pairs, engine.LabeledPair{A: fb.ItemA, B: fb.ItemB, Duplicate: fb.Verdict == store.PairVerdictDuplicate})
+	}
+	return pairs, nil
+}
+
+func loadLabeledPairs(ctx context.Context, r io.Reader, lookup func(ctx context.Context, number int) (string, bool, error)) ([]engine.LabeledPair, error) {
+	out := make([]engine.LabeledPair, 0)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var in labelLine
+		if err := json.Unmarshal([]byte(line), &in); err != nil {
+			return nil, fmt.Errorf("labels line %d: %w", lineNo, err)
+		}
+
+		var duplicate bool
+		switch store.PairVerdict(strings.ToLower(strings.TrimSpace(in.Verdict))) {
+		case store.PairVerdictDuplicate:
+			duplicate = true
+		case store.PairVerdictNotDuplicate:
+		default:
+			return nil, fmt.Errorf("labels line %d: unknown verdict %q", lineNo, in.Verdict)
+		}
+
+		a, err := resolveLabelItem(ctx, in.A, lookup)
+		if err != nil {
+			return nil, fmt.Errorf("labels line %d: %w", lineNo, err)
+		}
+		b, err := resolveLabelItem(ctx, in.B, lookup)
+		if err != nil {
+			return nil, fmt.Errorf("labels line %d: %w", lineNo, err)
+		}
+		out = append(out, engine.LabeledPair{A: a, B: b, Duplicate: duplicate})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read labels: %w", err)
+	}
+	return out, nil
+}
+
+func resolveLabelItem(ctx context.Context, raw string, lookup func(ctx context.Context, number int) (string, bool, error)) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.HasPrefix(raw, "#") {
+		if raw == "" {
+			return "", errors.New("item reference is empty")
+		}
+		return raw, nil
+	}
+
+	number, err := strconv.Atoi(strings.TrimPrefix(raw, "#"))
+	if err != nil || number <= 0 {
+		return "", fmt.Errorf("invalid item reference %q", raw)
+	}
+	id, found, err := lookup(ctx, number)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("%s is not in the index", raw)
+	}
+	return id, nil
+}
+
+func writeEvalTable(w io.Writer, report engine.EvalReport) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "fusion\t%s\n", report.Fusion)
+	fmt.Fprintf(tw, "queries\t%d (%d keyword-only, %d skipped)\n", report.Queries, report.KeywordOnly, len(report.SkippedItems))
+	fmt.Fprintf(tw, "pairs\t%d (%d duplicate)\n", report.Pairs, report.DuplicatePairs)
+	fmt.Fprintf(tw, "MRR\t%.3f\n", report.MRR)
+	for _, r := range report.RecallAtK {
+		fmt.Fprintf(tw, "recall@%d\t%.3f\n", r.K, r.Recall)
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "threshold\tprecision\trecall\tTP\tFP\tFN\tunlabeled")
+	for _, m := range report.Sweep {
+		fmt.Fprintf(tw, "%.2f\t%.3f\t%.3f\t%d\t%d\t%d\t%d\n",
+			m.Threshold, m.Precision, m.Recall, m.TruePositives, m.FalsePositives, m.FalseNegatives, m.Unlabeled)
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintf(tw, "recommended similarity-threshold\t%s\n", formatRecommendation(report.RecommendedSimilarity))
+	fmt.Fprintf(tw, "recommended duplicate-threshold\t%s\n", formatRecommendation(report.RecommendedDuplicate))
+	return tw.Flush()
+}
+
+func formatRecommendation(value float64) string {
+	if value <= 0 {
+		return "n/a (target not reached)"
+	}
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
@@ -2,15 +2,19 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"vector-triage/internal/embed"
 	"vector-triage/internal/engine"
 	gh "vector-triage/internal/github"
+	"vector-triage/internal/rerank"
 	"vector-triage/internal/respond"
 	"vector-triage/internal/store"
 )
@@ -25,15 +29,89 @@ type config struct {
 	DuplicateThreshold  float64
 	MaxResults          int
 	IndexBranch         string
+	IndexBackend        string
+	State               gh.StateBackendConfig
+
+	EmbeddingProvider   string
+	EmbeddingEndpoint   string
+	EmbeddingModel      string
+	EmbeddingAPIKey     string
+	EmbeddingDimensions int
+	// EmbeddingDimensionsSet is true when INPUT_EMBEDDING_DIMENSIONS was given.
+	EmbeddingDimensionsSet bool
+	ChunkScoring           store.ChunkScoringMode
+	Fusion                 store.FusionStrategy
+	CalibrationMethod      string
+
+	Reranker        string
+	RerankModel     string
+	RerankTopN      int
+	RerankThreshold float64
+
+	SearchFilter store.SearchFilter
+	Recency      store.Recency
 }
 
+const (
+	commandRun       = "run"
+	commandBackfill  = "backfill"
+	commandEval      = "eval"
+	commandReindex   = "reindex"
+	commandCalibrate = "calibrate"
+)
+
+var (
+	eventRequiredEnv    = []string{"GITHUB_TOKEN", "GITHUB_EVENT_NAME", "GITHUB_EVENT_PATH", "GITHUB_REPOSITORY"}
+	backfillRequiredEnv = []string{"GITHUB_TOKEN", "GITHUB_REPOSITORY"}
+)
+
 func main() {
 	ctx := context.Background()
-	if err := run(ctx, os.Getenv); err != nil {
+
+	var err error
+	switch command := resolveCommand(os.Args[1:], os.Getenv); command {
+	case commandRun:
+		err = run(ctx, os.Getenv)
+	case commandBackfill:
+		err = runBackfill(ctx, os.Getenv)
+	case commandReindex:
+		err = runReindex(ctx, os.Getenv)
+	case commandCalibrate:
+		err = runCalibrate(ctx, os.Getenv)
+	case commandEval:
+		// eval is run by hand, so a failure fails the process instead of
+		// being logged as a warning like the action commands.
+		if err := runEvalCommand(ctx, os.Args[1:], os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "eval: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		err = fmt.Errorf("unknown command %q", command)
+	}
+	if err != nil {
 		logWarning(err)
 	}
 }
 
+// resolveCommand picks the subcommand from argv first, then INPUT_COMMAND for the composite action.
+func resolveCommand(args []string, getenv func(string) string) string {
+	if len(args) > 0 && strings.TrimSpace(args[0]) != "" {
+		return strings.TrimSpace(args[0])
+	}
+	if command := strings.TrimSpace(getenv("INPUT_COMMAND")); command != "" {
+		return command
+	}
+	return commandRun
+}
+
+// commandArgs returns the flags after an argv subcommand, if one was given.
+func commandArgs(args []string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	return args[1:]
+}
+
 func run(ctx context.Context, getenv func(string) string) error {
 	cfg, err := parseConfigFromEnv(getenv)
 	if err != nil {
@@ -45,35 +123,52 @@ func run(ctx context.Context, getenv func(string) string) error {
 		return err
 	}
 
+	event, err := gh.ParseEventFile(cfg.EventName, cfg.EventPath, cfg.Repository)
+	if err != nil {
+		return fmt.Errorf("parse event: %w", err)
+	}
+	// Plain comments change nothing; skipping them keeps the index history
+	// quiet and avoids racing other runs for the index.
+	if !engine.NeedsIndex(event) {
+		return nil
+	}
+
 	indexPath := filepath.Join(os.TempDir(), "triage-index.db")
 
-	stateManager := gh.StateManager{
-		Owner:  owner,
-		Repo:   repo,
-		Token:  cfg.Token,
-		Branch: cfg.IndexBranch,
+	stateManager, err := newStateManager(cfg, owner, repo)
+	if err != nil {
+		return fmt.Errorf("create state backend: %w", err)
 	}
 	_, err = stateManager.Pull(ctx, indexPath)
 	if err != nil {
 		return fmt.Errorf("pull state: %w", err)
 	}
 
-	s, err := store.Open(ctx, indexPath)
+	embedder, err := newEmbedder(cfg)
+	if err != nil {
+		return fmt.Errorf("create embedder: %w", err)
+	}
+
+	s, err := store.OpenWithEmbedding(ctx, indexPath, embeddingSpace(embedder))
 	if err != nil {
 		return fmt.Errorf("open store: %w", err)
 	}
 	defer s.Close()
+	stateManager.Merge = mergeInto(s)
+	s.SetChunkScoring(store.ChunkScoring{Mode: cfg.ChunkScoring})
 
-	githubClient, err := gh.NewClient(cfg.Token, nil)
+	calibration, err := loadCalibration(ctx, s, cfg.Fusion)
 	if err != nil {
-		return fmt.Errorf("create github client: %w", err)
+		logWarning(err)
 	}
 
-	event, err := gh.ParseEventFile(cfg.EventName, cfg.EventPath, cfg.Repository)
+	githubClient, err := gh.NewClient(cfg.Token, nil)
 	if err != nil {
-		return fmt.Errorf("parse event: %w", err)
+		return fmt.Errorf("create github client: %w", err)
 	}
-	if event.Type == "pr" {
+
+	needsContent := engine.NeedsFullContent(event)
+	if event.Type == "pr" && needsContent {
 		files, filesErr := githubClient.ListPullRequestFiles(ctx, owner, repo, event.Number)
 		if filesErr != nil {
 			logWarning(fmt.Errorf("fetch pr files: %w", filesErr))
@@ -89,21 +184,19 @@ func run(ctx context.Context, getenv func(string) string) error {
 		}
 	}
 
-	embedder, err := embed.NewGitHubModelsEmbedder(embed.GitHubModelsConfig{
-		Token:      cfg.Token,
-		MaxRetries: 3,
-		Dimensions: embed.DefaultEmbeddingDimensions,
-		MaxChars:   embed.DefaultMaxInputChars,
-	})
+	reranker, err := newReranker(cfg)
 	if err != nil {
-		return fmt.Errorf("create embedder: %w", err)
+		return fmt.Errorf("create reranker: %w", err)
 	}
 
 	commentManager := gh.CommentManager{API: githubClient}
 	eng := &engine.Engine{
-		Embedder: embedder,
-		Store:    s,
-		Comments: commentManager,
+		Embedder:    embedder,
+		Store:       s,
+		Comments:    commentManager,
+		Permissions: githubClient,
+		Duplicates:  githubClient,
+		Reranker:    reranker,
 		Formatter: respond.Formatter{
 			SimilarityThreshold: cfg.SimilarityThreshold,
 			DuplicateThreshold:  cfg.DuplicateThreshold,
@@ -112,11 +205,28 @@ func run(ctx context.Context, getenv func(string) string) error {
 			SimilarityThreshold: cfg.SimilarityThreshold,
 			DuplicateThreshold:  cfg.DuplicateThreshold,
 			MaxResults:          cfg.MaxResults,
+			Fusion:              cfg.Fusion,
+			Calibration:         calibration,
+			RerankTopN:          cfg.RerankTopN,
+			RerankThreshold:     cfg.RerankThreshold,
+			Recency:             cfg.Recency,
+			SearchFilter:        cfg.SearchFilter,
 		},
+		Warn: logWarning,
 	}
-	if err := eng.Handle(ctx, event); err != nil {
+	changed, err := eng.Process(ctx, event)
+	if err != nil {
 		return fmt.Errorf("engine handle: %w", err)
 	}
+	if !changed {
+		return nil
+	}
+
+	if needsContent {
+		if _, err := engine.FillPendingEmbeddings(ctx, embedder, s, engine.DefaultPendingEmbeddingLimit); err != nil {
+			logWarning(fmt.Errorf("fill pending embeddings: %w", err))
+		}
+	}
 
 	if err := stateManager.Push(ctx, indexPath); err != nil {
 		return fmt.Errorf("push state: %w", err)
@@ -125,8 +235,265 @@ func run(ctx context.Context, getenv func(string) string) error {
 	return nil
 }
 
+// runBackfill indexes every existing issue and PR, then pushes the index once.
+// Partial progress is pushed too so an interrupted backfill resumes from its checkpoint.
+func runBackfill(ctx context.Context, getenv func(string) string) error {
+	cfg, err := parseBackfillConfigFromEnv(getenv)
+	if err != nil {
+		return err
+	}
+
+	owner, repo, err := gh.ParseRepository(cfg.Repository)
+	if err != nil {
+		return err
+	}
+
+	indexPath := filepath.Join(os.TempDir(), "triage-index.db")
+
+	stateManager, err := newStateManager(cfg, owner, repo)
+	if err != nil {
+		return fmt.Errorf("create state backend: %w", err)
+	}
+	if _, err := stateManager.Pull(ctx, indexPath); err != nil {
+		return fmt.Errorf("pull state: %w", err)
+	}
+
+	embedder, err := newEmbedder(cfg)
+	if err != nil {
+		return fmt.Errorf("create embedder: %w", err)
+	}
+
+	s, err := store.Open(ctx, indexPath)
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer s.Close()
+	stateManager.Merge = mergeInto(s)
+
+	// Backfill re-embeds everything anyway, so a model change is migrated here
+	// instead of failing like a normal run does.
+	space := embeddingSpace(embedder)
+	var mismatch *store.EmbeddingSpaceMismatchError
+	if err := s.EnsureEmbeddingSpace(ctx, space); errors.As(err, &mismatch) {
+		logWarning(fmt.Errorf("re-embedding index: switching from %s to %s", mismatch.Stored, mismatch.Configured))
+		if err := s.ResetEmbeddingSpace(ctx, space); err != nil {
+			return fmt.Errorf("reset embedding space: %w", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("check embedding space: %w", err)
+	}
+
+	githubClient, err := gh.NewClient(cfg.Token, nil)
+	if err != nil {
+		return fmt.Errorf("create github client: %w", err)
+	}
+
+	backfiller := &engine.Backfiller{
+		Owner:    owner,
+		Repo:     repo,
+		Source:   githubClient,
+		Embedder: embedder,
+		Store:    s,
+		Warn:     logWarning,
+	}
+	stats, runErr := backfiller.Run(ctx)
+	resumed := "from the start"
+	if !stats.StartCursor.IsZero() {
+		resumed = "resuming from items updated since " + stats.StartCursor.UTC().Format(time.RFC3339)
+	}
+	fmt.Printf("backfill indexed %d items (%d embedded) across %d pages, %s\n",
+		stats.Items, stats.Embedded, stats.Pages, resumed)
+
+	if stats.Pages > 0 || runErr == nil {
+		if err := stateManager.Push(ctx, indexPath); err != nil {
+			return fmt.Errorf("push state: %w", err)
+		}
+	}
+	if runErr != nil {
+		return fmt.Errorf("backfill: %w", runErr)
+	}
+
+	return nil
+}
+
+// runReindex re-embeds every stored item with the configured model and swaps
+// the new vectors in atomically. Partial progress is pushed so it can resume.
+func runReindex(ctx context.Context, getenv func(string) string) error {
+	cfg, err := parseBackfillConfigFromEnv(getenv)
+	if err != nil {
+		return err
+	}
+
+	owner, repo, err := gh.ParseRepository(cfg.Repository)
+	if err != nil {
+		return err
+	}
+
+	indexPath := filepath.Join(os.TempDir(), "triage-index.db")
+
+	stateManager, err := newStateManager(cfg, owner, repo)
+	if err != nil {
+		return fmt.Errorf("create state backend: %w", err)
+	}
+	if _, err := stateManager.Pull(ctx, indexPath); err != nil {
+		return fmt.Errorf("pull state: %w", err)
+	}
+
+	embedder, err := newEmbedder(cfg)
+	if err != nil {
+		return fmt.Errorf("create embedder: %w", err)
+	}
+
+	s, err := store.Open(ctx, indexPath)
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer s.Close()
+	stateManager.Merge = mergeInto(s)
+
+	reindexer := &engine.Reindexer{Embedder: embedder, Store: s}
+	stats, runErr := reindexer.Run(ctx)
+	fmt.Printf("reindex embedded %d of %d items (%d resumed from a previous run), swapped %d vectors\n",
+		stats.Embedded, stats.Items, stats.Resumed, stats.Swapped)
+
+	if stats.Embedded > 0 || runErr == nil {
+		if err := stateManager.Push(ctx, indexPath); err != nil {
+			return fmt.Errorf("push state: %w", err)
+		}
+	}
+	if runErr != nil {
+		return fmt.Errorf("reindex: %w", runErr)
+	}
+
+	return nil
+}
+
+func runCalibrate(ctx context.Context, getenv func(string) string) error {
+	cfg, err := parseBackfillConfigFromEnv(getenv)
+	if err != nil {
+		return err
+	}
+
+	owner, repo, err := gh.ParseRepository(cfg.Repository)
+	if err != nil {
+		return err
+	}
+
+	indexPath := filepath.Join(os.TempDir(), "triage-index.db")
+
+	stateManager, err := newStateManager(cfg, owner, repo)
+	if err != nil {
+		return fmt.Errorf("create state backend: %w", err)
+	}
+	if _, err := stateManager.Pull(ctx, indexPath); err != nil {
+		return fmt.Errorf("pull state: %w", err)
+	}
+
+	s, err := store.Open(ctx, indexPath)
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer s.Close()
+	stateManager.Merge = mergeInto(s)
+
+	pairs, err := storedLabeledPairs(ctx, s)
+	if err != nil {
+		return err
+	}
+	cal, err := engine.FitCalibration(ctx, s, pairs, engine.CalibrationOptions{
+		Method: cfg.CalibrationMethod,
+		Fusion: cfg.Fusion,
+	})
+	if err != nil {
+		return fmt.Errorf("calibrate: %w", err)
+	}
+	if err := s.SaveCalibration(ctx, cal); err != nil {
+		return err
+	}
+	fmt.Printf("calibration fitted with %s on %d labeled pairs (%d duplicate) for %s fusion\n",
+		cal.Method, cal.Pairs, cal.Duplicates, cal.Fusion)
+
+	if err := stateManager.Push(ctx, indexPath); err != nil {
+		return fmt.Errorf("push state: %w", err)
+	}
+	return nil
+}
+
+// loadCalibration returns the stored calibration when it was fitted for the
+// configured fusion strategy, and nil (with an error to warn about) otherwise.
+func loadCalibration(ctx context.Context, s *store.Store, fusion store.FusionStrategy) (*store.Calibration, error) {
+	cal, found, err := s.LoadCalibration(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load calibration, using raw similarity: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	if fusion != nil && cal.Fusion != fusion.Name() {
+		return nil, fmt.Errorf("calibration was fitted for %s fusion but %s is configured; run the calibrate command again", cal.Fusion, fusion.Name())
+	}
+	return &cal, nil
+}
+
+func newStateManager(cfg config, owner, repo string) (*gh.StateManager, error) {
+	state := cfg.State
+	state.Owner, state.Repo, state.Token = owner, repo, cfg.Token
+	backend, err := gh.NewStateBackend(cfg.IndexBackend, state)
+	if err != nil {
+		return nil, err
+	}
+	return &gh.StateManager{Backend: backend, Branch: cfg.IndexBranch}, nil
+}
+
+// mergeInto folds the index another run pushed first into s, so a rejected
+// push is retried with both runs' updates instead of dropping one.
+func mergeInto(s *store.Store) func(context.Context, string) error {
+	return func(ctx context.Context, remotePath string) error {
+		stats, err := s.MergeFrom(ctx, remotePath)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("merged concurrent index update: %d items (%d vectors, %d queued for embedding), %d deleted, %d pair verdicts, %d index settings\n",
+			stats.Items, stats.Vectors, stats.Pending, stats.Deleted, stats.Feedback, stats.Meta)
+		return nil
+	}
+}
+
+func newEmbedder(cfg config) (embed.Embedder, error) {
+	return embed.New(cfg.EmbeddingProvider, embed.ProviderConfig{
+		Endpoint:      cfg.EmbeddingEndpoint,
+		Model:         cfg.EmbeddingModel,
+		APIKey:        cfg.EmbeddingAPIKey,
+		MaxRetries:    3,
+		Dimensions:    cfg.EmbeddingDimensions,
+		DimensionsSet: cfg.EmbeddingDimensionsSet,
+		MaxChars:      embed.DefaultMaxInputChars,
+	})
+}
+
+// newReranker builds the rerank stage; the GitHub Models judge reuses the
+// workflow token, which needs the models: read permission.
+func newReranker(cfg config) (rerank.Reranker, error) {
+	return rerank.New(cfg.Reranker, rerank.Config{
+		Token:      cfg.Token,
+		Model:      cfg.RerankModel,
+		MaxRetries: 3,
+	})
+}
+
+func embeddingSpace(embedder embed.Embedder) store.EmbeddingSpace {
+	return store.EmbeddingSpace{Model: embedder.Model(), Dimensions: embedder.Dimensions()}
+}
+
 func parseConfigFromEnv(getenv func(string) string) (config, error) {
-	required := []string{"GITHUB_TOKEN", "GITHUB_EVENT_NAME", "GITHUB_EVENT_PATH", "GITHUB_REPOSITORY"}
+	return parseConfig(getenv, eventRequiredEnv)
+}
+
+func parseBackfillConfigFromEnv(getenv func(string) string) (config, error) {
+	return parseConfig(getenv, backfillRequiredEnv)
+}
+
+func parseConfig(getenv func(string) string, required []string) (config, error) {
 	for _, key := range required {
 		if strings.TrimSpace(getenv(key)) == "" {
 			return config{}, fmt.Errorf("missing required env %s", key)
@@ -154,6 +521,11 @@ func parseConfigFromEnv(getenv func(string) string) (config, error) {
 		indexBranch = "triage-index"
 	}
 
+	indexBackend, state, err := parseStateInputs(getenv)
+	if err != nil {
+		return config{}, err
+	}
+
 	if similarity < 0 || similarity > 1 {
 		return config{}, fmt.Errorf("INPUT_SIMILARITY_THRESHOLD must be between 0 and 1")
 	}
@@ -161,18 +533,248 @@ func parseConfigFromEnv(getenv func(string) string) (config, error) {
 		return config{}, fmt.Errorf("INPUT_DUPLICATE_THRESHOLD must be between 0 and 1")
 	}
 
+	provider, apiKey, err := parseEmbeddingProvider(getenv)
+	if err != nil {
+		return config{}, err
+	}
+	embeddingModel := strings.TrimSpace(getenv("INPUT_EMBEDDING_MODEL"))
+	dimensions, err := parseIntInput(getenv("INPUT_EMBEDDING_DIMENSIONS"), embed.DefaultDimensions(provider, embeddingModel))
+	if err != nil {
+		return config{}, fmt.Errorf("parse INPUT_EMBEDDING_DIMENSIONS: %w", err)
+	}
+	if dimensions == 0 {
+		return config{}, fmt.Errorf("INPUT_EMBEDDING_DIMENSIONS is required for the %s model %q", provider, embeddingModel)
+	}
+	if dimensions < 1 || dimensions > 8192 {
+		return config{}, fmt.Errorf("INPUT_EMBEDDING_DIMENSIONS must be between 1 and 8192")
+	}
+	chunkScoring, err := store.ParseChunkScoringMode(getenv("INPUT_CHUNK_SCORING"))
+	if err != nil {
+		return config{}, fmt.Errorf("parse INPUT_CHUNK_SCORING: %w", err)
+	}
+	fusion, err := parseFusionInputs(getenv)
+	if err != nil {
+		return config{}, err
+	}
+	calibrationMethod := strings.ToLower(strings.TrimSpace(getenv("INPUT_CALIBRATION_METHOD")))
+	if calibrationMethod != "" && calibrationMethod != store.CalibrationPlatt && calibrationMethod != store.CalibrationIsotonic {
+		return config{}, fmt.Errorf("INPUT_CALIBRATION_METHOD must be one of %s", strings.Join(store.CalibrationMethodNames(), ", "))
+	}
+	reranker := strings.ToLower(strings.TrimSpace(getenv("INPUT_RERANKER")))
+	if reranker == "" {
+		reranker = rerank.NameHeuristic
+	}
+	if !slices.Contains(rerank.Names(), reranker) {
+		return config{}, fmt.Errorf("INPUT_RERANKER must be one of %s", strings.Join(rerank.Names(), ", "))
+	}
+	rerankTopN, err := parseIntInput(getenv("INPUT_RERANK_TOP_N"), rerank.DefaultTopN)
+	if err != nil {
+		return config{}, fmt.Errorf("parse INPUT_RERANK_TOP_N: %w", err)
+	}
+	if rerankTopN < 1 || rerankTopN > 20 {
+		return config{}, fmt.Errorf("INPUT_RERANK_TOP_N must be between 1 and 20")
+	}
+	rerankThreshold, err := parseFloatInput(getenv("INPUT_RERANK_THRESHOLD"), rerank.DefaultThreshold)
+	if err != nil {
+		return config{}, fmt.Errorf("parse INPUT_RERANK_THRESHOLD: %w", err)
+	}
+	if rerankThreshold <= 0 || rerankThreshold > 1 {
+		return config{}, fmt.Errorf("INPUT_RERANK_THRESHOLD must be between 0 and 1")
+	}
+	filter, err := parseSearchFilter(getenv, time.Now().UTC())
+	if err != nil {
+		return config{}, err
+	}
+	halfLife, err := parseAgeInput(getenv("INPUT_RECENCY_HALF_LIFE"))
+	if err != nil {
+		return config{}, fmt.Errorf("parse INPUT_RECENCY_HALF_LIFE: %w", err)
+	}
+	openBoost, err := parseFloatInput(getenv("INPUT_OPEN_BOOST"), 0)
+	if err != nil {
+		return config{}, fmt.Errorf("parse INPUT_OPEN_BOOST: %w", err)
+	}
+	if openBoost < 0 || openBoost > 10 {
+		return config{}, fmt.Errorf("INPUT_OPEN_BOOST must be between 0 and 10")
+	}
+
 	return config{
-		Token:               getenv("GITHUB_TOKEN"),
-		EventName:           getenv("GITHUB_EVENT_NAME"),
-		EventPath:           getenv("GITHUB_EVENT_PATH"),
-		Repository:          getenv("GITHUB_REPOSITORY"),
-		SimilarityThreshold: similarity,
-		DuplicateThreshold:  duplicate,
-		MaxResults:          maxResults,
-		IndexBranch:         indexBranch,
+		Token:                  getenv("GITHUB_TOKEN"),
+		EventName:              getenv("GITHUB_EVENT_NAME"),
+		EventPath:              getenv("GITHUB_EVENT_PATH"),
+		Repository:             getenv("GITHUB_REPOSITORY"),
+		SimilarityThreshold:    similarity,
+		DuplicateThreshold:     duplicate,
+		MaxResults:             maxResults,
+		IndexBranch:            indexBranch,
+		IndexBackend:           indexBackend,
+		State:                  state,
+		EmbeddingProvider:      provider,
+		EmbeddingEndpoint:      strings.TrimSpace(getenv("INPUT_EMBEDDING_ENDPOINT")),
+		EmbeddingModel:         embeddingModel,
+		EmbeddingAPIKey:        apiKey,
+		EmbeddingDimensions:    dimensions,
+		EmbeddingDimensionsSet: strings.TrimSpace(getenv("INPUT_EMBEDDING_DIMENSIONS")) != "",
+		ChunkScoring:           chunkScoring,
+		Fusion:                 fusion,
+		CalibrationMethod:      calibrationMethod,
+		Reranker:               reranker,
+		RerankModel:            strings.TrimSpace(getenv("INPUT_RERANK_MODEL")),
+		RerankTopN:             rerankTopN,
+		RerankThreshold:        rerankThreshold,
+		SearchFilter:           filter,
+		Recency:                store.Recency{HalfLife: halfLife, OpenBoost: openBoost},
 	}, nil
 }
 
+// parseFusionInputs builds the fusion strategy from INPUT_FUSION_STRATEGY,
+// INPUT_FUSION_WEIGHTS and INPUT_RRF_K.
+func parseFusionInputs(getenv func(string) string) (store.FusionStrategy, error) {
+	rrfK, err := parseIntInput(getenv("INPUT_RRF_K"), 60)
+	if err != nil {
+		return nil, fmt.Errorf("parse INPUT_RRF_K: %w", err)
+	}
+	if rrfK < 1 {
+		return nil, fmt.Errorf("INPUT_RRF_K must be at least 1")
+	}
+	weights, err := store.ParseFusionWeights(getenv("INPUT_FUSION_WEIGHTS"))
+	if err != nil {
+		return nil, fmt.Errorf("parse INPUT_FUSION_WEIGHTS: %w", err)
+	}
+	fusion, err := store.NewFusionStrategy(getenv("INPUT_FUSION_STRATEGY"), weights, rrfK)
+	if err != nil {
+		return nil, fmt.Errorf("parse INPUT_FUSION_STRATEGY: %w", err)
+	}
+	return fusion, nil
+}
+
+// parseStateInputs picks where the index is stored. The Actions cache
+// backend reads the runtime URL and token, which the runner only exposes to
+// steps that export them (for example crazy-max/ghaction-github-runtime).
+func parseStateInputs(getenv func(string) string) (string, gh.StateBackendConfig, error) {
+	backend := strings.ToLower(strings.TrimSpace(getenv("INPUT_INDEX_BACKEND")))
+	if backend == "" {
+		backend = gh.StateBackendBranch
+	}
+	if !slices.Contains(gh.StateBackendNames(), backend) {
+		return "", gh.StateBackendConfig{}, fmt.Errorf("INPUT_INDEX_BACKEND must be one of %s", strings.Join(gh.StateBackendNames(), ", "))
+	}
+	transport := strings.ToLower(strings.TrimSpace(getenv("INPUT_BRANCH_TRANSPORT")))
+	if transport == "" {
+		transport = gh.BranchTransportGit
+	}
+	if !slices.Contains(gh.BranchTransportNames(), transport) {
+		return "", gh.StateBackendConfig{}, fmt.Errorf("INPUT_BRANCH_TRANSPORT must be one of %s", strings.Join(gh.BranchTransportNames(), ", "))
+	}
+
+	state := gh.StateBackendConfig{
+		Transport:  transport,
+		Directory:  strings.TrimSpace(getenv("INPUT_INDEX_DIRECTORY")),
+		CacheURL:   strings.TrimSpace(getenv("ACTIONS_RESULTS_URL")),
+		CacheToken: strings.TrimSpace(getenv("ACTIONS_RUNTIME_TOKEN")),
+	}
+	switch {
+	case backend == gh.StateBackendLocal && state.Directory == "":
+		return "", gh.StateBackendConfig{}, fmt.Errorf("INPUT_INDEX_DIRECTORY is required for the %s index backend", backend)
+	case backend == gh.StateBackendActionsCache && (state.CacheURL == "" || state.CacheToken == ""):
+		return "", gh.StateBackendConfig{}, fmt.Errorf("the %s index backend needs ACTIONS_RESULTS_URL and ACTIONS_RUNTIME_TOKEN in the environment", backend)
+	}
+	return backend, state, nil
+}
+
+// parseSearchFilter reads INPUT_MATCH_TYPES, INPUT_MATCH_STATES,
+// INPUT_INCLUDE_LABELS, INPUT_EXCLUDE_LABELS and INPUT_IGNORE_CLOSED_OLDER_THAN.
+func parseSearchFilter(getenv func(string) string, now time.Time) (store.SearchFilter, error) {
+	filter := store.SearchFilter{
+		Types:         splitList(getenv("INPUT_MATCH_TYPES")),
+		States:        splitList(getenv("INPUT_MATCH_STATES")),
+		IncludeLabels: splitList(getenv("INPUT_INCLUDE_LABELS")),
+		ExcludeLabels: splitList(getenv("INPUT_EXCLUDE_LABELS")),
+	}
+	for _, kind := range filter.Types {
+		if kind != "issue" && kind != "pr" {
+			return store.SearchFilter{}, fmt.Errorf("INPUT_MATCH_TYPES entries must be issue or pr, got %q", kind)
+		}
+	}
+	for _, state := range filter.States {
+		if state != "open" && state != "closed" && state != "merged" {
+			return store.SearchFilter{}, fmt.Errorf("INPUT_MATCH_STATES entries must be open, closed or merged, got %q", state)
+		}
+	}
+	age, err := parseAgeInput(getenv("INPUT_IGNORE_CLOSED_OLDER_THAN"))
+	if err != nil {
+		return store.SearchFilter{}, fmt.Errorf("parse INPUT_IGNORE_CLOSED_OLDER_THAN: %w", err)
+	}
+	if age > 0 {
+		filter.ClosedAfter = now.Add(-age)
+	}
+	return filter, nil
+}
+
+// splitList reads a comma-separated input, dropping blanks. Matching is
+// case-insensitive, so entries are lowercased.
+func splitList(raw string) []string {
+	var out []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// parseAgeInput reads ages like "365d", "8w" or "72h". Empty or "0" disables
+// the limit.
+func parseAgeInput(raw string) (time.Duration, error) {
+	raw = strings.TrimSpace(strings.ToLower(raw))
+	if raw == "" || raw == "0" {
+		return 0, nil
+	}
+	unit := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour}[raw[len(raw)-1]]
+	if unit == 0 {
+		age, err := time.ParseDuration(raw)
+		if err != nil || age < 0 {
+			return 0, fmt.Errorf("age %q must look like 365d, 8w or 72h", raw)
+		}
+		return age, nil
+	}
+	count, err := strconv.Atoi(raw[:len(raw)-1])
+	if err != nil || count < 0 {
+		return 0, fmt.Errorf("age %q must look like 365d, 8w or 72h", raw)
+	}
+	return time.Duration(count) * unit, nil
+}
+
+// parseEmbeddingProvider validates INPUT_EMBEDDING_PROVIDER and reads the API key
+// from the env var named by INPUT_EMBEDDING_API_KEY_ENV, so secrets never pass
+// through action inputs. GitHub Models defaults to the workflow token.
+func parseEmbeddingProvider(getenv func(string) string) (string, string, error) {
+	provider := embed.NormalizeProvider(getenv("INPUT_EMBEDDING_PROVIDER"))
+	known := false
+	for _, name := range embed.ProviderNames() {
+		if name == provider {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return "", "", fmt.Errorf("INPUT_EMBEDDING_PROVIDER must be one of %s", strings.Join(embed.ProviderNames(), ", "))
+	}
+
+	keyEnv := strings.TrimSpace(getenv("INPUT_EMBEDDING_API_KEY_ENV"))
+	if keyEnv == "" && provider == embed.ProviderGitHubModels {
+		keyEnv = "GITHUB_TOKEN"
+	}
+	if keyEnv == "" {
+		return provider, "", nil
+	}
+
+	apiKey := strings.TrimSpace(getenv(keyEnv))
+	if apiKey == "" {
+		return "", "", fmt.Errorf("missing required env %s (from INPUT_EMBEDDING_API_KEY_ENV)", keyEnv)
+	}
+	return provider, apiKey, nil
+}
+
 func parseFloatInput(raw string, fallback float64) (float64, error) {
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
@@ -1,6 +1,19 @@
 package main
 
-import "testing"
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	gh "vector-triage/internal/github"
+	"vector-triage/internal/rerank"
+	"vector-triage/internal/store"
+)
 
 func TestParseConfigFromEnv_Defaults(t *testing.T) {
 	t.Helper()
@@ -19,6 +32,9 @@ func TestParseConfigFromEnv_Defaults(t *testing.T) {
 	if cfg.SimilarityThreshold != 0.75 || cfg.DuplicateThreshold != 0.92 || cfg.MaxResults != 5 || cfg.IndexBranch != "triage-index" {
 		t.Fatalf("unexpected defaults: %+v", cfg)
 	}
+	if cfg.Reranker != rerank.NameHeuristic || cfg.RerankTopN != rerank.DefaultTopN || cfg.RerankThreshold != rerank.DefaultThreshold {
+		t.Fatalf("unexpected rerank defaults: %+v", cfg)
+	}
 }
 
 func TestParseConfigFromEnv_CustomValues(t *testing.T) {
@@ -33,6 +49,8 @@ func TestParseConfigFromEnv_CustomValues(t *testing.T) {
 		"INPUT_DUPLICATE_THRESHOLD":  "0.95",
 		"INPUT_MAX_RESULTS":          "10",
 		"INPUT_INDEX_BRANCH":         "my-index",
+		"INPUT_RECENCY_HALF_LIFE":    "180d",
+		"INPUT_OPEN_BOOST":           "0.25",
 	}
 
 	cfg, err := parseConfigFromEnv(mapEnv(env))
@@ -42,6 +60,9 @@ func TestParseConfigFromEnv_CustomValues(t *testing.T) {
 	if cfg.SimilarityThreshold != 0.8 || cfg.DuplicateThreshold != 0.95 || cfg.MaxResults != 10 || cfg.IndexBranch != "my-index" {
 		t.Fatalf("unexpected config values: %+v", cfg)
 	}
+	if cfg.Recency.HalfLife != 180*24*time.Hour || cfg.Recency.OpenBoost != 0.25 {
+		t.Fatalf("unexpected recency config: %+v", cfg.Recency)
+	}
 }
 
 func TestParseConfigFromEnv_InvalidValues(t *testing.T) {
@@ -68,6 +89,36 @@ func TestParseConfigFromEnv_InvalidValues(t *testing.T) {
 	}
 }
 
+func TestParseBackfillConfigFromEnv_DoesNotRequireEvent(t *testing.T) {
+	t.Helper()
+
+	env := map[string]string{
+		"GITHUB_TOKEN":      "tkn",
+		"GITHUB_REPOSITORY": "acme/repo",
+	}
+
+	if _, err := parseBackfillConfigFromEnv(mapEnv(env)); err != nil {
+		t.Fatalf("parseBackfillConfigFromEnv() error = %v", err)
+	}
+	if _, err := parseConfigFromEnv(mapEnv(env)); err == nil {
+		t.Fatalf("parseConfigFromEnv() expected missing event env error")
+	}
+}
+
+func TestResolveCommand(t *testing.T) {
+	t.Helper()
+
+	if got := resolveCommand(nil, mapEnv(nil)); got != commandRun {
+		t.Fatalf("resolveCommand(default) = %q, want %q", got, commandRun)
+	}
+	if got := resolveCommand([]string{"backfill"}, mapEnv(nil)); got != commandBackfill {
+		t.Fatalf("resolveCommand(args) = %q, want %q", got, commandBackfill)
+	}
+	if got := resolveCommand(nil, mapEnv(map[string]string{"INPUT_COMMAND": "backfill"})); got != commandBackfill {
+		t.Fatalf("resolveCommand(env) = %q, want %q", got, commandBackfill)
+	}
+}
+
 func mapEnv(values map[string]string) func(string) string {
 	return func(key string) string {
 		return values[key]
@@ -84,3 +135,270 @@ func merge(base map[string]string, extra map[string]string) map[string]string {
 	}
 	return out
 }
+
+func TestRunEvalCommand_RejectsActionInput(t *testing.T) {
+	t.Helper()
+
+	// With INPUT_COMMAND=eval there are no argv flags to read the index from.
+	err := runEvalCommand(context.Background(), nil, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "not an action command") {
+		t.Fatalf("runEvalCommand(no args) error = %v, want action command rejection", err)
+	}
+	if err := runEvalCommand(context.Background(), []string{"eval"}, io.Discard); err == nil || !strings.Contains(err.Error(), "requires -db") {
+		t.Fatalf("runEvalCommand(eval) error = %v, want missing -db", err)
+	}
+}
+
+func TestParseEvalArgs(t *testing.T) {
+	t.Helper()
+
+	opts, err := parseEvalArgs([]string{"-db", "index.db", "-format", "JSON", "-thresholds", "0.8, 0.9"})
+	if err != nil {
+		t.Fatalf("parseEvalArgs() error = %v", err)
+	}
+	if opts.DBPath != "index.db" || opts.Format != "json" || opts.Depth != 20 || len(opts.Thresholds) != 2 || opts.Thresholds[1] != 0.9 {
+		t.Fatalf("unexpected eval options: %+v", opts)
+	}
+	if opts.Fusion == nil || opts.Fusion.Name() != store.FusionRRF {
+		t.Fatalf("default eval fusion = %v, want rrf", opts.Fusion)
+	}
+
+	opts, err = parseEvalArgs([]string{"-db", "index.db", "-fusion", "linear", "-fusion-weights", "vector=0.7,fts=0.3"})
+	if err != nil || opts.Fusion.Name() != store.FusionLinear {
+		t.Fatalf("parseEvalArgs(linear) = %+v, %v", opts, err)
+	}
+
+	for _, args := range [][]string{
+		{},
+		{"-db", "x.db", "-format", "csv"},
+		{"-db", "x.db", "-thresholds", "1.5"},
+		{"-db", "x.db", "-depth", "0"},
+		{"-db", "x.db", "-fusion", "borda"},
+		{"-db", "x.db", "-fusion-weights", "title=2"},
+		{"-db", "x.db", "-rrf-k", "0"},
+	} {
+		if _, err := parseEvalArgs(args); err == nil {
+			t.Fatalf("expected error for args %v", args)
+		}
+	}
+}
+
+func TestLoadLabeledPairs(t *testing.T) {
+	t.Helper()
+
+	lookup := func(ctx context.Context, number int) (string, bool, error) {
+		_ = ctx
+		if number == 4 {
+			return "pr/4", true, nil
+		}
+		return "", false, nil
+	}
+
+	input := "{\"a\":\"issue/1\",\"b\":\"#4\",\"verdict\":\"duplicate\"}\n\n{\"a\":\"issue/1\",\"b\":\"issue/2\",\"verdict\":\"not_duplicate\"}\n"
+	pairs, err := loadLabeledPairs(context.Background(), strings.NewReader(input), lookup)
+	if err != nil {
+		t.Fatalf("loadLabeledPairs() error = %v", err)
+	}
+	if len(pairs) != 2 || pairs[0].B != "pr/4" || !pairs[0].Duplicate || pairs[1].Duplicate {
+		t.Fatalf("unexpected pairs: %+v", pairs)
+	}
+
+	if _, err := loadLabeledPairs(context.Background(), strings.NewReader(`{"a":"issue/1","b":"#7","verdict":"duplicate"}`), lookup); err == nil {
+		t.Fatalf("expected error for unindexed #7")
+	}
+	if _, err := loadLabeledPairs(context.Background(), strings.NewReader(`{"a":"issue/1","b":"issue/2","verdict":"maybe"}`), lookup); err == nil {
+		t.Fatalf("expected error for unknown verdict")
+	}
+}
+
+func TestParseConfig_EmbeddingProvider(t *testing.T) {
+	t.Helper()
+
+	base := map[string]string{"GITHUB_TOKEN": "tkn", "GITHUB_REPOSITORY": "acme/repo"}
+
+	cfg, err := parseBackfillConfigFromEnv(mapEnv(base))
+	if err != nil {
+		t.Fatalf("parseBackfillConfigFromEnv() error = %v", err)
+	}
+	if cfg.EmbeddingProvider != "github-models" || cfg.EmbeddingAPIKey != "tkn" || cfg.EmbeddingDimensions != 1536 {
+		t.Fatalf("unexpected default provider config: %+v", cfg)
+	}
+
+	cfg, err = parseBackfillConfigFromEnv(mapEnv(merge(base, map[string]string{
+		"INPUT_EMBEDDING_PROVIDER":    "Azure-OpenAI",
+		"INPUT_EMBEDDING_ENDPOINT":    "https://r.openai.azure.com/openai/deployments/emb",
+		"INPUT_EMBEDDING_API_KEY_ENV": "AZURE_KEY",
+		"AZURE_KEY":                   "secret",
+	})))
+	if err != nil {
+		t.Fatalf("parseBackfillConfigFromEnv(azure) error = %v", err)
+	}
+	if cfg.EmbeddingProvider != "azure-openai" || cfg.EmbeddingAPIKey != "secret" || cfg.EmbeddingEndpoint == "" || cfg.EmbeddingDimensionsSet {
+		t.Fatalf("unexpected azure config: %+v", cfg)
+	}
+
+	cfg, err = parseBackfillConfigFromEnv(mapEnv(merge(base, map[string]string{
+		"INPUT_EMBEDDING_PROVIDER": "ollama",
+		"INPUT_EMBEDDING_MODEL":    "nomic-embed-text:v1.5",
+	})))
+	if err != nil || cfg.EmbeddingAPIKey != "" {
+		t.Fatalf("ollama without key env should parse with no key: %+v, %v", cfg, err)
+	}
+	if cfg.EmbeddingDimensions != 768 {
+		t.Fatalf("ollama dimensions = %d, want the model's 768 by default", cfg.EmbeddingDimensions)
+	}
+
+	for _, extra := range []map[string]string{
+		{"INPUT_EMBEDDING_PROVIDER": "bedrock"},
+		{"INPUT_EMBEDDING_PROVIDER": "openai", "INPUT_EMBEDDING_API_KEY_ENV": "UNSET_KEY"},
+		{"INPUT_EMBEDDING_DIMENSIONS": "0"},
+		{"INPUT_EMBEDDING_PROVIDER": "ollama", "INPUT_EMBEDDING_MODEL": "my-custom-embedder"},
+		{"INPUT_CHUNK_SCORING": "mean"},
+		{"INPUT_FUSION_STRATEGY": "borda"},
+		{"INPUT_FUSION_WEIGHTS": "fts"},
+		{"INPUT_RRF_K": "0"},
+		{"INPUT_CALIBRATION_METHOD": "beta"},
+		{"INPUT_RERANKER": "cohere"},
+		{"INPUT_RERANK_TOP_N": "0"},
+		{"INPUT_RERANK_THRESHOLD": "1.5"},
+		{"INPUT_MATCH_TYPES": "discussion"},
+		{"INPUT_MATCH_STATES": "draft"},
+		{"INPUT_IGNORE_CLOSED_OLDER_THAN": "soon"},
+		{"INPUT_RECENCY_HALF_LIFE": "-30d"},
+		{"INPUT_OPEN_BOOST": "-0.5"},
+	} {
+		if _, err := parseBackfillConfigFromEnv(mapEnv(merge(base, extra))); err == nil {
+			t.Fatalf("expected error for %v", extra)
+		}
+	}
+}
+
+func TestParseSearchFilter(t *testing.T) {
+	t.Helper()
+
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	filter, err := parseSearchFilter(mapEnv(map[string]string{
+		"INPUT_MATCH_TYPES":              "issue",
+		"INPUT_MATCH_STATES":             " Open, closed ",
+		"INPUT_EXCLUDE_LABELS":           "wontfix,,invalid",
+		"INPUT_IGNORE_CLOSED_OLDER_THAN": "2w",
+	}), now)
+	if err != nil {
+		t.Fatalf("parseSearchFilter() error = %v", err)
+	}
+	if !slices.Equal(filter.Types, []string{"issue"}) || !slices.Equal(filter.States, []string{"open", "closed"}) {
+		t.Fatalf("unexpected types/states: %+v", filter)
+	}
+	if !slices.Equal(filter.ExcludeLabels, []string{"wontfix", "invalid"}) || filter.IncludeLabels != nil {
+		t.Fatalf("unexpected labels: %+v", filter)
+	}
+	if !filter.ClosedAfter.Equal(now.Add(-14 * 24 * time.Hour)) {
+		t.Fatalf("ClosedAfter = %v", filter.ClosedAfter)
+	}
+
+	for raw, want := range map[string]time.Duration{"": 0, "0": 0, "365d": 365 * 24 * time.Hour, "72h": 72 * time.Hour} {
+		got, err := parseAgeInput(raw)
+		if err != nil || got != want {
+			t.Fatalf("parseAgeInput(%q) = %v, %v; want %v", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"d", "-3d", "1y"} {
+		if _, err := parseAgeInput(raw); err == nil {
+			t.Fatalf("expected error for age %q", raw)
+		}
+	}
+}
+
+func TestParseStateInputs(t *testing.T) {
+	t.Helper()
+
+	backend, state, err := parseStateInputs(mapEnv(nil))
+	if err != nil || backend != gh.StateBackendBranch || state.Transport != gh.BranchTransportGit {
+		t.Fatalf("parseStateInputs(defaults) = %q, %+v, %v", backend, state, err)
+	}
+	backend, state, err = parseStateInputs(mapEnv(map[string]string{
+		"INPUT_INDEX_BACKEND":   "Local",
+		"INPUT_INDEX_DIRECTORY": "/srv/triage",
+	}))
+	if err != nil || backend != gh.StateBackendLocal || state.Directory != "/srv/triage" {
+		t.Fatalf("parseStateInputs(local) = %q, %+v, %v", backend, state, err)
+	}
+
+	// A working config proves the backend is reachable from every command.
+	cfg, err := parseBackfillConfigFromEnv(mapEnv(map[string]string{
+		"GITHUB_TOKEN":          "tkn",
+		"GITHUB_REPOSITORY":     "acme/repo",
+		"INPUT_INDEX_BACKEND":   "local",
+		"INPUT_INDEX_DIRECTORY": t.TempDir(),
+	}))
+	if err != nil {
+		t.Fatalf("parseBackfillConfigFromEnv() error = %v", err)
+	}
+	manager, err := newStateManager(cfg, "acme", "repo")
+	if err != nil {
+		t.Fatalf("newStateManager() error = %v", err)
+	}
+	if _, ok := manager.Backend.(*gh.LocalBackend); !ok {
+		t.Fatalf("backend = %T, want *gh.LocalBackend", manager.Backend)
+	}
+
+	for _, env := range []map[string]string{
+		{"INPUT_INDEX_BACKEND": "s3"},
+		{"INPUT_BRANCH_TRANSPORT": "svn"},
+		{"INPUT_INDEX_BACKEND": "local"},
+		{"INPUT_INDEX_BACKEND": "actions-cache", "ACTIONS_RESULTS_URL": "https://results.example/"},
+	} {
+		if _, _, err := parseStateInputs(mapEnv(env)); err == nil {
+			t.Fatalf("expected error for %v", env)
+		}
+	}
+}
+
+func TestRun_PlainCommentLeavesIndexUntouched(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+	eventPath := filepath.Join(dir, "event.json")
+	payload := `{"action":"created","issue":{"number":7,"title":"login","body":"hangs"},"comment":{"id":1,"body":"Thanks, same here!","user":{"login":"someone"}}}`
+	if err := os.WriteFile(eventPath, []byte(payload), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	indexDir := filepath.Join(dir, "index")
+
+	err := run(context.Background(), mapEnv(map[string]string{
+		"GITHUB_TOKEN":             "tkn",
+		"GITHUB_EVENT_NAME":        "issue_comment",
+		"GITHUB_EVENT_PATH":        eventPath,
+		"GITHUB_REPOSITORY":        "acme/repo",
+		"INPUT_EMBEDDING_PROVIDER": "offline",
+		"INPUT_INDEX_BACKEND":      "local",
+		"INPUT_INDEX_DIRECTORY":    indexDir,
+	}))
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if _, err := os.Stat(indexDir); !os.IsNotExist(err) {
+		t.Fatalf("a comment without a /triage command should not write the index, stat err = %v", err)
+	}
+}
+
+func TestNewEmbedder_OfflineNeedsNoKey(t *testing.T) {
+	t.Helper()
+
+	cfg, err := parseBackfillConfigFromEnv(mapEnv(map[string]string{
+		"GITHUB_TOKEN":             "tkn",
+		"GITHUB_REPOSITORY":        "acme/repo",
+		"INPUT_EMBEDDING_PROVIDER": "offline",
+	}))
+	if err != nil {
+		t.Fatalf("parseBackfillConfigFromEnv() error = %v", err)
+	}
+	emb, err := newEmbedder(cfg)
+	if err != nil {
+		t.Fatalf("newEmbedder() error = %v", err)
+	}
+	vec, err := emb.Embed(context.Background(), "offline works")
+	if err != nil || len(vec) != emb.Dimensions() {
+		t.Fatalf("offline Embed() = %d dims, %v", len(vec), err)
+	}
+}
@@ -0,0 +1,83 @@
+package embed
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const DefaultAzureAPIVersion = "2024-02-01"
+
+// NewAzureOpenAIEmbedder targets an Azure OpenAI deployment URL such as
+// https://<resource>.openai.azure.com/openai/deployments/<deployment>/embeddings.
+// The deployment picks the model; Model labels stored vectors and defaults to
+// the deployment name. `dimensions` is sent when it was configured, or when
+// Model names a text-embedding-3 model.
+func NewAzureOpenAIEmbedder(cfg ProviderConfig) (Embedder, error) {
+	raw := strings.TrimSpace(cfg.Endpoint)
+	if raw == "" {
+		return nil, errors.New("azure openai deployment endpoint is required")
+	}
+	if strings.TrimSpace(cfg.APIKey) == "" {
+		return nil, errors.New("azure openai api key is required")
+	}
+
+	endpoint, deployment, err := azureDeploymentURL(raw)
+	if err != nil {
+		return nil, err
+	}
+	model := strings.TrimSpace(cfg.Model)
+	if model == "" {
+		model = deployment
+	}
+
+	h := newHTTPEmbedder(cfg, endpoint, model)
+	h.headers["api-key"] = strings.TrimSpace(cfg.APIKey)
+	h.encode = func(model string, dimensions int, inputs []string) any {
+		if !cfg.DimensionsSet {
+			dimensions = requestDimensions(model, dimensions)
+		}
+		return azureEmbeddingRequest{Input: inputs, Dimensions: dimensions}
+	}
+	h.decode = decodeOpenAIEmbeddings
+	return h, nil
+}
+
+// azureDeploymentURL appends /embeddings and a default api-version when the
+// configured URL omits them, and returns the deployment name.
+func azureDeploymentURL(raw string) (string, string, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", "", fmt.Errorf("invalid azure openai endpoint %q", raw)
+	}
+
+	path := strings.TrimRight(u.Path, "/")
+	if !strings.HasSuffix(path, "/embeddings") {
+		path += "/embeddings"
+	}
+	u.Path = path
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	deployment := ""
+	for i := 0; i+1 < len(segments); i++ {
+		if segments[i] == "deployments" {
+			deployment = segments[i+1]
+		}
+	}
+	if deployment == "" || deployment == "embeddings" {
+		return "", "", fmt.Errorf("azure openai endpoint %q must include /openai/deployments/<deployment>", raw)
+	}
+
+	query := u.Query()
+	if query.Get("api-version") == "" {
+		query.Set("api-version", DefaultAzureAPIVersion)
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), deployment, nil
+}
+
+type azureEmbeddingRequest struct {
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
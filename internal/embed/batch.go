@@ -0,0 +1,160 @@
+package embed
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultMaxInputTokens is the per-input limit of the OpenAI embedding models.
+	DefaultMaxInputTokens = 8191
+	// DefaultMaxBatchTokens keeps a sub-request well under provider request-size caps.
+	DefaultMaxBatchTokens = 64000
+	DefaultMaxBatchItems  = 256
+	DefaultConcurrency    = 4
+)
+
+// BatchLimits bounds how EmbedBatch splits its inputs. Token counts use the
+// cl100k_base tokenizer, which is exact for OpenAI models and a close
+// estimate for others. Zero values select the defaults.
+type BatchLimits struct {
+	MaxInputTokens int
+	MaxBatchTokens int
+	MaxBatchItems  int
+	Concurrency    int
+}
+
+func (l BatchLimits) normalized() BatchLimits {
+	if l.MaxInputTokens <= 0 {
+		l.MaxInputTokens = DefaultMaxInputTokens
+	}
+	if l.MaxBatchTokens <= 0 {
+		l.MaxBatchTokens = DefaultMaxBatchTokens
+	}
+	if l.MaxBatchTokens < l.MaxInputTokens {
+		l.MaxInputTokens = l.MaxBatchTokens
+	}
+	if l.MaxBatchItems <= 0 {
+		l.MaxBatchItems = DefaultMaxBatchItems
+	}
+	if l.Concurrency <= 0 {
+		l.Concurrency = DefaultConcurrency
+	}
+	return l
+}
+
+// prepareInputs applies the character cap and then the token cap to every
+// input, returning the trimmed texts and their token counts.
+func prepareInputs(texts []string, maxChars, maxTokens int) ([]string, []int, error) {
+	tokenizer, err := CL100K()
+	if err != nil {
+		return nil, nil, fmt.Errorf("load tokenizer: %w", err)
+	}
+
+	inputs := make([]string, len(texts))
+	counts := make([]int, len(texts))
+	for i, text := range texts {
+		// JSON encoding replaces each invalid byte with U+FFFD; do it up front
+		// so the token count matches what the provider receives.
+		text = toValidUTF8(text)
+		inputs[i], counts[i] = tokenizer.Truncate(truncateForEmbedding(text, maxChars), maxTokens)
+	}
+	return inputs, counts, nil
+}
+
+// planBatches groups consecutive inputs into sub-requests under the item and
+// token limits. It returns half-open [start, end) index ranges in input order.
+func planBatches(counts []int, limits BatchLimits) [][2]int {
+	var ranges [][2]int
+	start, tokens := 0, 0
+	for i, count := range counts {
+		if i > start && (i-start >= limits.MaxBatchItems || tokens+count > limits.MaxBatchTokens) {
+			ranges = append(ranges, [2]int{start, i})
+			start, tokens = i, 0
+		}
+		tokens += count
+	}
+	if start < len(counts) {
+		ranges = append(ranges, [2]int{start, len(counts)})
+	}
+	return ranges
+}
+
+// embedInBatches sends each planned sub-request through send, at most
+// limits.Concurrency at a time, and stitches the vectors back in input order.
+// The first failure cancels the sub-requests still in flight.
+func embedInBatches(ctx context.Context, texts []string, maxChars int, limits BatchLimits, send func(ctx context.Context, inputs []string) ([][]float32, error)) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+
+	limits = limits.normalized()
+	inputs, counts, err := prepareInputs(texts, maxChars, limits.MaxInputTokens)
+	if err != nil {
+		return nil, err
+	}
+	ranges := planBatches(counts, limits)
+	if len(ranges) == 1 {
+		return send(ctx, inputs)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	out := make([][]float32, len(inputs))
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	slots := make(chan struct{}, limits.Concurrency)
+	for _, r := range ranges {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			vectors, err := send(ctx, inputs[start:end])
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			copy(out[start:end], vectors)
+		}(r[0], r[1])
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func toValidUTF8(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	buf := make([]rune, 0, len(s))
+	for _, r := range s {
+		buf = append(buf, r)
+	}
+	return string(buf)
+}
@@ -0,0 +1,132 @@
+package embed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestOpenAICompatibleEmbedder_SplitsBatchesAndKeepsOrder(t *testing.T) {
+	t.Helper()
+
+	var (
+		mu        sync.Mutex
+		sizes     []int
+		active    int32
+		maxActive int32
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			seen := atomic.LoadInt32(&maxActive)
+			if n <= seen || atomic.CompareAndSwapInt32(&maxActive, seen, n) {
+				break
+			}
+		}
+
+		var req embeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+			return
+		}
+		mu.Lock()
+		sizes = append(sizes, len(req.Input))
+		mu.Unlock()
+
+		// Answer in reverse so the client has to reorder by index.
+		var items []string
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			var id float64
+			if _, err := fmt.Sscanf(req.Input[i], "item %g", &id); err != nil {
+				t.Errorf("unexpected input %q", req.Input[i])
+			}
+			items = append(items, fmt.Sprintf(`{"index":%d,"embedding":[%g,0]}`, i, id))
+		}
+		_, _ = w.Write([]byte(`{"data":[` + strings.Join(items, ",") + `]}`))
+	}))
+	defer srv.Close()
+
+	emb, err := NewOpenAICompatibleEmbedder(ProviderConfig{
+		Endpoint:   srv.URL,
+		Dimensions: 2,
+		Batch:      BatchLimits{MaxBatchItems: 3, Concurrency: 2},
+	})
+	if err != nil {
+		t.Fatalf("NewOpenAICompatibleEmbedder() error = %v", err)
+	}
+
+	texts := make([]string, 10)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("item %d", i)
+	}
+	vectors, err := emb.EmbedBatch(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("EmbedBatch() error = %v", err)
+	}
+	for i, vec := range vectors {
+		if vec[0] != float32(i) {
+			t.Fatalf("vector %d = %v, results not stitched in input order", i, vec)
+		}
+	}
+	if len(sizes) != 4 {
+		t.Fatalf("sub-requests = %v, want 4 of at most 3 items", sizes)
+	}
+	if got := atomic.LoadInt32(&maxActive); got > 2 {
+		t.Fatalf("max concurrent requests = %d, want <= 2", got)
+	}
+}
+
+func TestPrepareInputsAndPlanBatches_RespectTokenLimits(t *testing.T) {
+	t.Helper()
+
+	cjk := strings.Repeat("日本語のテキスト", 200)
+	inputs, counts, err := prepareInputs([]string{cjk, "hello world", "bad \xff byte"}, DefaultMaxInputChars, 100)
+	if err != nil {
+		t.Fatalf("prepareInputs() error = %v", err)
+	}
+	if counts[0] > 100 || counts[1] != 2 {
+		t.Fatalf("token counts = %v, want first capped at 100 and second = 2", counts)
+	}
+	if !strings.HasPrefix(cjk, inputs[0]) || len(inputs[0]) >= len(cjk) {
+		t.Fatalf("CJK input was not truncated to a prefix")
+	}
+	if inputs[2] != "bad � byte" {
+		t.Fatalf("invalid UTF-8 not replaced: %q", inputs[2])
+	}
+
+	ranges := planBatches([]int{40, 40, 40, 90, 5}, BatchLimits{MaxBatchTokens: 100, MaxBatchItems: 10})
+	want := [][2]int{{0, 2}, {2, 3}, {3, 5}}
+	if fmt.Sprint(ranges) != fmt.Sprint(want) {
+		t.Fatalf("planBatches() = %v, want %v", ranges, want)
+	}
+}
+
+func TestEmbedInBatches_FirstErrorCancelsRemaining(t *testing.T) {
+	t.Helper()
+
+	boom := errors.New("boom")
+	var calls int32
+	_, err := embedInBatches(context.Background(), []string{"a", "b", "c", "d"}, DefaultMaxInputChars,
+		BatchLimits{MaxBatchItems: 1, Concurrency: 1},
+		func(ctx context.Context, inputs []string) ([][]float32, error) {
+			atomic.AddInt32(&calls, 1)
+			if inputs[0] == "b" {
+				return nil, boom
+			}
+			return [][]float32{{1}}, ctx.Err()
+		})
+	if !errors.Is(err, boom) {
+		t.Fatalf("embedInBatches() error = %v, want boom", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("sub-requests = %d, want 2 (stop after failure)", got)
+	}
+}
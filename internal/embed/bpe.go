@@ -0,0 +1,319 @@
+package embed
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"container/heap"
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// cl100kRanks is OpenAI's cl100k_base merge table (MIT licensed, as shipped
+// with tiktoken): one "base64(token) rank" pair per line, gzipped.
+//
+//go:embed cl100k_base.tiktoken.gz
+var cl100kRanks []byte
+
+// BPETokenizer is a byte-level BPE tokenizer. The cl100k instance produces
+// the same token ids as tiktoken's cl100k_base, the encoding used by the
+// text-embedding-3 and ada-002 models, so input limits can be enforced in
+// tokens rather than guessed from character counts.
+type BPETokenizer struct {
+	ranks   map[string]int
+	decoder [][]byte
+}
+
+var (
+	cl100kOnce sync.Once
+	cl100k     *BPETokenizer
+	cl100kErr  error
+)
+
+// CL100K returns the shared cl100k_base tokenizer, loading its merge table on first use.
+func CL100K() (*BPETokenizer, error) {
+	cl100kOnce.Do(func() {
+		cl100k, cl100kErr = loadBPETokenizer(cl100kRanks)
+	})
+	return cl100k, cl100kErr
+}
+
+func loadBPETokenizer(compressed []byte) (*BPETokenizer, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("open bpe ranks: %w", err)
+	}
+	defer zr.Close()
+
+	t := &BPETokenizer{ranks: make(map[string]int, 100256)}
+	scanner := bufio.NewScanner(zr)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		sep := bytes.IndexByte(line, ' ')
+		if sep <= 0 {
+			return nil, fmt.Errorf("malformed bpe rank line %q", line)
+		}
+		token, err := base64.StdEncoding.DecodeString(string(line[:sep]))
+		if err != nil {
+			return nil, fmt.Errorf("decode bpe token: %w", err)
+		}
+		rank, err := strconv.Atoi(string(line[sep+1:]))
+		if err != nil || rank < 0 {
+			return nil, fmt.Errorf("malformed bpe rank %q", line[sep+1:])
+		}
+		t.ranks[string(token)] = rank
+		for len(t.decoder) <= rank {
+			t.decoder = append(t.decoder, nil)
+		}
+		t.decoder[rank] = token
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read bpe ranks: %w", err)
+	}
+	if len(t.ranks) == 0 {
+		return nil, fmt.Errorf("bpe rank table is empty")
+	}
+	return t, nil
+}
+
+// Encode returns the token ids for text. Special tokens such as
+// <|endoftext|> are encoded as plain text, which is what the embeddings API does.
+func (t *BPETokenizer) Encode(text string) []int {
+	tokens, _ := t.encode(text, -1)
+	return tokens
+}
+
+// Count returns the number of tokens in text.
+func (t *BPETokenizer) Count(text string) int {
+	return len(t.Encode(text))
+}
+
+// Decode turns token ids back into text. Unknown ids are skipped.
+func (t *BPETokenizer) Decode(tokens []int) string {
+	var buf bytes.Buffer
+	for _, id := range tokens {
+		if id >= 0 && id < len(t.decoder) {
+			buf.Write(t.decoder[id])
+		}
+	}
+	return buf.String()
+}
+
+// Truncate cuts text to at most maxTokens tokens and returns the kept prefix
+// with its token count. The cut lands on a pre-token boundary, so the prefix
+// is valid UTF-8 and encodes to exactly the counted tokens.
+func (t *BPETokenizer) Truncate(text string, maxTokens int) (string, int) {
+	if maxTokens <= 0 {
+		return "", 0
+	}
+	tokens, end := t.encode(text, maxTokens)
+	return text[:end], len(tokens)
+}
+
+// encode tokenizes text piece by piece. With limit >= 0 it stops before the
+// piece that would exceed limit and reports how many bytes were consumed.
+func (t *BPETokenizer) encode(text string, limit int) ([]int, int) {
+	var tokens []int
+	for pos := 0; pos < len(text); {
+		end := pos + cl100kPieceLen(text[pos:])
+		piece := t.bytePairEncode(text[pos:end])
+		if limit >= 0 && len(tokens)+len(piece) > limit {
+			return tokens, pos
+		}
+		tokens = append(tokens, piece...)
+		pos = end
+	}
+	return tokens, len(text)
+}
+
+// bytePairEncode repeatedly merges the adjacent pair with the lowest rank,
+// leftmost first, the same greedy procedure tiktoken uses. Parts form a linked
+// list and candidate merges sit in a heap, so long runs of punctuation or
+// whitespace stay O(n log n) instead of rescanning the piece after every merge.
+func (t *BPETokenizer) bytePairEncode(piece string) []int {
+	if rank, ok := t.ranks[piece]; ok {
+		return []int{rank}
+	}
+
+	// Part i covers piece[start[i]:start[next[i]]]; the sentinel part n has start len(piece).
+	n := len(piece)
+	start := make([]int, n+1)
+	next := make([]int, n+1)
+	prev := make([]int, n+1)
+	for i := 0; i <= n; i++ {
+		start[i], next[i], prev[i] = i, i+1, i-1
+	}
+
+	candidates := &mergeHeap{}
+	push := func(i int) {
+		if i < 0 || next[i] >= n {
+			return
+		}
+		end := start[next[next[i]]]
+		if rank, ok := t.ranks[piece[start[i]:end]]; ok {
+			heap.Push(candidates, mergeCandidate{rank: rank, part: i, end: end})
+		}
+	}
+	for i := 0; i < n-1; i++ {
+		push(i)
+	}
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(mergeCandidate)
+		// Skip candidates made stale by an earlier merge of either part.
+		if prev[c.part] == -2 || next[c.part] >= n || start[next[next[c.part]]] != c.end {
+			continue
+		}
+		removed := next[c.part]
+		next[c.part] = next[removed]
+		prev[next[removed]] = c.part
+		prev[removed] = -2
+		push(prev[c.part])
+		push(c.part)
+	}
+
+	var out []int
+	for i := 0; i < n; i = next[i] {
+		out = append(out, t.ranks[piece[start[i]:start[next[i]]]])
+	}
+	return out
+}
+
+type mergeCandidate struct {
+	rank int
+	part int
+	end  int
+}
+
+type mergeHeap []mergeCandidate
+
+func (h mergeHeap) Len() int { return len(h) }
+func (h mergeHeap) Less(i, j int) bool {
+	if h[i].rank != h[j].rank {
+		return h[i].rank < h[j].rank
+	}
+	return h[i].part < h[j].part
+}
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x any)   { *h = append(*h, x.(mergeCandidate)) }
+func (h *mergeHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// cl100kPieceLen returns the byte length of the next pre-token, following
+// cl100k_base's split pattern alternative by alternative:
+//
+//	(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}|
+//	 ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+
+//
+// Go's regexp has no lookahead, hence the hand-written scanner.
+func cl100kPieceLen(s string) int {
+	r, size := utf8.DecodeRuneInString(s)
+
+	if r == '\'' {
+		if n := contractionLen(s[size:]); n > 0 {
+			return size + n
+		}
+	}
+
+	if isLetter(r) {
+		return size + spanLen(s[size:], isLetter)
+	}
+	if r != '\r' && r != '\n' && !isNumber(r) {
+		if next, nextSize := utf8.DecodeRuneInString(s[size:]); size < len(s) && isLetter(next) {
+			return size + nextSize + spanLen(s[size+nextSize:], isLetter)
+		}
+	}
+
+	if isNumber(r) {
+		n := size
+		for digits := 1; digits < 3 && n < len(s); digits++ {
+			next, nextSize := utf8.DecodeRuneInString(s[n:])
+			if !isNumber(next) {
+				break
+			}
+			n += nextSize
+		}
+		return n
+	}
+
+	isSymbol := func(r rune) bool { return !unicode.IsSpace(r) && !isLetter(r) && !isNumber(r) }
+	start := 0
+	if r == ' ' && size < len(s) {
+		if next, _ := utf8.DecodeRuneInString(s[size:]); isSymbol(next) {
+			start = size
+		}
+	}
+	if first, _ := utf8.DecodeRuneInString(s[start:]); isSymbol(first) {
+		n := start + spanLen(s[start:], isSymbol)
+		return n + spanLen(s[n:], isNewline)
+	}
+
+	// Whitespace run: \s*[\r\n]+ ends after its last newline; \s+(?!\S)
+	// leaves the final space to prefix the following word.
+	wsEnd := spanLen(s, unicode.IsSpace)
+	lastNewline := -1
+	for i := 0; i < wsEnd; {
+		ws, wsSize := utf8.DecodeRuneInString(s[i:])
+		if isNewline(ws) {
+			lastNewline = i + wsSize
+		}
+		i += wsSize
+	}
+	if lastNewline > 0 {
+		return lastNewline
+	}
+	if wsEnd == len(s) {
+		return wsEnd
+	}
+	_, lastSize := utf8.DecodeLastRuneInString(s[:wsEnd])
+	if wsEnd-lastSize > 0 {
+		return wsEnd - lastSize
+	}
+	return wsEnd
+}
+
+func contractionLen(s string) int {
+	for _, suffix := range []string{"s", "t", "re", "ve", "m", "ll", "d"} {
+		if len(s) >= len(suffix) && equalFoldASCII(s[:len(suffix)], suffix) {
+			return len(suffix)
+		}
+	}
+	return 0
+}
+
+func equalFoldASCII(a, b string) bool {
+	for i := 0; i < len(a); i++ {
+		if a[i]|0x20 != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func spanLen(s string, keep func(rune) bool) int {
+	n := 0
+	for n < len(s) {
+		r, size := utf8.DecodeRuneInString(s[n:])
+		if !keep(r) {
+			break
+		}
+		n += size
+	}
+	return n
+}
+
+func isLetter(r rune) bool  { return unicode.IsLetter(r) }
+func isNumber(r rune) bool  { return unicode.IsNumber(r) }
+func isNewline(r rune) bool { return r == '\r' || r == '\n' }
@@ -0,0 +1,63 @@
+package embed
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCL100K_MatchesReferenceTokenIDs(t *testing.T) {
+	t.Helper()
+
+	tok, err := CL100K()
+	if err != nil {
+		t.Fatalf("CL100K() error = %v", err)
+	}
+
+	// Reference ids from tiktoken's cl100k_base.
+	cases := map[string][]int{
+		"hello world": {15339, 1917},
+		"Panic in `store.Open()` when   the DB is locked!!\n\n  It's 2024.": {
+			47, 32370, 304, 1595, 4412, 13250, 55358, 994, 256, 279, 6078, 374, 16447, 25833, 220, 1102, 596, 220, 2366, 19, 13,
+		},
+		"日本語のテキスト":      {9080, 22656, 45918, 252, 16144, 57933, 62903, 71634},
+		"<|endoftext|>": {27, 91, 8862, 728, 428, 91, 29},
+	}
+	for text, want := range cases {
+		got := tok.Encode(text)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Encode(%q) = %v, want %v", text, got, want)
+		}
+		if decoded := tok.Decode(got); decoded != text {
+			t.Fatalf("Decode(Encode(%q)) = %q", text, decoded)
+		}
+	}
+}
+
+func TestCL100K_TruncateKeepsValidPrefix(t *testing.T) {
+	t.Helper()
+
+	tok, err := CL100K()
+	if err != nil {
+		t.Fatalf("CL100K() error = %v", err)
+	}
+
+	text := strings.Repeat("日本語のテキスト ", 100)
+	kept, count := tok.Truncate(text, 50)
+	if count > 50 || count != tok.Count(kept) {
+		t.Fatalf("Truncate() count = %d, recount = %d, want <= 50", count, tok.Count(kept))
+	}
+	if !utf8.ValidString(kept) || !strings.HasPrefix(text, kept) || kept == "" {
+		t.Fatalf("Truncate() returned an invalid prefix %q", kept)
+	}
+
+	if kept, count := tok.Truncate("short", 50); kept != "short" || count != 1 {
+		t.Fatalf("Truncate(short) = %q, %d", kept, count)
+	}
+
+	// Long punctuation runs are a single pre-token; merging must stay fast.
+	if n := tok.Count(strings.Repeat("=", 50000)); n == 0 || n > 50000 {
+		t.Fatalf("Count(long run) = %d", n)
+	}
+}
@@ -0,0 +1,93 @@
+package embed
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	DefaultChunkTokens        = 512
+	DefaultChunkOverlapTokens = 64
+	DefaultMaxChunks          = 16
+)
+
+// ChunkOptions controls how long content is split into chunk embeddings.
+// Zero values select the defaults; a negative OverlapTokens disables overlap.
+type ChunkOptions struct {
+	ChunkTokens   int
+	OverlapTokens int
+	MaxChunks     int
+}
+
+func (o ChunkOptions) normalized() ChunkOptions {
+	if o.ChunkTokens <= 0 {
+		o.ChunkTokens = DefaultChunkTokens
+	}
+	if o.OverlapTokens < 0 {
+		o.OverlapTokens = 0
+	} else if o.OverlapTokens == 0 {
+		o.OverlapTokens = DefaultChunkOverlapTokens
+	}
+	if o.OverlapTokens >= o.ChunkTokens {
+		o.OverlapTokens = o.ChunkTokens / 4
+	}
+	if o.MaxChunks <= 0 {
+		o.MaxChunks = DefaultMaxChunks
+	}
+	return o
+}
+
+// SplitChunks cuts text into overlapping windows of about opts.ChunkTokens
+// cl100k tokens, always on pre-token boundaries so no word or rune is split.
+// Text that fits in one window returns nil; the whole-item vector already
+// covers it. Past opts.MaxChunks windows, the first MaxChunks-1 and the last
+// are kept, because logs and stack traces tend to sit at the end of a report.
+func SplitChunks(text string, opts ChunkOptions) ([]string, error) {
+	opts = opts.normalized()
+	tokenizer, err := CL100K()
+	if err != nil {
+		return nil, fmt.Errorf("load tokenizer: %w", err)
+	}
+
+	type span struct{ start, end, tokens int }
+	var spans []span
+	total := 0
+	for pos := 0; pos < len(text); {
+		end := pos + cl100kPieceLen(text[pos:])
+		n := len(tokenizer.bytePairEncode(text[pos:end]))
+		spans = append(spans, span{start: pos, end: end, tokens: n})
+		total += n
+		pos = end
+	}
+	if total <= opts.ChunkTokens {
+		return nil, nil
+	}
+
+	var chunks []string
+	for first := 0; first < len(spans); {
+		last, tokens := first, spans[first].tokens
+		for last+1 < len(spans) && tokens+spans[last+1].tokens <= opts.ChunkTokens {
+			last++
+			tokens += spans[last].tokens
+		}
+		if chunk := strings.TrimSpace(text[spans[first].start:spans[last].end]); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		if last == len(spans)-1 {
+			break
+		}
+
+		// Step back from the window end until the overlap budget is used up.
+		next, overlap := last+1, 0
+		for next-1 > first && overlap+spans[next-1].tokens <= opts.OverlapTokens {
+			next--
+			overlap += spans[next].tokens
+		}
+		first = next
+	}
+
+	if len(chunks) > opts.MaxChunks {
+		chunks = append(chunks[:opts.MaxChunks-1], chunks[len(chunks)-1])
+	}
+	return chunks, nil
+}
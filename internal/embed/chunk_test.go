@@ -0,0 +1,57 @@
+package embed
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSplitChunks_OverlapsAndKeepsTail(t *testing.T) {
+	t.Helper()
+
+	if chunks, err := SplitChunks("short issue body", ChunkOptions{}); err != nil || chunks != nil {
+		t.Fatalf("SplitChunks(short) = %v, %v; want nil", chunks, err)
+	}
+
+	var b strings.Builder
+	for i := 0; i < 400; i++ {
+		fmt.Fprintf(&b, "line %d of the log output\n", i)
+	}
+	b.WriteString("panic: nil pointer dereference in store.Open")
+	text := b.String()
+
+	tok, err := CL100K()
+	if err != nil {
+		t.Fatalf("CL100K() error = %v", err)
+	}
+	chunks, err := SplitChunks(text, ChunkOptions{ChunkTokens: 200, OverlapTokens: 20, MaxChunks: 100})
+	if err != nil {
+		t.Fatalf("SplitChunks() error = %v", err)
+	}
+	if len(chunks) < 2 {
+		t.Fatalf("chunks = %d, want several", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if n := tok.Count(chunk); n > 200 {
+			t.Fatalf("chunk %d has %d tokens, want <= 200", i, n)
+		}
+		if i > 0 && !strings.Contains(chunks[i-1], firstLine(chunk)) {
+			t.Fatalf("chunk %d does not overlap the previous chunk", i)
+		}
+	}
+
+	capped, err := SplitChunks(text, ChunkOptions{ChunkTokens: 200, OverlapTokens: 20, MaxChunks: 3})
+	if err != nil {
+		t.Fatalf("SplitChunks(capped) error = %v", err)
+	}
+	if len(capped) != 3 || capped[0] != chunks[0] || !strings.HasSuffix(capped[2], "store.Open") {
+		t.Fatalf("capped chunks should keep the head and the tail, got %d", len(capped))
+	}
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
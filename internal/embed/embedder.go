@@ -13,4 +13,6 @@ type Embedder interface {
 	Embed(ctx context.Context, text string) ([]float32, error)
 	EmbedBatch(ctx context.Context, texts []string) ([][]float32, error)
 	Dimensions() int
+	// Model names the embedding model so stored vectors can be matched to their producer.
+	Model() string
 }
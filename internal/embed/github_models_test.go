@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"math"
 	"net/http"
 	"strings"
 	"sync/atomic"
@@ -24,11 +25,11 @@ func TestGitHubModelsEmbedder_EmbedSuccess(t *testing.T) {
 		}),
 	}
 
-	emb, err := NewGitHubModelsEmbedder(GitHubModelsConfig{
-		Token:      "token-123",
+	emb, err := NewGitHubModelsEmbedder(ProviderConfig{
+		APIKey:     "token-123",
 		Endpoint:   "https://example.test/embeddings",
 		Model:      DefaultEmbeddingModel,
-		Dimensions: 1536,
+		Dimensions: 3,
 		MaxRetries: 3,
 		HTTPClient: client,
 	})
@@ -64,9 +65,10 @@ func TestGitHubModelsEmbedder_RetriesWithRetryAfterAndBackoff(t *testing.T) {
 	}
 
 	sleeps := make([]time.Duration, 0)
-	emb, err := NewGitHubModelsEmbedder(GitHubModelsConfig{
-		Token:      "token-123",
+	emb, err := NewGitHubModelsEmbedder(ProviderConfig{
+		APIKey:     "token-123",
 		Endpoint:   "https://example.test/embeddings",
+		Dimensions: 2,
 		MaxRetries: 3,
 		HTTPClient: client,
 		Sleep: func(d time.Duration) {
@@ -117,10 +119,11 @@ func TestGitHubModelsEmbedder_TruncatesInput(t *testing.T) {
 		}),
 	}
 
-	emb, err := NewGitHubModelsEmbedder(GitHubModelsConfig{
-		Token:      "token-123",
+	emb, err := NewGitHubModelsEmbedder(ProviderConfig{
+		APIKey:     "token-123",
 		Endpoint:   "https://example.test/embeddings",
 		MaxChars:   10,
+		Dimensions: 1,
 		MaxRetries: 0,
 		HTTPClient: client,
 	})
@@ -149,8 +152,8 @@ func TestGitHubModelsEmbedder_FailsAfterMaxRetries(t *testing.T) {
 		}),
 	}
 
-	emb, err := NewGitHubModelsEmbedder(GitHubModelsConfig{
-		Token:      "token-123",
+	emb, err := NewGitHubModelsEmbedder(ProviderConfig{
+		APIKey:     "token-123",
 		Endpoint:   "https://example.test/embeddings",
 		MaxRetries: 2,
 		HTTPClient: client,
@@ -170,9 +173,93 @@ func TestGitHubModelsEmbedder_FailsAfterMaxRetries(t *testing.T) {
 	}
 }
 
+func TestGitHubModelsEmbedder_SendsDimensionsAndOrdersByIndex(t *testing.T) {
+	t.Helper()
+
+	client := &http.Client{
+		Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			defer r.Body.Close()
+			var req embeddingRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("decode request: %v", err)
+			}
+			if req.Dimensions != 2 {
+				t.Fatalf("request dimensions = %d, want 2", req.Dimensions)
+			}
+			return jsonResponse(http.StatusOK, `{"data":[{"index":1,"embedding":[0.3,0.4]},{"index":0,"embedding":[0.1,0.2]}]}`), nil
+		}),
+	}
+
+	emb, err := NewGitHubModelsEmbedder(ProviderConfig{
+		APIKey:     "token-123",
+		Endpoint:   "https://example.test/embeddings",
+		Model:      "text-embedding-3-large",
+		Dimensions: 2,
+		HTTPClient: client,
+	})
+	if err != nil {
+		t.Fatalf("NewGitHubModelsEmbedder() error = %v", err)
+	}
+
+	vectors, err := emb.EmbedBatch(context.Background(), []string{"first", "second"})
+	if err != nil {
+		t.Fatalf("EmbedBatch() error = %v", err)
+	}
+	if vectors[0][0] != 0.1 || vectors[1][0] != 0.3 {
+		t.Fatalf("vectors not ordered by index: %v", vectors)
+	}
+}
+
+func TestGitHubModelsEmbedder_RejectsMalformedVectorsWithoutRetry(t *testing.T) {
+	t.Helper()
+
+	cases := map[string]string{
+		"wrong length":    `{"data":[{"index":0,"embedding":[0.1,0.2,0.3]}]}`,
+		"duplicate index": `{"data":[{"index":0,"embedding":[0.1,0.2]},{"index":0,"embedding":[0.3,0.4]}]}`,
+		"missing vector":  `{"data":[{"index":0,"embedding":[0.1,0.2]}]}`,
+	}
+	for name, body := range cases {
+		var calls int32
+		client := &http.Client{
+			Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				atomic.AddInt32(&calls, 1)
+				return jsonResponse(http.StatusOK, body), nil
+			}),
+		}
+		emb, err := NewGitHubModelsEmbedder(ProviderConfig{
+			APIKey:     "token-123",
+			Endpoint:   "https://example.test/embeddings",
+			Dimensions: 2,
+			MaxRetries: 3,
+			HTTPClient: client,
+			Sleep:      func(time.Duration) {},
+		})
+		if err != nil {
+			t.Fatalf("NewGitHubModelsEmbedder() error = %v", err)
+		}
+
+		inputs := []string{"a"}
+		if name != "wrong length" {
+			inputs = append(inputs, "b")
+		}
+		_, err = emb.EmbedBatch(context.Background(), inputs)
+		if !IsInvalidEmbedding(err) {
+			t.Fatalf("%s: EmbedBatch() error = %v, want InvalidEmbeddingError", name, err)
+		}
+		if got := atomic.LoadInt32(&calls); got != 1 {
+			t.Fatalf("%s: call count = %d, want 1", name, got)
+		}
+	}
+
+	err := validateEmbeddings([][]float32{{float32(math.NaN()), 0}}, 1, 2)
+	if !IsInvalidEmbedding(err) {
+		t.Fatalf("validateEmbeddings(NaN) error = %v, want InvalidEmbeddingError", err)
+	}
+}
+
 func TestNewGitHubModelsEmbedder_RequiresToken(t *testing.T) {
 	t.Helper()
-	_, err := NewGitHubModelsEmbedder(GitHubModelsConfig{})
+	_, err := NewGitHubModelsEmbedder(ProviderConfig{})
 	if err == nil {
 		t.Fatalf("expected token validation error")
 	}
@@ -0,0 +1,136 @@
+package embed
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// HashingModel names the built-in offline embedder. Bump the suffix whenever
+// feature extraction changes so stored vectors are recomputed.
+const HashingModel = "hashed-ngram-v1"
+
+// errEmptyEmbeddingText is returned for blank text, which has no features and
+// would otherwise embed to a zero vector that cosine distance cannot compare.
+var errEmptyEmbeddingText = errors.New("offline embedder: text has nothing to embed")
+
+const (
+	hashingCharGramMin = 3
+	hashingCharGramMax = 5
+	hashingWordWeight  = 2.0
+)
+
+// HashingEmbedder is a pure-Go embedder that needs no network or model files.
+// Word unigrams, word bigrams and character 3-5 grams are hashed with a sign
+// bit into a fixed number of buckets (a sparse random projection), weighted by
+// sublinear term frequency, and L2-normalized so cosine search works as usual.
+// Quality is below a hosted model, but output is fully deterministic.
+type HashingEmbedder struct {
+	dimensions int
+	maxChars   int
+}
+
+func NewHashingEmbedder(cfg ProviderConfig) (*HashingEmbedder, error) {
+	dimensions := cfg.Dimensions
+	if dimensions <= 0 {
+		dimensions = DefaultEmbeddingDimensions
+	}
+	maxChars := cfg.MaxChars
+	if maxChars <= 0 {
+		maxChars = DefaultMaxInputChars
+	}
+	return &HashingEmbedder{dimensions: dimensions, maxChars: maxChars}, nil
+}
+
+func (h *HashingEmbedder) Dimensions() int {
+	return h.dimensions
+}
+
+func (h *HashingEmbedder) Model() string {
+	return HashingModel
+}
+
+func (h *HashingEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return h.embed(truncateForEmbedding(text, h.maxChars))
+}
+
+func (h *HashingEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, 0, len(texts))
+	for _, text := range texts {
+		vec, err := h.Embed(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, vec)
+	}
+	return out, nil
+}
+
+func (h *HashingEmbedder) embed(text string) ([]float32, error) {
+	counts := map[string]float64{}
+	words := hashingWords(text)
+	if len(words) == 0 {
+		// Text made only of emoji, punctuation or code symbols still has
+		// features; without them it would embed to the zero vector.
+		words = strings.Fields(text)
+	}
+	if len(words) == 0 {
+		return nil, errEmptyEmbeddingText
+	}
+	for i, word := range words {
+		counts["w:"+word] += hashingWordWeight
+		if i > 0 {
+			counts["b:"+words[i-1]+" "+word] += hashingWordWeight
+		}
+
+		padded := []rune(" " + word + " ")
+		for n := hashingCharGramMin; n <= hashingCharGramMax; n++ {
+			for start := 0; start+n <= len(padded); start++ {
+				counts["c:"+string(padded[start:start+n])]++
+			}
+		}
+	}
+
+	acc := make([]float64, h.dimensions)
+	for feature, count := range counts {
+		bucket, sign := hashingBucket(feature, h.dimensions)
+		acc[bucket] += sign * (1 + math.Log(count))
+	}
+
+	var norm float64
+	for _, v := range acc {
+		norm += v * v
+	}
+	if norm == 0 {
+		return nil, errEmptyEmbeddingText
+	}
+	norm = math.Sqrt(norm)
+	out := make([]float32, h.dimensions)
+	for i, v := range acc {
+		out[i] = float32(v / norm)
+	}
+	return out, nil
+}
+
+func hashingWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+func hashingBucket(feature string, dimensions int) (int, float64) {
+	hasher := fnv.New64a()
+	_, _ = hasher.Write([]byte(feature))
+	sum := hasher.Sum64()
+	sign := 1.0
+	if sum>>63 == 1 {
+		sign = -1
+	}
+	return int(sum % uint64(dimensions)), sign
+}
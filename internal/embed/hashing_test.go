@@ -0,0 +1,80 @@
+package embed
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestHashingEmbedder_DeterministicUnitVectors(t *testing.T) {
+	t.Helper()
+
+	emb, err := NewHashingEmbedder(ProviderConfig{})
+	if err != nil {
+		t.Fatalf("NewHashingEmbedder() error = %v", err)
+	}
+	if emb.Dimensions() != DefaultEmbeddingDimensions || emb.Model() != HashingModel {
+		t.Fatalf("unexpected embedder identity: dims=%d model=%q", emb.Dimensions(), emb.Model())
+	}
+
+	vectors, err := emb.EmbedBatch(context.Background(), []string{"Login times out on Safari", "Login times out on Safari"})
+	if err != nil {
+		t.Fatalf("EmbedBatch() error = %v", err)
+	}
+	if len(vectors[0]) != DefaultEmbeddingDimensions {
+		t.Fatalf("vector length = %d", len(vectors[0]))
+	}
+	if cosine(vectors[0], vectors[1]) < 0.9999 {
+		t.Fatalf("same text should embed identically")
+	}
+	if norm := math.Sqrt(cosine(vectors[0], vectors[0])); math.Abs(norm-1) > 1e-5 {
+		t.Fatalf("norm = %f, want 1", norm)
+	}
+}
+
+func TestHashingEmbedder_SymbolOnlyTextAndBlankText(t *testing.T) {
+	t.Helper()
+
+	emb, err := NewHashingEmbedder(ProviderConfig{Dimensions: 256})
+	if err != nil {
+		t.Fatalf("NewHashingEmbedder() error = %v", err)
+	}
+	ctx := context.Background()
+	for _, text := range []string{"🔥🔥🔥", "!= && || ->"} {
+		vec, err := emb.Embed(ctx, text)
+		if err != nil {
+			t.Fatalf("Embed(%q) error = %v", text, err)
+		}
+		if norm := math.Sqrt(cosine(vec, vec)); math.Abs(norm-1) > 1e-5 {
+			t.Fatalf("Embed(%q) norm = %f, want a unit vector", text, norm)
+		}
+	}
+	if _, err := emb.Embed(ctx, " \n\t "); err == nil {
+		t.Fatalf("expected an error for blank text instead of a zero vector")
+	}
+}
+
+func TestHashingEmbedder_RanksRelatedTextHigher(t *testing.T) {
+	t.Helper()
+
+	emb, err := NewHashingEmbedder(ProviderConfig{Dimensions: 512})
+	if err != nil {
+		t.Fatalf("NewHashingEmbedder() error = %v", err)
+	}
+	ctx := context.Background()
+	query, _ := emb.Embed(ctx, "Login page times out after entering password")
+	related, _ := emb.Embed(ctx, "Timeout on login after typing the password")
+	unrelated, _ := emb.Embed(ctx, "Dark mode colors are wrong in settings menu")
+
+	if cosine(query, related) <= cosine(query, unrelated) {
+		t.Fatalf("related=%f should exceed unrelated=%f", cosine(query, related), cosine(query, unrelated))
+	}
+}
+
+func cosine(a, b []float32) float64 {
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot
+}
@@ -0,0 +1,150 @@
+package embed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"vector-triage/internal/retry"
+)
+
+type SleepFunc func(time.Duration)
+
+// retryEmbed runs attempt up to maxRetries+1 times, honouring Retry-After when
+// the server sends it and falling back to exponential backoff otherwise. Every
+// HTTP provider goes through here so they all back off the same way.
+func retryEmbed(maxRetries int, sleep SleepFunc, attempt func() ([][]float32, time.Duration, error)) ([][]float32, error) {
+	var lastErr error
+	for i := 0; i <= maxRetries; i++ {
+		vectors, retryAfter, err := attempt()
+		if err == nil {
+			return vectors, nil
+		}
+		if IsInvalidEmbedding(err) {
+			return nil, err
+		}
+		lastErr = err
+		if i == maxRetries {
+			break
+		}
+
+		wait := retryAfter
+		if wait <= 0 {
+			wait = retry.Backoff(i)
+		}
+		sleep(wait)
+	}
+
+	return nil, fmt.Errorf("embed batch failed after %d attempts: %w", maxRetries+1, lastErr)
+}
+
+// postJSON sends one embedding request and returns the raw 2xx body. Non-2xx
+// responses report the Retry-After hint so retryEmbed can respect it.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body []byte) ([]byte, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, fmt.Errorf("build embedding request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("send embedding request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		retryAfter := retry.ParseRetryAfter(resp.Header.Get("Retry-After"))
+		bodyText, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, retryAfter, fmt.Errorf("embedding request failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(bodyText)))
+	}
+
+	out, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("read embedding response: %w", err)
+	}
+	return out, 0, nil
+}
+
+// encodeOpenAIEmbeddings builds the request body of OpenAI's POST /embeddings,
+// which GitHub Models and OpenAI-compatible servers accept.
+func encodeOpenAIEmbeddings(model string, dimensions int, inputs []string) any {
+	return embeddingRequest{Input: inputs, Model: model, Dimensions: requestDimensions(model, dimensions)}
+}
+
+// decodeOpenAIEmbeddings parses the {"data":[{"index":0,"embedding":[...]}]}
+// shape shared by GitHub Models, OpenAI-compatible servers and Azure OpenAI.
+// Items are placed by their index, since batches may come back out of order;
+// servers that omit index are taken in response order.
+func decodeOpenAIEmbeddings(body []byte) ([][]float32, error) {
+	var out embeddingResponse
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("decode embedding response: %w", err)
+	}
+	if len(out.Data) == 0 {
+		return nil, errors.New("embedding response data is empty")
+	}
+
+	vectors := make([][]float32, len(out.Data))
+	for pos, item := range out.Data {
+		idx := pos
+		if item.Index != nil {
+			idx = *item.Index
+		}
+		if idx < 0 || idx >= len(vectors) {
+			return nil, &InvalidEmbeddingError{Index: -1, Reason: fmt.Sprintf("index %d out of range for %d items", idx, len(vectors))}
+		}
+		if vectors[idx] != nil {
+			return nil, &InvalidEmbeddingError{Index: idx, Reason: "index appears more than once"}
+		}
+		vectors[idx] = append([]float32{}, item.Embedding...)
+	}
+	return vectors, nil
+}
+
+func newHTTPClient(client *http.Client, timeout time.Duration) *http.Client {
+	if client == nil {
+		return &http.Client{Timeout: timeout}
+	}
+	if client.Timeout <= 0 {
+		copyClient := *client
+		copyClient.Timeout = timeout
+		return &copyClient
+	}
+	return client
+}
+
+func truncateForEmbedding(text string, maxChars int) string {
+	if maxChars <= 0 {
+		return ""
+	}
+	runes := []rune(text)
+	if len(runes) <= maxChars {
+		return text
+	}
+	return string(runes[:maxChars])
+}
+
+type embeddingRequest struct {
+	Input      []string `json:"input"`
+	Model      string   `json:"model"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+type embeddingResponse struct {
+	Data []embeddingData `json:"data"`
+}
+
+type embeddingData struct {
+	Index     *int      `json:"index"`
+	Embedding []float32 `json:"embedding"`
+}
@@ -5,15 +5,20 @@ import (
 	"errors"
 )
 
+const mockEmbeddingModel = "mock-embedding"
+
 // MockEmbedder is a test double with deterministic outputs.
 type MockEmbedder struct {
-	Vectors [][]float32
-	Err     error
-	Dims    int
+	Vectors   [][]float32
+	Err       error
+	Dims      int
+	ModelName string
+	Calls     int
 }
 
 func (m *MockEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
 	_ = ctx
+	m.Calls++
 	if m.Err != nil {
 		return nil, m.Err
 	}
@@ -25,6 +30,7 @@ func (m *MockEmbedder) Embed(ctx context.Context, text string) ([]float32, error
 
 func (m *MockEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
 	_ = ctx
+	m.Calls++
 	if m.Err != nil {
 		return nil, m.Err
 	}
@@ -54,3 +60,10 @@ func (m *MockEmbedder) Dimensions() int {
 	}
 	return m.Dims
 }
+
+func (m *MockEmbedder) Model() string {
+	if m.ModelName == "" {
+		return mockEmbeddingModel
+	}
+	return m.ModelName
+}
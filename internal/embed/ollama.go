@@ -0,0 +1,62 @@
+package embed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const DefaultOllamaEndpoint = "http://localhost:11434"
+
+// NewOllamaEmbedder calls Ollama's batch /api/embed endpoint. Endpoint is the
+// server base URL; Model must name a pulled embedding model.
+func NewOllamaEmbedder(cfg ProviderConfig) (Embedder, error) {
+	model := strings.TrimSpace(cfg.Model)
+	if model == "" {
+		return nil, errors.New("ollama embedding model is required")
+	}
+	endpoint := strings.TrimRight(strings.TrimSpace(cfg.Endpoint), "/")
+	if endpoint == "" {
+		endpoint = DefaultOllamaEndpoint
+	}
+	if !strings.HasSuffix(endpoint, "/api/embed") {
+		endpoint += "/api/embed"
+	}
+
+	h := newHTTPEmbedder(cfg, endpoint, model)
+	if key := strings.TrimSpace(cfg.APIKey); key != "" {
+		// Ollama itself has no auth, but reverse proxies in front of it often do.
+		h.headers["Authorization"] = "Bearer " + key
+	}
+	h.encode = func(model string, _ int, inputs []string) any {
+		return ollamaEmbedRequest{Model: model, Input: inputs}
+	}
+	h.decode = decodeOllamaEmbeddings
+	return h, nil
+}
+
+func decodeOllamaEmbeddings(body []byte) ([][]float32, error) {
+	var out ollamaEmbedResponse
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("decode ollama embedding response: %w", err)
+	}
+	if len(out.Embeddings) == 0 {
+		return nil, errors.New("ollama embedding response is empty")
+	}
+
+	vectors := make([][]float32, 0, len(out.Embeddings))
+	for _, embedding := range out.Embeddings {
+		vectors = append(vectors, append([]float32(nil), embedding...))
+	}
+	return vectors, nil
+}
+
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type ollamaEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
@@ -0,0 +1,32 @@
+package embed
+
+import (
+	"errors"
+	"strings"
+)
+
+// NewOpenAICompatibleEmbedder talks to any server implementing OpenAI's
+// POST /embeddings (OpenAI itself, vLLM, LocalAI, LiteLLM, ...). Endpoint may
+// be the full /embeddings URL or the API base, e.g. https://api.openai.com/v1.
+// The API key is optional for local servers that do not check it.
+func NewOpenAICompatibleEmbedder(cfg ProviderConfig) (Embedder, error) {
+	endpoint := strings.TrimRight(strings.TrimSpace(cfg.Endpoint), "/")
+	if endpoint == "" {
+		return nil, errors.New("openai embedding endpoint is required")
+	}
+	if !strings.HasSuffix(endpoint, "/embeddings") {
+		endpoint += "/embeddings"
+	}
+	model := strings.TrimSpace(cfg.Model)
+	if model == "" {
+		model = DefaultEmbeddingModel
+	}
+
+	h := newHTTPEmbedder(cfg, endpoint, model)
+	if key := strings.TrimSpace(cfg.APIKey); key != "" {
+		h.headers["Authorization"] = "Bearer " + key
+	}
+	h.encode = encodeOpenAIEmbeddings
+	h.decode = decodeOpenAIEmbeddings
+	return h, nil
+}
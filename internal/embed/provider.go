@@ -0,0 +1,195 @@
+package embed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	ProviderGitHubModels     = "github-models"
+	ProviderOpenAICompatible = "openai"
+	ProviderAzureOpenAI      = "azure-openai"
+	ProviderOllama           = "ollama"
+	ProviderOffline          = "offline"
+)
+
+// ProviderConfig is the provider-agnostic input used by New. Fields a provider
+// does not need are ignored.
+type ProviderConfig struct {
+	Endpoint   string
+	Model      string
+	APIKey     string
+	Timeout    time.Duration
+	MaxRetries int
+	MaxChars   int
+	Dimensions int
+	// DimensionsSet reports that Dimensions was configured rather than
+	// defaulted. Azure then always sends it as the `dimensions` parameter,
+	// since a deployment name does not tell which model it serves.
+	DimensionsSet bool
+	Batch         BatchLimits
+	HTTPClient    *http.Client
+	Sleep         SleepFunc
+}
+
+type providerFactory func(cfg ProviderConfig) (Embedder, error)
+
+var providers = map[string]providerFactory{
+	ProviderGitHubModels:     func(cfg ProviderConfig) (Embedder, error) { return NewGitHubModelsEmbedder(cfg) },
+	ProviderOpenAICompatible: func(cfg ProviderConfig) (Embedder, error) { return NewOpenAICompatibleEmbedder(cfg) },
+	ProviderAzureOpenAI:      func(cfg ProviderConfig) (Embedder, error) { return NewAzureOpenAIEmbedder(cfg) },
+	ProviderOllama:           func(cfg ProviderConfig) (Embedder, error) { return NewOllamaEmbedder(cfg) },
+	ProviderOffline:          func(cfg ProviderConfig) (Embedder, error) { return NewHashingEmbedder(cfg) },
+}
+
+// New builds the embedder registered under provider. An empty name selects GitHub Models.
+func New(provider string, cfg ProviderConfig) (Embedder, error) {
+	name := NormalizeProvider(provider)
+	factory, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown embedding provider %q (supported: %s)", provider, strings.Join(ProviderNames(), ", "))
+	}
+	return factory(cfg)
+}
+
+// NormalizeProvider lowercases a provider name and maps empty to the default.
+func NormalizeProvider(provider string) string {
+	name := strings.ToLower(strings.TrimSpace(provider))
+	if name == "" {
+		return ProviderGitHubModels
+	}
+	return name
+}
+
+// ProviderNames lists the registered providers in sorted order.
+func ProviderNames() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ollamaModelDimensions are the vector sizes of common Ollama embedding models.
+var ollamaModelDimensions = map[string]int{
+	"all-minilm":             384,
+	"bge-large":              1024,
+	"bge-m3":                 1024,
+	"mxbai-embed-large":      1024,
+	"nomic-embed-text":       768,
+	"snowflake-arctic-embed": 1024,
+}
+
+// DefaultDimensions is the vector size assumed for provider and model when
+// none is configured. It returns 0 for Ollama models it does not know, whose
+// size must then be set explicitly.
+func DefaultDimensions(provider, model string) int {
+	if NormalizeProvider(provider) != ProviderOllama {
+		return DefaultEmbeddingDimensions
+	}
+	// Ollama models may carry a tag, as in nomic-embed-text:v1.5.
+	name, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(model)), ":")
+	return ollamaModelDimensions[name]
+}
+
+// httpEmbedder is the shared core behind the GitHub Models, OpenAI-compatible,
+// Azure and Ollama providers; each one only supplies its URL, headers and wire format.
+type httpEmbedder struct {
+	endpoint   string
+	model      string
+	headers    map[string]string
+	maxRetries int
+	maxChars   int
+	dimensions int
+	limits     BatchLimits
+	client     *http.Client
+	sleep      SleepFunc
+
+	encode func(model string, dimensions int, inputs []string) any
+	decode func(body []byte) ([][]float32, error)
+}
+
+func newHTTPEmbedder(cfg ProviderConfig, endpoint, model string) *httpEmbedder {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	maxRetries := cfg.MaxRetries
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	maxChars := cfg.MaxChars
+	if maxChars <= 0 {
+		maxChars = DefaultMaxInputChars
+	}
+	dimensions := cfg.Dimensions
+	if dimensions <= 0 {
+		dimensions = DefaultEmbeddingDimensions
+	}
+	sleep := cfg.Sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+
+	return &httpEmbedder{
+		endpoint:   endpoint,
+		model:      model,
+		headers:    map[string]string{},
+		maxRetries: maxRetries,
+		maxChars:   maxChars,
+		dimensions: dimensions,
+		limits:     cfg.Batch.normalized(),
+		client:     newHTTPClient(cfg.HTTPClient, timeout),
+		sleep:      sleep,
+	}
+}
+
+func (h *httpEmbedder) Dimensions() int {
+	return h.dimensions
+}
+
+func (h *httpEmbedder) Model() string {
+	return h.model
+}
+
+func (h *httpEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := h.EmbedBatch(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) == 0 {
+		return nil, errors.New("embed response contained no vectors")
+	}
+	return vectors[0], nil
+}
+
+func (h *httpEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return embedInBatches(ctx, texts, h.maxChars, h.limits, func(ctx context.Context, inputs []string) ([][]float32, error) {
+		bodyBytes, err := json.Marshal(h.encode(h.model, h.dimensions, inputs))
+		if err != nil {
+			return nil, fmt.Errorf("marshal embedding request: %w", err)
+		}
+
+		return retryEmbed(h.maxRetries, h.sleep, func() ([][]float32, time.Duration, error) {
+			raw, retryAfter, err := postJSON(ctx, h.client, h.endpoint, h.headers, bodyBytes)
+			if err != nil {
+				return nil, retryAfter, err
+			}
+			vectors, err := h.decode(raw)
+			if err != nil {
+				return nil, 0, err
+			}
+			if err := validateEmbeddings(vectors, len(inputs), h.dimensions); err != nil {
+				return nil, 0, err
+			}
+			return vectors, 0, nil
+		})
+	})
+}
@@ -0,0 +1,223 @@
+package embed
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNew_SelectsProviderAndRejectsUnknown(t *testing.T) {
+	t.Helper()
+
+	emb, err := New("", ProviderConfig{APIKey: "tkn"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if h, ok := emb.(*httpEmbedder); !ok || h.endpoint != DefaultEmbeddingEndpoint {
+		t.Fatalf("default provider = %T, want the GitHub Models preset", emb)
+	}
+
+	emb, err = New(" Ollama ", ProviderConfig{Model: "nomic-embed-text"})
+	if err != nil {
+		t.Fatalf("New(ollama) error = %v", err)
+	}
+	if emb.Model() != "nomic-embed-text" {
+		t.Fatalf("Model() = %q", emb.Model())
+	}
+
+	if _, err := New("bedrock", ProviderConfig{}); err == nil {
+		t.Fatalf("expected unknown provider error")
+	}
+}
+
+func TestDefaultDimensions(t *testing.T) {
+	t.Helper()
+
+	tests := []struct {
+		provider, model string
+		want            int
+	}{
+		{provider: "", model: "", want: DefaultEmbeddingDimensions},
+		{provider: ProviderOpenAICompatible, model: "text-embedding-3-large", want: DefaultEmbeddingDimensions},
+		{provider: ProviderOllama, model: "nomic-embed-text", want: 768},
+		{provider: "Ollama", model: "mxbai-embed-large:latest", want: 1024},
+		{provider: ProviderOllama, model: "my-custom-embedder", want: 0},
+	}
+	for _, tt := range tests {
+		if got := DefaultDimensions(tt.provider, tt.model); got != tt.want {
+			t.Fatalf("DefaultDimensions(%q, %q) = %d, want %d", tt.provider, tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestOpenAICompatibleEmbedder_EmbedBatch(t *testing.T) {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Fatalf("path = %q, want /v1/embeddings", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-test" {
+			t.Fatalf("authorization header = %q", got)
+		}
+		var req embeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		if req.Model != "text-embedding-3-large" || len(req.Input) != 2 {
+			t.Fatalf("unexpected request: %+v", req)
+		}
+		_, _ = w.Write([]byte(`{"data":[{"embedding":[0.1,0.2]},{"embedding":[0.3,0.4]}]}`))
+	}))
+	defer srv.Close()
+
+	emb, err := NewOpenAICompatibleEmbedder(ProviderConfig{Endpoint: srv.URL + "/v1/", Model: "text-embedding-3-large", APIKey: "sk-test", Dimensions: 2})
+	if err != nil {
+		t.Fatalf("NewOpenAICompatibleEmbedder() error = %v", err)
+	}
+
+	vectors, err := emb.EmbedBatch(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("EmbedBatch() error = %v", err)
+	}
+	if len(vectors) != 2 || vectors[1][1] != 0.4 {
+		t.Fatalf("unexpected vectors: %v", vectors)
+	}
+}
+
+func TestAzureOpenAIEmbedder_UsesDeploymentURLAndAPIKey(t *testing.T) {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/embed-small/embeddings" {
+			t.Fatalf("path = %q", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("api-version"); got != DefaultAzureAPIVersion {
+			t.Fatalf("api-version = %q", got)
+		}
+		if got := r.Header.Get("api-key"); got != "az-key" {
+			t.Fatalf("api-key header = %q", got)
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Fatalf("azure requests must not send a bearer token")
+		}
+		_, _ = w.Write([]byte(`{"data":[{"embedding":[0.5,0.6]}]}`))
+	}))
+	defer srv.Close()
+
+	emb, err := NewAzureOpenAIEmbedder(ProviderConfig{Endpoint: srv.URL + "/openai/deployments/embed-small", APIKey: "az-key", Dimensions: 2})
+	if err != nil {
+		t.Fatalf("NewAzureOpenAIEmbedder() error = %v", err)
+	}
+	if emb.Model() != "embed-small" {
+		t.Fatalf("Model() = %q, want deployment name", emb.Model())
+	}
+
+	vec, err := emb.Embed(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	if len(vec) != 2 {
+		t.Fatalf("vector length = %d, want 2", len(vec))
+	}
+
+	if _, err := NewAzureOpenAIEmbedder(ProviderConfig{Endpoint: srv.URL + "/embeddings", APIKey: "az-key"}); err == nil {
+		t.Fatalf("expected error for endpoint without deployment")
+	}
+	if _, err := NewAzureOpenAIEmbedder(ProviderConfig{Endpoint: srv.URL + "/openai/deployments/x"}); err == nil {
+		t.Fatalf("expected error for missing api key")
+	}
+}
+
+func TestAzureOpenAIEmbedder_SendsConfiguredDimensions(t *testing.T) {
+	t.Helper()
+
+	for _, tc := range []struct {
+		name string
+		cfg  ProviderConfig
+		want int
+	}{
+		{name: "configured for a custom deployment name", cfg: ProviderConfig{Dimensions: 2, DimensionsSet: true}, want: 2},
+		{name: "defaulted for a custom deployment name", cfg: ProviderConfig{Dimensions: 2}, want: 0},
+		{name: "defaulted for a text-embedding-3 model", cfg: ProviderConfig{Model: "text-embedding-3-small", Dimensions: 2}, want: 2},
+	} {
+		client := &http.Client{
+			Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				defer r.Body.Close()
+				var req azureEmbeddingRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Fatalf("decode request: %v", err)
+				}
+				if req.Dimensions != tc.want {
+					t.Fatalf("%s: request dimensions = %d, want %d", tc.name, req.Dimensions, tc.want)
+				}
+				return jsonResponse(http.StatusOK, `{"data":[{"embedding":[0.5,0.6]}]}`), nil
+			}),
+		}
+		cfg := tc.cfg
+		cfg.Endpoint, cfg.APIKey, cfg.HTTPClient = "https://r.openai.azure.com/openai/deployments/prod-embed", "az-key", client
+		emb, err := NewAzureOpenAIEmbedder(cfg)
+		if err != nil {
+			t.Fatalf("NewAzureOpenAIEmbedder() error = %v", err)
+		}
+		if _, err := emb.Embed(context.Background(), "hello"); err != nil {
+			t.Fatalf("%s: Embed() error = %v", tc.name, err)
+		}
+	}
+}
+
+func TestOllamaEmbedder_RetriesLikeOtherProviders(t *testing.T) {
+	t.Helper()
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			t.Fatalf("path = %q, want /api/embed", r.URL.Path)
+		}
+		var req ollamaEmbedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		if req.Model != "nomic-embed-text" || len(req.Input) != 1 {
+			t.Fatalf("unexpected request: %+v", req)
+		}
+
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			_, _ = w.Write([]byte(`{"model":"nomic-embed-text","embeddings":[[0.7,0.8,0.9]]}`))
+		}
+	}))
+	defer srv.Close()
+
+	var sleeps []time.Duration
+	emb, err := NewOllamaEmbedder(ProviderConfig{
+		Endpoint:   srv.URL,
+		Model:      "nomic-embed-text",
+		Dimensions: 3,
+		MaxRetries: 3,
+		Sleep:      func(d time.Duration) { sleeps = append(sleeps, d) },
+	})
+	if err != nil {
+		t.Fatalf("NewOllamaEmbedder() error = %v", err)
+	}
+
+	vec, err := emb.Embed(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	if len(vec) != 3 {
+		t.Fatalf("vector length = %d, want 3", len(vec))
+	}
+	if len(sleeps) != 2 || sleeps[0] != 3*time.Second || sleeps[1] != 2*time.Second {
+		t.Fatalf("sleeps = %v, want [3s 2s]", sleeps)
+	}
+}
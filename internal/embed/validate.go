@@ -0,0 +1,60 @@
+package embed
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// InvalidEmbeddingError reports a response that arrived intact but cannot be
+// stored: wrong vector count or length, bad indexes, or NaN/Inf components.
+// Retrying will not fix it, so it is returned immediately and callers can tell
+// it apart from transport and rate-limit failures with IsInvalidEmbedding.
+type InvalidEmbeddingError struct {
+	// Index is the input position of the offending vector, or -1 for the batch as a whole.
+	Index  int
+	Reason string
+}
+
+func (e *InvalidEmbeddingError) Error() string {
+	if e.Index < 0 {
+		return "invalid embedding response: " + e.Reason
+	}
+	return fmt.Sprintf("invalid embedding for input %d: %s", e.Index, e.Reason)
+}
+
+// IsInvalidEmbedding reports whether err wraps an InvalidEmbeddingError.
+func IsInvalidEmbedding(err error) bool {
+	var invalid *InvalidEmbeddingError
+	return errors.As(err, &invalid)
+}
+
+// validateEmbeddings checks that a decoded batch has one finite vector of the
+// configured length per input.
+func validateEmbeddings(vectors [][]float32, inputs, dimensions int) error {
+	if len(vectors) != inputs {
+		return &InvalidEmbeddingError{Index: -1, Reason: fmt.Sprintf("got %d vectors for %d inputs", len(vectors), inputs)}
+	}
+	for i, vec := range vectors {
+		if len(vec) != dimensions {
+			return &InvalidEmbeddingError{Index: i, Reason: fmt.Sprintf("got %d dimensions, want %d", len(vec), dimensions)}
+		}
+		for _, v := range vec {
+			if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+				return &InvalidEmbeddingError{Index: i, Reason: "vector contains NaN or Inf"}
+			}
+		}
+	}
+	return nil
+}
+
+// requestDimensions returns the value to send as the `dimensions` request
+// field. Only the text-embedding-3 family can shorten its output; other
+// models reject the field, so it is omitted for them.
+func requestDimensions(model string, dimensions int) int {
+	if !strings.HasPrefix(strings.ToLower(model), "text-embedding-3") {
+		return 0
+	}
+	return dimensions
+}
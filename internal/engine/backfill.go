@@ -0,0 +1,296 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"vector-triage/internal/embed"
+	gh "vector-triage/internal/github"
+	"vector-triage/internal/ingest"
+	"vector-triage/internal/store"
+)
+
+const (
+	defaultBackfillBatchSize = 20
+
+	// BackfillCursorKey stores the updated_at of the last item indexed, so an
+	// interrupted backfill resumes from there instead of starting over. Unlike
+	// a page number, it does not drift when items are edited or deleted.
+	BackfillCursorKey = "backfill.cursor"
+	// BackfillCompletedAtKey stores when the last full backfill finished.
+	BackfillCompletedAtKey = "backfill.completed_at"
+)
+
+type ItemSource interface {
+	ListRepositoryItems(ctx context.Context, owner, repo string, since time.Time, page int) ([]gh.Event, int, error)
+	ListPullRequestFiles(ctx context.Context, owner, repo string, number int) ([]string, error)
+}
+
+type BackfillIndexer interface {
+	UpsertItem(ctx context.Context, rec store.ItemRecord) error
+	UpsertVector(ctx context.Context, id string, embedding []float32) error
+	DeleteVector(ctx context.Context, id string) error
+	ReplaceChunkVectors(ctx context.Context, itemID string, chunks [][]float32) error
+	LookupEmbedding(ctx context.Context, id, contentHash, model string) ([]float32, bool, error)
+	SetEmbeddingFingerprint(ctx context.Context, id, contentHash, model string) error
+	MarkPendingEmbedding(ctx context.Context, id, reason string) error
+	GetMeta(ctx context.Context, key string) (string, bool, error)
+	SetMeta(ctx context.Context, key, value string) error
+	DeleteMeta(ctx context.Context, key string) error
+}
+
+type BackfillStats struct {
+	// StartCursor is the checkpoint the run resumed from; zero for a fresh start.
+	StartCursor time.Time
+	Pages       int
+	Items       int
+	Embedded    int
+	Completed   bool
+}
+
+// Backfiller indexes every existing issue and PR without posting comments.
+type Backfiller struct {
+	Owner     string
+	Repo      string
+	Source    ItemSource
+	Embedder  embed.Embedder
+	Store     BackfillIndexer
+	BatchSize int
+	Warn      func(error)
+}
+
+// Run pages through the repository, least recently updated first, and writes
+// items plus vectors into the store. After every page the listing restarts
+// from the newest updated_at seen so far, which is also saved as the
+// checkpoint; on error the caller should still push the index so the next run
+// resumes from it. Items edited meanwhile move to the end and are seen again.
+func (b *Backfiller) Run(ctx context.Context) (BackfillStats, error) {
+	var stats BackfillStats
+	if b == nil {
+		return stats, errors.New("nil backfiller")
+	}
+	if b.Source == nil {
+		return stats, errors.New("item source dependency is required")
+	}
+	if b.Store == nil {
+		return stats, errors.New("store dependency is required")
+	}
+	if b.Embedder == nil {
+		return stats, errors.New("embedder dependency is required")
+	}
+
+	cursor, err := b.startCursor(ctx)
+	if err != nil {
+		return stats, err
+	}
+	stats.StartCursor = cursor
+
+	// The since filter is inclusive, so items already indexed at the cursor's
+	// timestamp come back on the next listing and are skipped.
+	seen := map[string]time.Time{}
+	for page := 1; page > 0; {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		listed, nextPage, err := b.Source.ListRepositoryItems(ctx, b.Owner, b.Repo, cursor, page)
+		if err != nil {
+			return stats, fmt.Errorf("list items updated since %s: %w", formatCursor(cursor), err)
+		}
+		events := make([]gh.Event, 0, len(listed))
+		latest := cursor
+		for _, event := range listed {
+			if event.UpdatedAt.After(latest) {
+				latest = event.UpdatedAt
+			}
+			if at, ok := seen[store.BuildItemID(event.Type, event.Number)]; !ok || !at.Equal(event.UpdatedAt) {
+				events = append(events, event)
+			}
+		}
+
+		embedded, err := b.indexPage(ctx, events)
+		if err != nil {
+			return stats, fmt.Errorf("index items updated since %s: %w", formatCursor(cursor), err)
+		}
+		stats.Pages++
+		stats.Items += len(events)
+		stats.Embedded += embedded
+
+		if nextPage == 0 {
+			break
+		}
+		if latest.After(cursor) {
+			// Restart from the newest item seen rather than counting pages,
+			// which shift as items are edited or deleted.
+			cursor, page = latest, 1
+			clear(seen)
+		} else {
+			// The whole page shares the cursor's timestamp; step past it.
+			page = nextPage
+		}
+		for _, event := range listed {
+			if event.UpdatedAt.Equal(cursor) {
+				seen[store.BuildItemID(event.Type, event.Number)] = event.UpdatedAt
+			}
+		}
+		if !cursor.IsZero() {
+			if err := b.Store.SetMeta(ctx, BackfillCursorKey, cursor.UTC().Format(time.RFC3339Nano)); err != nil {
+				return stats, fmt.Errorf("save checkpoint: %w", err)
+			}
+		}
+	}
+
+	if err := b.Store.DeleteMeta(ctx, BackfillCursorKey); err != nil {
+		return stats, fmt.Errorf("clear checkpoint: %w", err)
+	}
+	if err := b.Store.SetMeta(ctx, BackfillCompletedAtKey, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return stats, fmt.Errorf("record completion: %w", err)
+	}
+	stats.Completed = true
+	return stats, nil
+}
+
+// startCursor reads the checkpoint; a missing or unreadable one starts over.
+func (b *Backfiller) startCursor(ctx context.Context) (time.Time, error) {
+	raw, found, err := b.Store.GetMeta(ctx, BackfillCursorKey)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("read checkpoint: %w", err)
+	}
+	if !found {
+		return time.Time{}, nil
+	}
+	cursor, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(raw))
+	if err != nil {
+		return time.Time{}, nil
+	}
+	return cursor, nil
+}
+
+func formatCursor(cursor time.Time) string {
+	if cursor.IsZero() {
+		return "the start"
+	}
+	return cursor.UTC().Format(time.RFC3339)
+}
+
+func (b *Backfiller) indexPage(ctx context.Context, events []gh.Event) (int, error) {
+	ids := make([]string, 0, len(events))
+	contents := make([]string, 0, len(events))
+
+	for i := range events {
+		event := events[i]
+		if event.Type == "pr" {
+			files, err := b.Source.ListPullRequestFiles(ctx, b.Owner, b.Repo, event.Number)
+			if err != nil {
+				if gh.IsRateLimitError(err) {
+					return 0, err
+				}
+				b.warn(fmt.Errorf("fetch pr #%d files: %w", event.Number, err))
+			} else {
+				event.Files = files
+			}
+		}
+
+		id := store.BuildItemID(event.Type, event.Number)
+		content := buildEmbeddableContent(event)
+		rec := buildItemRecord(event, id)
+		contentHash := ingest.ContentHash(content)
+
+		// Items re-seen on a resumed or repeated backfill keep their vector when unchanged.
+		_, unchanged, err := b.Store.LookupEmbedding(ctx, id, contentHash, b.Embedder.Model())
+		if err != nil {
+			return 0, fmt.Errorf("lookup stored embedding %s: %w", id, err)
+		}
+		// Changed items get their fingerprint once the new vector is stored, so
+		// a failed batch cannot pair the new content with the old vector.
+		if unchanged {
+			rec.ContentHash, rec.EmbeddingModel = contentHash, b.Embedder.Model()
+		}
+		if err := b.Store.UpsertItem(ctx, rec); err != nil {
+			return 0, fmt.Errorf("upsert item %s: %w", id, err)
+		}
+
+		if strings.TrimSpace(content) == "" || unchanged {
+			continue
+		}
+		ids = append(ids, id)
+		contents = append(contents, content)
+	}
+
+	embedded, err := b.embedItems(ctx, ids, contents)
+	if err != nil {
+		if pendingErr := b.queuePending(ctx, ids[embedded:], err); pendingErr != nil {
+			return embedded, errors.Join(err, pendingErr)
+		}
+		return embedded, err
+	}
+	return embedded, nil
+}
+
+// embedItems stores vectors for the given items in batches and returns how
+// many were stored before the first failure.
+func (b *Backfiller) embedItems(ctx context.Context, ids, contents []string) (int, error) {
+	batchSize := b.batchSize()
+	for start := 0; start < len(contents); start += batchSize {
+		end := start + batchSize
+		if end > len(contents) {
+			end = len(contents)
+		}
+
+		vectors, err := b.Embedder.EmbedBatch(ctx, contents[start:end])
+		if err != nil {
+			return start, fmt.Errorf("embed batch: %w", err)
+		}
+		if len(vectors) != end-start {
+			return start, fmt.Errorf("embed batch returned %d vectors for %d inputs", len(vectors), end-start)
+		}
+
+		for i, vec := range vectors {
+			chunks, err := embedChunks(ctx, b.Embedder, contents[start+i])
+			if err != nil {
+				return start + i, fmt.Errorf("embed chunks %s: %w", ids[start+i], err)
+			}
+			if err := b.Store.ReplaceChunkVectors(ctx, ids[start+i], chunks); err != nil {
+				return start + i, fmt.Errorf("upsert chunk vectors %s: %w", ids[start+i], err)
+			}
+			if err := b.Store.UpsertVector(ctx, ids[start+i], vec); err != nil {
+				return start + i, fmt.Errorf("upsert vector %s: %w", ids[start+i], err)
+			}
+			if err := b.Store.SetEmbeddingFingerprint(ctx, ids[start+i], ingest.ContentHash(contents[start+i]), b.Embedder.Model()); err != nil {
+				return start + i, fmt.Errorf("set embedding fingerprint %s: %w", ids[start+i], err)
+			}
+		}
+	}
+
+	return len(contents), nil
+}
+
+// queuePending hands items that could not be embedded to the pending queue
+// and drops their old vectors, which no longer match the stored content.
+func (b *Backfiller) queuePending(ctx context.Context, ids []string, cause error) error {
+	for _, id := range ids {
+		if err := b.Store.DeleteVector(ctx, id); err != nil {
+			return fmt.Errorf("delete stale vector %s: %w", id, err)
+		}
+		if err := b.Store.MarkPendingEmbedding(ctx, id, cause.Error()); err != nil {
+			return fmt.Errorf("mark pending embedding %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func (b *Backfiller) batchSize() int {
+	if b.BatchSize <= 0 {
+		return defaultBackfillBatchSize
+	}
+	return b.BatchSize
+}
+
+func (b *Backfiller) warn(err error) {
+	if b.Warn != nil {
+		b.Warn(err)
+	}
+}
@@ -0,0 +1,326 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"vector-triage/internal/embed"
+	gh "vector-triage/internal/github"
+	"vector-triage/internal/store"
+)
+
+func TestBackfill_IndexesAllPagesAndClearsCheckpoint(t *testing.T) {
+	t.Helper()
+
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	source := &fakeItemSource{
+		perPage: 2,
+		items: []gh.Event{
+			{Type: "issue", Number: 1, Title: "login timeout", Body: "hangs", UpdatedAt: base},
+			{Type: "pr", Number: 2, Title: "fix login", Body: "retries", UpdatedAt: base.Add(time.Hour)},
+			{Type: "issue", Number: 3, Title: "", Body: "", UpdatedAt: base.Add(2 * time.Hour)},
+		},
+		files: map[int][]string{2: {"auth.go"}},
+	}
+	// Deleting an item between pages would shift a page-numbered listing
+	// and skip issue #3.
+	source.afterList = func() { source.items = source.items[1:]; source.afterList = nil }
+	idx := newFakeBackfillIndexer()
+	b := &Backfiller{
+		Owner:     "acme",
+		Repo:      "repo",
+		Source:    source,
+		Embedder:  &embed.MockEmbedder{Dims: 3},
+		Store:     idx,
+		BatchSize: 1,
+	}
+
+	stats, err := b.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !stats.Completed || stats.Pages != 2 || stats.Items != 3 || stats.Embedded != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if len(idx.items) != 3 {
+		t.Fatalf("indexed items = %d, want 3", len(idx.items))
+	}
+	if len(idx.vectors) != 2 {
+		t.Fatalf("indexed vectors = %d, want 2 (empty content skipped)", len(idx.vectors))
+	}
+	if got := idx.items["pr/2"].Files; len(got) != 1 || got[0] != "auth.go" {
+		t.Fatalf("pr files = %v, want [auth.go]", got)
+	}
+	if source.since[1] != base.Add(time.Hour) {
+		t.Fatalf("second listing since = %v, want the last indexed update", source.since[1])
+	}
+	if _, ok := idx.meta[BackfillCursorKey]; ok {
+		t.Fatalf("checkpoint should be cleared after completion")
+	}
+	if _, ok := idx.meta[BackfillCompletedAtKey]; !ok {
+		t.Fatalf("expected completion timestamp")
+	}
+}
+
+func TestBackfill_SkipsUnchangedItemsOnRerun(t *testing.T) {
+	t.Helper()
+
+	source := &fakeItemSource{
+		items: []gh.Event{{Type: "issue", Number: 1, Title: "login timeout", Body: "hangs"}},
+	}
+	idx := newFakeBackfillIndexer()
+	embedder := &embed.MockEmbedder{Dims: 3}
+	b := &Backfiller{Source: source, Embedder: embedder, Store: idx}
+
+	if _, err := b.Run(context.Background()); err != nil {
+		t.Fatalf("first Run() error = %v", err)
+	}
+	stats, err := b.Run(context.Background())
+	if err != nil {
+		t.Fatalf("second Run() error = %v", err)
+	}
+	if stats.Embedded != 0 || embedder.Calls != 1 {
+		t.Fatalf("expected unchanged item to reuse its vector, embedded=%d calls=%d", stats.Embedded, embedder.Calls)
+	}
+}
+
+func TestBackfill_ReembedsChangedItemAfterFailedBatch(t *testing.T) {
+	t.Helper()
+
+	source := &fakeItemSource{
+		items: []gh.Event{{Type: "issue", Number: 1, Title: "login timeout", Body: "hangs"}},
+	}
+	idx := newFakeBackfillIndexer()
+	embedder := &embed.MockEmbedder{Vectors: [][]float32{{1, 0, 0}}}
+	b := &Backfiller{Source: source, Embedder: embedder, Store: idx}
+	if _, err := b.Run(context.Background()); err != nil {
+		t.Fatalf("first Run() error = %v", err)
+	}
+
+	// The item is edited, and the provider fails while re-embedding it.
+	source.items[0].Body = "hangs after the upgrade"
+	embedder.Err = errors.New("provider unavailable")
+	if _, err := b.Run(context.Background()); err == nil {
+		t.Fatalf("Run() with failing embedder should return an error")
+	}
+	if _, ok := idx.pending["issue/1"]; !ok {
+		t.Fatalf("item whose embedding failed should be pending")
+	}
+	if _, ok := idx.vectors["issue/1"]; ok {
+		t.Fatalf("the old vector should not stay behind for the changed content")
+	}
+
+	embedder.Err = nil
+	embedder.Vectors = [][]float32{{0, 1, 0}}
+	stats, err := b.Run(context.Background())
+	if err != nil {
+		t.Fatalf("resumed Run() error = %v", err)
+	}
+	if stats.Embedded != 1 {
+		t.Fatalf("embedded = %d on resume, want the changed item re-embedded", stats.Embedded)
+	}
+	if got := idx.vectors["issue/1"]; len(got) != 3 || got[1] != 1 {
+		t.Fatalf("stored vector = %v, want the new embedding", got)
+	}
+	if _, ok := idx.pending["issue/1"]; ok {
+		t.Fatalf("re-embedded item should leave the pending queue")
+	}
+	if idx.items["issue/1"].EmbeddingModel != embedder.Model() {
+		t.Fatalf("fingerprint model = %q, want %q", idx.items["issue/1"].EmbeddingModel, embedder.Model())
+	}
+}
+
+func TestBackfill_ResumesFromCheckpointAndStopsOnRateLimit(t *testing.T) {
+	t.Helper()
+
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	rateLimited := errors.New("rate limited")
+	source := &fakeItemSource{
+		perPage: 1,
+		items: []gh.Event{
+			{Type: "issue", Number: 100, Title: "done", Body: "b", UpdatedAt: base.Add(-time.Hour)},
+			{Type: "issue", Number: 101, Title: "a", Body: "b", UpdatedAt: base},
+			{Type: "issue", Number: 102, Title: "c", Body: "d", UpdatedAt: base},
+			{Type: "issue", Number: 103, Title: "e", Body: "f", UpdatedAt: base.Add(time.Hour)},
+			{Type: "issue", Number: 104, Title: "g", Body: "h", UpdatedAt: base.Add(2 * time.Hour)},
+		},
+		failOnCall: 4,
+		err:        rateLimited,
+	}
+	idx := newFakeBackfillIndexer()
+	idx.meta[BackfillCursorKey] = base.Format(time.RFC3339Nano)
+
+	b := &Backfiller{Source: source, Embedder: &embed.MockEmbedder{Dims: 3}, Store: idx}
+	stats, err := b.Run(context.Background())
+	if !errors.Is(err, rateLimited) {
+		t.Fatalf("Run() error = %v, want rate limit error", err)
+	}
+	if !stats.StartCursor.Equal(base) || stats.Completed {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if !source.since[0].Equal(base) {
+		t.Fatalf("first listing since = %v, want the checkpoint %v", source.since[0], base)
+	}
+	// Two items share the checkpoint's timestamp: the listing steps past them
+	// by page, then restarts from the next newer item.
+	if got := source.pages; len(got) != 4 || got[1] != 2 || got[2] != 3 || got[3] != 1 {
+		t.Fatalf("requested pages = %v, want [1 2 3 1]", got)
+	}
+	if _, ok := idx.items["issue/100"]; ok {
+		t.Fatalf("items before the checkpoint should not be listed again")
+	}
+	if _, ok := idx.items["issue/103"]; !ok {
+		t.Fatalf("issue/103 should be indexed before the rate limit")
+	}
+	if want := base.Add(time.Hour).Format(time.RFC3339Nano); idx.meta[BackfillCursorKey] != want {
+		t.Fatalf("checkpoint = %q, want %q", idx.meta[BackfillCursorKey], want)
+	}
+}
+
+// fakeItemSource serves items like the issues API sorted by update time:
+// since is inclusive and pages are offsets into the filtered list.
+type fakeItemSource struct {
+	items      []gh.Event
+	perPage    int
+	files      map[int][]string
+	failOnCall int
+	err        error
+	afterList  func()
+
+	since []time.Time
+	pages []int
+}
+
+func (f *fakeItemSource) ListRepositoryItems(ctx context.Context, owner, repo string, since time.Time, page int) ([]gh.Event, int, error) {
+	_ = ctx
+	_ = owner
+	_ = repo
+	f.since = append(f.since, since)
+	f.pages = append(f.pages, page)
+	if len(f.pages) == f.failOnCall {
+		return nil, 0, f.err
+	}
+	defer func() {
+		if f.afterList != nil {
+			f.afterList()
+		}
+	}()
+
+	var matching []gh.Event
+	for _, item := range f.items {
+		if !item.UpdatedAt.Before(since) {
+			matching = append(matching, item)
+		}
+	}
+	perPage := f.perPage
+	if perPage <= 0 {
+		perPage = 100
+	}
+	start := (page - 1) * perPage
+	if start >= len(matching) {
+		return nil, 0, nil
+	}
+	end := min(start+perPage, len(matching))
+	next := 0
+	if end < len(matching) {
+		next = page + 1
+	}
+	return append([]gh.Event(nil), matching[start:end]...), next, nil
+}
+
+func (f *fakeItemSource) ListPullRequestFiles(ctx context.Context, owner, repo string, number int) ([]string, error) {
+	_ = ctx
+	_ = owner
+	_ = repo
+	return f.files[number], nil
+}
+
+type fakeBackfillIndexer struct {
+	items   map[string]store.ItemRecord
+	vectors map[string][]float32
+	chunks  map[string][][]float32
+	pending map[string]string
+	meta    map[string]string
+}
+
+func newFakeBackfillIndexer() *fakeBackfillIndexer {
+	return &fakeBackfillIndexer{
+		items:   map[string]store.ItemRecord{},
+		vectors: map[string][]float32{},
+		pending: map[string]string{},
+		meta:    map[string]string{},
+	}
+}
+
+func (f *fakeBackfillIndexer) UpsertItem(ctx context.Context, rec store.ItemRecord) error {
+	_ = ctx
+	f.items[rec.ID] = rec
+	return nil
+}
+
+func (f *fakeBackfillIndexer) UpsertVector(ctx context.Context, id string, embedding []float32) error {
+	_ = ctx
+	f.vectors[id] = embedding
+	delete(f.pending, id)
+	return nil
+}
+
+func (f *fakeBackfillIndexer) DeleteVector(ctx context.Context, id string) error {
+	_ = ctx
+	delete(f.vectors, id)
+	delete(f.chunks, id)
+	return nil
+}
+
+func (f *fakeBackfillIndexer) SetEmbeddingFingerprint(ctx context.Context, id, contentHash, model string) error {
+	_ = ctx
+	rec := f.items[id]
+	rec.ContentHash, rec.EmbeddingModel = contentHash, model
+	f.items[id] = rec
+	return nil
+}
+
+func (f *fakeBackfillIndexer) MarkPendingEmbedding(ctx context.Context, id, reason string) error {
+	_ = ctx
+	f.pending[id] = reason
+	return nil
+}
+
+func (f *fakeBackfillIndexer) ReplaceChunkVectors(ctx context.Context, itemID string, chunks [][]float32) error {
+	_ = ctx
+	if f.chunks == nil {
+		f.chunks = map[string][][]float32{}
+	}
+	f.chunks[itemID] = chunks
+	return nil
+}
+
+func (f *fakeBackfillIndexer) LookupEmbedding(ctx context.Context, id, contentHash, model string) ([]float32, bool, error) {
+	_ = ctx
+	rec, ok := f.items[id]
+	vec, hasVec := f.vectors[id]
+	if !ok || !hasVec || rec.ContentHash != contentHash || rec.EmbeddingModel != model {
+		return nil, false, nil
+	}
+	return vec, true, nil
+}
+
+func (f *fakeBackfillIndexer) GetMeta(ctx context.Context, key string) (string, bool, error) {
+	_ = ctx
+	value, ok := f.meta[key]
+	return value, ok, nil
+}
+
+func (f *fakeBackfillIndexer) SetMeta(ctx context.Context, key, value string) error {
+	_ = ctx
+	f.meta[key] = value
+	return nil
+}
+
+func (f *fakeBackfillIndexer) DeleteMeta(ctx context.Context, key string) error {
+	_ = ctx
+	delete(f.meta, key)
+	return nil
+}
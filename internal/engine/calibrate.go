@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+
+	"vector-triage/internal/store"
+)
+
+type CalibrationOptions struct {
+	// Method is store.CalibrationPlatt (default) or store.CalibrationIsotonic.
+	Method string
+	// Depth is how many candidates each backend returns before fusion.
+	Depth int
+	// Fusion must match the strategy runs use; nil means RRF.
+	Fusion store.FusionStrategy
+}
+
+// FitCalibration replays retrieval for every labeled pair and fits a
+// duplicate-probability model on the scores each candidate got. Each
+// unordered pair gives one sample, scored with A as the item being triaged,
+// or B when A is not indexed; a later label for the same pair replaces an
+// earlier one. A candidate the backends did not return at all counts as
+// scoring zero.
+func FitCalibration(ctx context.Context, st EvalStore, pairs []LabeledPair, opts CalibrationOptions) (store.Calibration, error) {
+	if st == nil {
+		return store.Calibration{}, errors.New("store dependency is required")
+	}
+	if opts.Depth <= 0 {
+		opts.Depth = defaultEvalDepth
+	}
+	if opts.Fusion == nil {
+		opts.Fusion = store.WeightedRRF{}
+	}
+
+	unique := uniqueLabeledPairs(pairs)
+	samples := make([]store.CalibrationSample, 0, len(unique))
+	for pass := 0; pass < 2 && len(unique) > 0; pass++ {
+		byQuery := map[string][]LabeledPair{}
+		for _, pair := range unique {
+			byQuery[pair.A] = append(byQuery[pair.A], pair)
+		}
+		queries := make([]string, 0, len(byQuery))
+		for id := range byQuery {
+			queries = append(queries, id)
+		}
+		sort.Strings(queries)
+
+		// Pairs whose A is not indexed are retried once from B's side.
+		var flipped []LabeledPair
+		for _, id := range queries {
+			results, _, err := evalSearch(ctx, st, id, opts.Depth, opts.Fusion)
+			if errors.Is(err, sql.ErrNoRows) {
+				for _, pair := range byQuery[id] {
+					flipped = append(flipped, LabeledPair{A: pair.B, B: pair.A, Duplicate: pair.Duplicate})
+				}
+				continue
+			}
+			if err != nil {
+				return store.Calibration{}, err
+			}
+			byID := make(map[string]store.FusedResult, len(results))
+			for _, result := range results {
+				byID[result.ID] = result
+			}
+			for _, pair := range byQuery[id] {
+				result := byID[pair.B]
+				samples = append(samples, store.CalibrationSample{
+					VecScore:   result.VecScore,
+					FTSScore:   result.FTSScore,
+					NearScore:  result.NearScore,
+					Similarity: result.DisplaySimilarity,
+					Duplicate:  pair.Duplicate,
+				})
+			}
+		}
+		unique = flipped
+	}
+
+	cal, err := store.FitCalibration(opts.Method, samples)
+	if err != nil {
+		return store.Calibration{}, err
+	}
+	cal.Fusion = opts.Fusion.Name()
+	return cal, nil
+}
+
+// uniqueLabeledPairs drops self pairs and keeps one entry per unordered pair,
+// in first-seen order, carrying the last label given for it.
+func uniqueLabeledPairs(pairs []LabeledPair) []LabeledPair {
+	index := map[[2]string]int{}
+	out := make([]LabeledPair, 0, len(pairs))
+	for _, pair := range pairs {
+		if pair.A == "" || pair.B == "" || pair.A == pair.B {
+			continue
+		}
+		key := [2]string{pair.A, pair.B}
+		if key[0] > key[1] {
+			key[0], key[1] = key[1], key[0]
+		}
+		if i, ok := index[key]; ok {
+			out[i].Duplicate = pair.Duplicate
+			continue
+		}
+		index[key] = len(out)
+		out = append(out, pair)
+	}
+	return out
+}
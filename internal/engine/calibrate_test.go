@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"vector-triage/internal/store"
+)
+
+func TestFitCalibration_LearnsFromReplayedScores(t *testing.T) {
+	t.Helper()
+
+	st := &fakeEvalStore{items: map[string]store.ItemRecord{}, vectors: map[string][]store.VectorResult{}}
+	var pairs []LabeledPair
+	for i := 1; i <= 6; i++ {
+		query, dup, other := fmt.Sprintf("issue/%d", i), fmt.Sprintf("issue/%d", 100+i), fmt.Sprintf("issue/%d", 200+i)
+		st.items[query] = store.ItemRecord{ID: query, Type: "issue", Number: i, Title: "query"}
+		st.vectors[query] = []store.VectorResult{
+			{ID: dup, VecScore: 0.90 + float64(i)/100},
+			{ID: other, VecScore: 0.60 + float64(i)/100},
+		}
+		pairs = append(pairs,
+			LabeledPair{A: query, B: dup, Duplicate: true},
+			LabeledPair{A: query, B: other, Duplicate: false},
+		)
+	}
+
+	cal, err := FitCalibration(context.Background(), st, pairs, CalibrationOptions{})
+	if err != nil {
+		t.Fatalf("FitCalibration() error = %v", err)
+	}
+	if cal.Method != store.CalibrationPlatt || cal.Fusion != store.FusionRRF || cal.Pairs != 12 || cal.Duplicates != 6 {
+		t.Fatalf("unexpected calibration: %+v", cal)
+	}
+	if high, low := cal.Probability(0.93, 0, 0, 0.93), cal.Probability(0.63, 0, 0, 0.63); high < 0.8 || low > 0.2 {
+		t.Fatalf("Probability() = %.3f for a duplicate score and %.3f for a distinct one", high, low)
+	}
+
+	if _, err := FitCalibration(context.Background(), st, pairs[:4], CalibrationOptions{}); err == nil {
+		t.Fatalf("expected error for too few labeled pairs")
+	}
+}
+
+func TestFitCalibration_CountsEachUnorderedPairOnce(t *testing.T) {
+	t.Helper()
+
+	st := &fakeEvalStore{items: map[string]store.ItemRecord{}, vectors: map[string][]store.VectorResult{}}
+	var pairs, reversed []LabeledPair
+	for i := 1; i <= 6; i++ {
+		query, dup, other := fmt.Sprintf("issue/%d", i), fmt.Sprintf("issue/%d", 100+i), fmt.Sprintf("issue/%d", 200+i)
+		st.items[query] = store.ItemRecord{ID: query, Type: "issue", Number: i, Title: "query"}
+		st.vectors[query] = []store.VectorResult{
+			{ID: dup, VecScore: 0.90 + float64(i)/100},
+			{ID: other, VecScore: 0.60 + float64(i)/100},
+		}
+		pairs = append(pairs,
+			LabeledPair{A: query, B: dup, Duplicate: true},
+			LabeledPair{A: query, B: other, Duplicate: false},
+		)
+		reversed = append(reversed,
+			LabeledPair{A: dup, B: query, Duplicate: true},
+			LabeledPair{A: other, B: query, Duplicate: false},
+		)
+	}
+
+	// Labeling every pair twice, once per direction, must not double its weight.
+	cal, err := FitCalibration(context.Background(), st, append(append([]LabeledPair(nil), pairs...), reversed...), CalibrationOptions{})
+	if err != nil {
+		t.Fatalf("FitCalibration() error = %v", err)
+	}
+	if cal.Pairs != 12 || cal.Duplicates != 6 {
+		t.Fatalf("calibration pairs = %d (%d duplicate), want 12 (6)", cal.Pairs, cal.Duplicates)
+	}
+
+	// When A is not indexed the pair is scored from B's side instead.
+	cal, err = FitCalibration(context.Background(), st, reversed, CalibrationOptions{})
+	if err != nil {
+		t.Fatalf("FitCalibration(reversed) error = %v", err)
+	}
+	if cal.Pairs != 12 || cal.Duplicates != 6 {
+		t.Fatalf("reversed calibration pairs = %d (%d duplicate), want 12 (6)", cal.Pairs, cal.Duplicates)
+	}
+}
@@ -0,0 +1,30 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"vector-triage/internal/embed"
+)
+
+// embedChunks embeds the overlapping chunks of long content so text far down
+// a body (logs, stack traces) is searchable. Content that fits in one chunk
+// returns nil; the whole-item vector already covers it.
+func embedChunks(ctx context.Context, embedder embed.Embedder, content string) ([][]float32, error) {
+	chunks, err := embed.SplitChunks(content, embed.ChunkOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("split chunks: %w", err)
+	}
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+
+	vectors, err := embedder.EmbedBatch(ctx, chunks)
+	if err != nil {
+		return nil, fmt.Errorf("embed chunks: %w", err)
+	}
+	if len(vectors) != len(chunks) {
+		return nil, fmt.Errorf("embed batch returned %d vectors for %d chunks", len(vectors), len(chunks))
+	}
+	return vectors, nil
+}
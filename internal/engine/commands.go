@@ -0,0 +1,228 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	gh "vector-triage/internal/github"
+	"vector-triage/internal/store"
+)
+
+const slashCommandPrefix = "/triage"
+
+type SlashCommandKind string
+
+const (
+	SlashCommandRescan       SlashCommandKind = "rescan"
+	SlashCommandNotDuplicate SlashCommandKind = "not-duplicate"
+	SlashCommandDuplicateOf  SlashCommandKind = "duplicate-of"
+)
+
+// SlashCommand is one parsed `/triage <command> [#number]` line from an issue comment.
+type SlashCommand struct {
+	Kind   SlashCommandKind
+	Target int
+}
+
+// triagePermissions are the repository roles allowed to drive the bot.
+var triagePermissions = map[string]struct{}{
+	"admin":    {},
+	"maintain": {},
+	"write":    {},
+	"triage":   {},
+}
+
+// ParseSlashCommands extracts every /triage command from a comment body.
+// Malformed commands are reported in the returned error and skipped.
+func ParseSlashCommands(body string) ([]SlashCommand, error) {
+	var cmds []SlashCommand
+	var errs []error
+
+	for _, line := range strings.Split(body, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || !strings.EqualFold(fields[0], slashCommandPrefix) {
+			continue
+		}
+		if len(fields) < 2 {
+			errs = append(errs, errors.New("missing /triage subcommand"))
+			continue
+		}
+
+		kind := SlashCommandKind(strings.ToLower(fields[1]))
+		switch kind {
+		case SlashCommandRescan:
+			cmds = append(cmds, SlashCommand{Kind: kind})
+		case SlashCommandNotDuplicate, SlashCommandDuplicateOf:
+			if len(fields) < 3 {
+				errs = append(errs, fmt.Errorf("/triage %s needs an item number like #123", kind))
+				continue
+			}
+			number, err := strconv.Atoi(strings.TrimPrefix(fields[2], "#"))
+			if err != nil || number <= 0 {
+				errs = append(errs, fmt.Errorf("/triage %s: invalid item number %q", kind, fields[2]))
+				continue
+			}
+			cmds = append(cmds, SlashCommand{Kind: kind, Target: number})
+		default:
+			errs = append(errs, fmt.Errorf("unknown /triage subcommand %q", fields[1]))
+		}
+	}
+
+	return cmds, errors.Join(errs...)
+}
+
+// NeedsFullContent reports whether handling event will embed content, so callers
+// know when fetching PR files and diffs is worth the API calls.
+func NeedsFullContent(event gh.Event) bool {
+	switch ClassifyEvent(event) {
+	case EventKindContent:
+		return true
+	case EventKindComment:
+		cmds, _ := ParseSlashCommands(event.Comment.Body)
+		return isNewComment(event) && len(cmds) > 0
+	default:
+		return false
+	}
+}
+
+// NeedsIndex reports whether handling event reads or writes the index. Most
+// comments carry no /triage command, so callers skip pulling and pushing the
+// index for them.
+func NeedsIndex(event gh.Event) bool {
+	if ClassifyEvent(event) != EventKindComment {
+		return true
+	}
+	if !isNewComment(event) || strings.Contains(event.Comment.Body, gh.CommentMarker) {
+		return false
+	}
+	// Malformed commands still need handling so their errors are reported.
+	cmds, err := ParseSlashCommands(event.Comment.Body)
+	return len(cmds) > 0 || err != nil
+}
+
+// handleComment applies slash commands from users with triage access, then
+// re-runs the normal flow so the triage comment reflects any new verdicts.
+// changed is false when the comment was ignored.
+func (e *Engine) handleComment(ctx context.Context, event gh.Event, currentID string) (changed bool, err error) {
+	if !isNewComment(event) || strings.Contains(event.Comment.Body, gh.CommentMarker) {
+		return false, nil
+	}
+
+	cmds, parseErr := ParseSlashCommands(event.Comment.Body)
+	if parseErr != nil {
+		e.warn(parseErr)
+	}
+	if len(cmds) == 0 {
+		return false, nil
+	}
+
+	allowed, err := e.canTriage(ctx, event)
+	if err != nil {
+		return false, err
+	}
+	if !allowed {
+		e.warn(fmt.Errorf("ignoring /triage command from %s: write or triage access required", event.Comment.Author))
+		return false, nil
+	}
+
+	for _, cmd := range cmds {
+		if cmd.Kind == SlashCommandRescan {
+			continue
+		}
+		if err := e.applyPairCommand(ctx, event, currentID, cmd); err != nil {
+			return true, err
+		}
+	}
+
+	rescan, err := e.rescanEvent(ctx, event, currentID)
+	if err != nil {
+		return true, err
+	}
+	return true, e.handleContent(ctx, rescan, currentID)
+}
+
+// rescanEvent turns a comment event into the content event it re-runs. The
+// issue_comment payload describes a pull request as an issue: its state is
+// only open or closed and it carries no draft flag, base branch or files, so
+// those are kept from the indexed row rather than overwritten.
+func (e *Engine) rescanEvent(ctx context.Context, event gh.Event, currentID string) (gh.Event, error) {
+	rescan := event
+	rescan.Comment = nil
+	rescan.Action = string(SlashCommandRescan)
+	if normalizeItemType(event.Type) != "pr" {
+		return rescan, nil
+	}
+
+	stored, err := e.Store.GetItem(ctx, currentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return rescan, nil
+	}
+	if err != nil {
+		return gh.Event{}, fmt.Errorf("load %s for rescan: %w", currentID, err)
+	}
+	if stored.State == "merged" && strings.EqualFold(event.State, "closed") {
+		rescan.State = stored.State
+	}
+	rescan.Draft = stored.Draft
+	if rescan.BaseBranch == "" {
+		rescan.BaseBranch = stored.BaseBranch
+	}
+	if len(rescan.Files) == 0 {
+		rescan.Files = stored.Files
+	}
+	return rescan, nil
+}
+
+func (e *Engine) applyPairCommand(ctx context.Context, event gh.Event, currentID string, cmd SlashCommand) error {
+	if cmd.Target == event.Number {
+		e.warn(fmt.Errorf("/triage %s: #%d cannot reference itself", cmd.Kind, cmd.Target))
+		return nil
+	}
+
+	otherID, found, err := e.Store.LookupItemIDByNumber(ctx, cmd.Target)
+	if err != nil {
+		return fmt.Errorf("resolve #%d: %w", cmd.Target, err)
+	}
+	if !found {
+		e.warn(fmt.Errorf("/triage %s: #%d is not in the triage index", cmd.Kind, cmd.Target))
+		return nil
+	}
+
+	verdict := store.PairVerdictNotDuplicate
+	if cmd.Kind == SlashCommandDuplicateOf {
+		verdict = store.PairVerdictDuplicate
+	}
+	if err := e.Store.RecordPairFeedback(ctx, store.PairFeedback{
+		ItemA:   currentID,
+		ItemB:   otherID,
+		Verdict: verdict,
+		Actor:   event.Comment.Author,
+	}); err != nil {
+		return fmt.Errorf("record pair feedback: %w", err)
+	}
+	return nil
+}
+
+func (e *Engine) canTriage(ctx context.Context, event gh.Event) (bool, error) {
+	if e.Permissions == nil {
+		return false, errors.New("permission checker dependency is required for slash commands")
+	}
+	if strings.TrimSpace(event.Comment.Author) == "" {
+		return false, nil
+	}
+
+	level, err := e.Permissions.GetPermissionLevel(ctx, event.Owner, event.Repo, event.Comment.Author)
+	if err != nil {
+		return false, fmt.Errorf("check commenter permission: %w", err)
+	}
+	_, ok := triagePermissions[strings.ToLower(level)]
+	return ok, nil
+}
+
+func isNewComment(event gh.Event) bool {
+	return event.Comment != nil && strings.EqualFold(strings.TrimSpace(event.Action), "created")
+}
@@ -0,0 +1,176 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"vector-triage/internal/embed"
+	gh "vector-triage/internal/github"
+	"vector-triage/internal/store"
+)
+
+func TestParseSlashCommands(t *testing.T) {
+	t.Helper()
+
+	body := "Thanks!\n/triage rescan\n/triage not-duplicate #123\n/TRIAGE duplicate-of 45\n/triage duplicate-of #abc\n/triage explode"
+	cmds, err := ParseSlashCommands(body)
+	if err == nil {
+		t.Fatalf("expected errors for malformed commands")
+	}
+
+	want := []SlashCommand{
+		{Kind: SlashCommandRescan},
+		{Kind: SlashCommandNotDuplicate, Target: 123},
+		{Kind: SlashCommandDuplicateOf, Target: 45},
+	}
+	if len(cmds) != len(want) {
+		t.Fatalf("ParseSlashCommands() = %+v, want %+v", cmds, want)
+	}
+	for i := range want {
+		if cmds[i] != want[i] {
+			t.Fatalf("command[%d] = %+v, want %+v", i, cmds[i], want[i])
+		}
+	}
+
+	if cmds, err := ParseSlashCommands("no commands here"); err != nil || len(cmds) != 0 {
+		t.Fatalf("plain comment parsed as commands: %+v, %v", cmds, err)
+	}
+}
+
+func TestHandle_SlashCommandRecordsVerdictAndRescans(t *testing.T) {
+	t.Helper()
+
+	mockStore := &mockSearchIndexer{numberIDs: map[int]string{123: "issue/123"}}
+	mockComments := &mockCommentManager{}
+	embedder := &embed.MockEmbedder{Dims: 3}
+	eng := &Engine{
+		Embedder:    embedder,
+		Store:       mockStore,
+		Comments:    mockComments,
+		Permissions: fakePermissions{"maint": "maintain"},
+	}
+
+	event := gh.Event{
+		Type: "issue", Action: "created", Owner: "acme", Repo: "repo", Number: 7, Title: "login", Body: "hangs",
+		Comment: &gh.IssueComment{ID: 1, Body: "/triage not-duplicate #123", Author: "maint"},
+	}
+	if err := eng.Handle(context.Background(), event); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if len(mockStore.feedback) != 1 {
+		t.Fatalf("feedback records = %d, want 1", len(mockStore.feedback))
+	}
+	fb := mockStore.feedback[0]
+	if fb.ItemA != "issue/7" || fb.ItemB != "issue/123" || fb.Verdict != store.PairVerdictNotDuplicate || fb.Actor != "maint" {
+		t.Fatalf("unexpected feedback: %+v", fb)
+	}
+	if embedder.Calls != 1 || mockComments.calls != 1 {
+		t.Fatalf("expected rescan to embed and refresh comment, embeds=%d comments=%d", embedder.Calls, mockComments.calls)
+	}
+}
+
+func TestHandle_RescanKeepsMergedPullRequestState(t *testing.T) {
+	t.Helper()
+
+	mockStore := &mockSearchIndexer{items: map[string]store.ItemRecord{
+		"pr/9": {ID: "pr/9", Type: "pr", Number: 9, State: "merged", Draft: true, BaseBranch: "release", Files: []string{"auth/login.go"}},
+	}}
+	eng := &Engine{
+		Embedder:    &embed.MockEmbedder{Dims: 3},
+		Store:       mockStore,
+		Comments:    &mockCommentManager{},
+		Permissions: fakePermissions{"maint": "maintain"},
+	}
+
+	// issue_comment describes the merged PR as a closed issue.
+	event := gh.Event{
+		Type: "pr", Action: "created", Owner: "acme", Repo: "repo", Number: 9, Title: "fix login", Body: "retry auth", State: "closed",
+		Comment: &gh.IssueComment{ID: 1, Body: "/triage rescan", Author: "maint"},
+	}
+	if err := eng.Handle(context.Background(), event); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	got := mockStore.upsertItem
+	if got.State != "merged" || !got.Draft || got.BaseBranch != "release" || len(got.Files) != 1 {
+		t.Fatalf("rescanned PR = %+v, want the stored state, draft, base branch and files", got)
+	}
+	if got.Title != "fix login" || got.Body != "retry auth" {
+		t.Fatalf("rescanned PR = %+v, want the refreshed title and body", got)
+	}
+}
+
+func TestHandle_SlashCommandIgnoredWithoutPermission(t *testing.T) {
+	t.Helper()
+
+	mockStore := &mockSearchIndexer{numberIDs: map[int]string{45: "issue/45"}}
+	mockComments := &mockCommentManager{}
+	var warnings []error
+	eng := &Engine{
+		Embedder:    &embed.MockEmbedder{Dims: 3},
+		Store:       mockStore,
+		Comments:    mockComments,
+		Permissions: fakePermissions{"drive-by": "read"},
+		Warn:        func(err error) { warnings = append(warnings, err) },
+	}
+
+	event := gh.Event{
+		Type: "issue", Action: "created", Owner: "acme", Repo: "repo", Number: 7,
+		Comment: &gh.IssueComment{ID: 1, Body: "/triage duplicate-of #45", Author: "drive-by"},
+	}
+	changed, err := eng.Process(context.Background(), event)
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if changed {
+		t.Fatalf("ignored command should report no index change")
+	}
+	if len(mockStore.feedback) != 0 || mockComments.calls != 0 {
+		t.Fatalf("unauthorized command should be ignored")
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("warnings = %d, want 1", len(warnings))
+	}
+}
+
+func TestNeedsIndex(t *testing.T) {
+	t.Helper()
+
+	comment := func(action, body string) gh.Event {
+		return gh.Event{Type: "issue", Action: action, Number: 7, Comment: &gh.IssueComment{ID: 1, Body: body, Author: "someone"}}
+	}
+	tests := []struct {
+		name  string
+		event gh.Event
+		want  bool
+	}{
+		{name: "content event", event: gh.Event{Type: "issue", Action: "opened", Number: 7}, want: true},
+		{name: "metadata event", event: gh.Event{Type: "issue", Action: "labeled", Number: 7}, want: true},
+		{name: "plain comment", event: comment("created", "Same here, thanks!"), want: false},
+		{name: "edited command", event: comment("edited", "/triage rescan"), want: false},
+		{name: "bot comment", event: comment("created", gh.CommentMarker+"\n/triage rescan"), want: false},
+		{name: "command", event: comment("created", "/triage rescan"), want: true},
+		{name: "malformed command", event: comment("created", "/triage explode"), want: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Helper()
+
+			if got := NeedsIndex(tt.event); got != tt.want {
+				t.Fatalf("NeedsIndex() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type fakePermissions map[string]string
+
+func (f fakePermissions) GetPermissionLevel(ctx context.Context, owner, repo, user string) (string, error) {
+	_ = ctx
+	_ = owner
+	_ = repo
+	return f[user], nil
+}
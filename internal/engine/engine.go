@@ -9,20 +9,37 @@ import (
 	"vector-triage/internal/embed"
 	gh "vector-triage/internal/github"
 	"vector-triage/internal/ingest"
+	"vector-triage/internal/rerank"
 	"vector-triage/internal/store"
 )
 
 type SearchIndexer interface {
-	SearchVector(ctx context.Context, queryEmbedding []float32, excludeID string, limit int) ([]store.VectorResult, error)
-	SearchFTS(ctx context.Context, query string, excludeID string, limit int) ([]store.FTSResult, error)
+	SearchVectors(ctx context.Context, queries [][]float32, excludeID string, limit int, filter store.SearchFilter) ([]store.VectorResult, error)
+	SearchFTS(ctx context.Context, query string, excludeID string, limit int, filter store.SearchFilter) ([]store.FTSResult, error)
+	SearchNearDuplicates(ctx context.Context, title, body, excludeID string, limit int, filter store.SearchFilter) ([]store.NearDuplicateResult, error)
 	UpsertItem(ctx context.Context, rec store.ItemRecord) error
 	UpsertVector(ctx context.Context, id string, embedding []float32) error
+	DeleteVector(ctx context.Context, id string) error
+	ReplaceChunkVectors(ctx context.Context, itemID string, chunks [][]float32) error
+	LookupChunkVectors(ctx context.Context, itemID string) ([][]float32, error)
+	MarkPendingEmbedding(ctx context.Context, id, reason string) error
+	LookupEmbedding(ctx context.Context, id, contentHash, model string) ([]float32, bool, error)
+	UpdateItemMetadata(ctx context.Context, rec store.ItemRecord) (bool, error)
+	DeleteItem(ctx context.Context, id string) error
+	PairVerdictsFor(ctx context.Context, id string) (map[string]store.PairVerdict, error)
+	RecordPairFeedback(ctx context.Context, fb store.PairFeedback) error
+	LookupItemIDByNumber(ctx context.Context, number int) (string, bool, error)
+	GetItem(ctx context.Context, id string) (store.ItemRecord, error)
 }
 
 type CommentManager interface {
 	UpsertTriageComment(ctx context.Context, owner, repo string, number int, body string) (gh.CommentAction, error)
 }
 
+type PermissionChecker interface {
+	GetPermissionLevel(ctx context.Context, owner, repo, user string) (string, error)
+}
+
 type Formatter interface {
 	Format(event gh.Event, results []store.FusedResult) string
 }
@@ -31,70 +48,178 @@ type Config struct {
 	SimilarityThreshold float64
 	DuplicateThreshold  float64
 	MaxResults          int
+	// Fusion merges the vector, FTS and near-duplicate lists; nil means RRF.
+	Fusion store.FusionStrategy
+	// Calibration makes DuplicateThreshold a probability; nil uses raw similarity.
+	Calibration *store.Calibration
+	// RerankTopN is how many fused candidates the reranker rescores (default 5).
+	RerankTopN int
+	// RerankThreshold is the rerank score a duplicate must reach (default 0.5).
+	RerankThreshold float64
+	// Recency decays stale candidates and boosts open ones; zero is off.
+	Recency store.Recency
+	// SearchFilter restricts which indexed items can be suggested.
+	SearchFilter store.SearchFilter
 }
 
 type Engine struct {
-	Embedder  embed.Embedder
-	Store     SearchIndexer
-	Comments  CommentManager
-	Formatter Formatter
-	Config    Config
+	Embedder    embed.Embedder
+	Store       SearchIndexer
+	Comments    CommentManager
+	Permissions PermissionChecker
+	Duplicates  DuplicateResolver
+	Formatter   Formatter
+	Reranker    rerank.Reranker
+	Config      Config
+	Warn        func(error)
 }
 
 func (e *Engine) Handle(ctx context.Context, event gh.Event) error {
+	_, err := e.Process(ctx, event)
+	return err
+}
+
+// Process handles event like Handle and reports whether the index may have
+// changed. Ignored comments leave it untouched, so callers can skip the push.
+func (e *Engine) Process(ctx context.Context, event gh.Event) (changed bool, err error) {
 	if e == nil {
-		return errors.New("nil engine")
+		return false, errors.New("nil engine")
 	}
 	if e.Store == nil {
-		return errors.New("store dependency is required")
+		return false, errors.New("store dependency is required")
 	}
 	if e.Comments == nil {
-		return errors.New("comment manager dependency is required")
+		return false, errors.New("comment manager dependency is required")
 	}
 
 	currentID := store.BuildItemID(event.Type, event.Number)
+	switch ClassifyEvent(event) {
+	case EventKindMetadata:
+		return true, e.handleMetadata(ctx, event, currentID)
+	case EventKindRemoval:
+		return true, e.handleRemoval(ctx, currentID)
+	case EventKindComment:
+		return e.handleComment(ctx, event, currentID)
+	}
+	return true, e.handleContent(ctx, event, currentID)
+}
+
+// handleContent embeds and indexes the item, searches for similar ones and
+// refreshes the triage comment.
+func (e *Engine) handleContent(ctx context.Context, event gh.Event, currentID string) error {
 	content := buildEmbeddableContent(event)
+	contentHash := ingest.ContentHash(content)
 
 	var embedding []float32
+	var chunkVectors [][]float32
+	var embedErr, chunkErr error
+	reusedEmbedding := false
 	var vecResults []store.VectorResult
 	var ftsResults []store.FTSResult
+	var nearResults []store.NearDuplicateResult
 
 	if strings.TrimSpace(content) != "" {
 		if e.Embedder == nil {
 			return errors.New("embedder dependency is required when content is available")
 		}
 
-		vec, err := e.Embedder.Embed(ctx, content)
+		limit := e.maxResults()
+		stored, found, err := e.Store.LookupEmbedding(ctx, currentID, contentHash, e.Embedder.Model())
 		if err != nil {
-			return fmt.Errorf("embed content: %w", err)
+			return fmt.Errorf("lookup stored embedding: %w", err)
+		}
+		if found {
+			embedding, reusedEmbedding = stored, true
+		} else {
+			embedding, embedErr = e.Embedder.Embed(ctx, content)
 		}
-		embedding = vec
+		if embedErr != nil {
+			// Spec 8.4: keep indexing metadata and degrade to keyword-only search.
+			if embed.IsInvalidEmbedding(embedErr) {
+				// Retrying will not help; the provider and configured dimensions disagree.
+				e.warn(fmt.Errorf("embedding provider returned an unusable vector (check embedding-model and embedding-dimensions), falling back to keyword-only search: %w", embedErr))
+			} else {
+				e.warn(fmt.Errorf("embed content, falling back to keyword-only search: %w", embedErr))
+			}
+			embedding = nil
+		} else {
+			if reusedEmbedding {
+				chunkVectors, err = e.Store.LookupChunkVectors(ctx, currentID)
+				if err != nil {
+					return fmt.Errorf("lookup stored chunk vectors: %w", err)
+				}
+			} else if chunkVectors, chunkErr = embedChunks(ctx, e.Embedder, content); chunkErr != nil {
+				// The whole-item vector still works; chunks are retried from the pending queue.
+				e.warn(fmt.Errorf("embed chunks, searching with the whole-item vector only: %w", chunkErr))
+			}
 
-		limit := e.maxResults()
-		vecResults, err = e.Store.SearchVector(ctx, embedding, currentID, limit)
-		if err != nil {
-			return fmt.Errorf("vector search: %w", err)
+			queries := append([][]float32{embedding}, chunkVectors...)
+			vecResults, err = e.Store.SearchVectors(ctx, queries, currentID, limit, e.Config.SearchFilter)
+			if err != nil {
+				return fmt.Errorf("vector search: %w", err)
+			}
 		}
-		ftsResults, err = e.Store.SearchFTS(ctx, content, currentID, limit)
+
+		ftsResults, err = e.Store.SearchFTS(ctx, content, currentID, limit, e.Config.SearchFilter)
 		if err != nil {
 			return fmt.Errorf("fts search: %w", err)
 		}
+		nearResults, err = e.Store.SearchNearDuplicates(ctx, event.Title, event.Body, currentID, limit, e.Config.SearchFilter)
+		if err != nil {
+			return fmt.Errorf("near-duplicate search: %w", err)
+		}
+	}
+
+	overrides, err := e.Store.PairVerdictsFor(ctx, currentID)
+	if err != nil {
+		return fmt.Errorf("load pair overrides: %w", err)
 	}
 
-	fused := store.FuseResults(vecResults, ftsResults, currentID, store.FuseConfig{
+	fused := store.FuseResults(vecResults, ftsResults, nearResults, currentID, store.FuseConfig{
 		SimilarityThreshold: e.similarityThreshold(),
 		DuplicateThreshold:  e.duplicateThreshold(),
 		MaxResults:          e.maxResults(),
+		Overrides:           overrides,
+		Strategy:            e.Config.Fusion,
+		Calibration:         e.Config.Calibration,
+		Recency:             e.Config.Recency,
 	})
+	if embedErr != nil {
+		markKeywordOnly(fused, overrides)
+	}
+	fused, err = e.pinConfirmedDuplicates(ctx, fused, overrides)
+	if err != nil {
+		return err
+	}
+	fused = e.rerankResults(ctx, event, fused)
 
 	item := buildItemRecord(event, currentID)
+	item.ContentHash = contentHash
+	if len(embedding) > 0 {
+		item.EmbeddingModel = e.Embedder.Model()
+	}
 	if err := e.Store.UpsertItem(ctx, item); err != nil {
 		return fmt.Errorf("upsert item: %w", err)
 	}
-	if len(embedding) > 0 {
+	if embedErr != nil {
+		// The stored vector was built from the old content; drop it so it stops
+		// matching until the pending queue embeds the new text.
+		if err := e.Store.DeleteVector(ctx, currentID); err != nil {
+			return fmt.Errorf("delete stale vector: %w", err)
+		}
+	}
+	if len(embedding) > 0 && !reusedEmbedding {
 		if err := e.Store.UpsertVector(ctx, currentID, embedding); err != nil {
 			return fmt.Errorf("upsert vector: %w", err)
 		}
+		if err := e.Store.ReplaceChunkVectors(ctx, currentID, chunkVectors); err != nil {
+			return fmt.Errorf("upsert chunk vectors: %w", err)
+		}
+	}
+	if pendingErr := errors.Join(embedErr, chunkErr); pendingErr != nil {
+		if err := e.Store.MarkPendingEmbedding(ctx, currentID, pendingErr.Error()); err != nil {
+			return fmt.Errorf("mark pending embedding: %w", err)
+		}
 	}
 
 	commentBody := ""
@@ -113,6 +238,22 @@ func (e *Engine) Handle(ctx context.Context, event gh.Event) error {
 	return nil
 }
 
+func (e *Engine) warn(err error) {
+	if e.Warn != nil {
+		e.Warn(err)
+	}
+}
+
+// markKeywordOnly flags FTS-only results; BM25 scores are not comparable to
+// cosine similarity, so only maintainer-confirmed pairs count as duplicates.
+func markKeywordOnly(results []store.FusedResult, overrides map[string]store.PairVerdict) {
+	for i := range results {
+		results[i].KeywordOnly = true
+		// Textual copies do not depend on embeddings, so they keep their flag.
+		results[i].IsDuplicate = results[i].NearExact || overrides[results[i].ID] == store.PairVerdictDuplicate
+	}
+}
+
 func (e *Engine) similarityThreshold() float64 {
 	if e.Config.SimilarityThreshold <= 0 {
 		return 0.75
@@ -170,6 +311,16 @@ func buildItemRecord(event gh.Event, id string) store.ItemRecord {
 		Labels: event.Labels,
 		Files:  event.Files,
 		URL:    event.URL,
+
+		StateReason: event.StateReason,
+		Milestone:   event.Milestone,
+		Assignees:   event.Assignees,
+		Draft:       event.Draft,
+		BaseBranch:  event.BaseBranch,
+
+		CreatedAt: event.CreatedAt,
+		UpdatedAt: event.UpdatedAt,
+		ClosedAt:  event.ClosedAt,
 	}
 }
 
@@ -184,6 +335,9 @@ func defaultReport(event gh.Event, results []store.FusedResult) string {
 	var b strings.Builder
 	b.WriteString(gh.CommentMarker)
 	b.WriteString("\n### Triage Report\n\n")
+	if len(results) > 0 && results[0].KeywordOnly {
+		b.WriteString("_Keyword-only matches: semantic search was unavailable for this run._\n\n")
+	}
 	for _, result := range results {
 		percent := int(result.DisplaySimilarity * 100)
 		b.WriteString(fmt.Sprintf("- #%d %s (%d%%)\n", result.Number, result.Title, percent))
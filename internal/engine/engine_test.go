@@ -2,9 +2,12 @@ package engine
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"vector-triage/internal/embed"
 	gh "vector-triage/internal/github"
@@ -27,6 +30,7 @@ func TestHandle_SearchAndCommentFlow(t *testing.T) {
 			SimilarityThreshold: 0.75,
 			DuplicateThreshold:  0.92,
 			MaxResults:          5,
+			SearchFilter:        store.SearchFilter{Types: []string{"issue"}},
 		},
 	}
 
@@ -38,6 +42,9 @@ func TestHandle_SearchAndCommentFlow(t *testing.T) {
 	if mockStore.lastVectorExcludeID != "issue/1" || mockStore.lastFTSExcludeID != "issue/1" {
 		t.Fatalf("expected excludeID=issue/1, got vector=%q fts=%q", mockStore.lastVectorExcludeID, mockStore.lastFTSExcludeID)
 	}
+	if len(mockStore.lastVectorFilter.Types) != 1 || len(mockStore.lastFTSFilter.Types) != 1 {
+		t.Fatalf("search filters = %+v / %+v, want the configured filter", mockStore.lastVectorFilter, mockStore.lastFTSFilter)
+	}
 	if mockStore.upsertItem.ID != "issue/1" {
 		t.Fatalf("upsert item id = %q, want issue/1", mockStore.upsertItem.ID)
 	}
@@ -69,17 +76,259 @@ func TestHandle_NoMatchesDeletesOrNoopsComment(t *testing.T) {
 	}
 }
 
-func TestHandle_PropagatesEmbedError(t *testing.T) {
+func TestHandle_EmbedFailureFallsBackToKeywordOnly(t *testing.T) {
 	t.Helper()
 
+	mockStore := &mockSearchIndexer{
+		ftsResults: []store.FTSResult{{ID: "issue/2", Number: 2, Title: "near", FTSScore: 0.95}},
+	}
+	mockComments := &mockCommentManager{}
+	var warnings []error
 	eng := &Engine{
 		Embedder: &embed.MockEmbedder{Err: errors.New("embed failed")},
-		Store:    &mockSearchIndexer{},
+		Store:    mockStore,
+		Comments: mockComments,
+		Warn:     func(err error) { warnings = append(warnings, err) },
+	}
+	event := gh.Event{Type: "issue", Owner: "acme", Repo: "repo", Number: 1, Title: "a", Body: "b"}
+	if err := eng.Handle(context.Background(), event); err != nil {
+		t.Fatalf("Handle() error = %v, want degraded success", err)
+	}
+
+	if len(warnings) != 1 {
+		t.Fatalf("warnings = %d, want 1", len(warnings))
+	}
+	if mockStore.searchVectorCalls != 0 {
+		t.Fatalf("vector search should be skipped without an embedding")
+	}
+	if mockStore.upsertItem.ID != "issue/1" {
+		t.Fatalf("expected item upsert without vector, got %q", mockStore.upsertItem.ID)
+	}
+	if mockStore.upsertVectorID != "" {
+		t.Fatalf("did not expect vector upsert, got %q", mockStore.upsertVectorID)
+	}
+	if mockStore.pendingID != "issue/1" {
+		t.Fatalf("pending embedding id = %q, want issue/1", mockStore.pendingID)
+	}
+	if mockStore.deletedVector != "issue/1" {
+		t.Fatalf("stale vector of the changed item should be dropped, got %q", mockStore.deletedVector)
+	}
+	if !strings.Contains(mockComments.body, "Keyword-only") {
+		t.Fatalf("expected keyword-only note in comment: %q", mockComments.body)
+	}
+}
+
+func TestHandle_NearExactCopyStaysDuplicateWithoutEmbeddings(t *testing.T) {
+	t.Helper()
+
+	mockStore := &mockSearchIndexer{
+		nearResults: []store.NearDuplicateResult{{ID: "issue/2", Number: 2, Title: "copy", Jaccard: 0.9}},
+	}
+	formatter := &captureFormatter{}
+	eng := &Engine{
+		Embedder:  &embed.MockEmbedder{Err: errors.New("embed failed")},
+		Store:     mockStore,
+		Comments:  &mockCommentManager{},
+		Formatter: formatter,
+	}
+	event := gh.Event{Type: "issue", Owner: "acme", Repo: "repo", Number: 1, Title: "a", Body: "b"}
+	if err := eng.Handle(context.Background(), event); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if len(formatter.results) != 1 {
+		t.Fatalf("results = %+v, want the near-exact copy", formatter.results)
+	}
+	if got := formatter.results[0]; !got.NearExact || !got.IsDuplicate || !got.KeywordOnly {
+		t.Fatalf("near-exact copy should stay a duplicate in keyword-only mode: %+v", got)
+	}
+}
+
+func TestHandle_InvalidEmbeddingWarnsAboutConfiguration(t *testing.T) {
+	t.Helper()
+
+	mockStore := &mockSearchIndexer{}
+	var warnings []error
+	eng := &Engine{
+		Embedder: &embed.MockEmbedder{Err: fmt.Errorf("embed batch: %w", &embed.InvalidEmbeddingError{Index: 0, Reason: "got 768 dimensions, want 1536"})},
+		Store:    mockStore,
 		Comments: &mockCommentManager{},
+		Warn:     func(err error) { warnings = append(warnings, err) },
 	}
 	event := gh.Event{Type: "issue", Owner: "acme", Repo: "repo", Number: 1, Title: "a", Body: "b"}
-	if err := eng.Handle(context.Background(), event); err == nil {
-		t.Fatalf("expected embed error")
+	if err := eng.Handle(context.Background(), event); err != nil {
+		t.Fatalf("Handle() error = %v, want degraded success", err)
+	}
+
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Error(), "embedding-dimensions") {
+		t.Fatalf("warnings = %v, want configuration hint", warnings)
+	}
+	if !embed.IsInvalidEmbedding(warnings[0]) {
+		t.Fatalf("warning should wrap InvalidEmbeddingError: %v", warnings[0])
+	}
+	if mockStore.pendingID != "issue/1" {
+		t.Fatalf("pending embedding id = %q, want issue/1", mockStore.pendingID)
+	}
+}
+
+func TestHandle_ReusesStoredVectorWhenContentUnchanged(t *testing.T) {
+	t.Helper()
+
+	mockStore := &mockSearchIndexer{storedVector: []float32{1, 0, 0}}
+	embedder := &embed.MockEmbedder{Dims: 3}
+	eng := &Engine{
+		Embedder: embedder,
+		Store:    mockStore,
+		Comments: &mockCommentManager{},
+	}
+
+	created := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	event := gh.Event{Type: "issue", Owner: "acme", Repo: "repo", Number: 4, Title: "login timeout", Body: "fails", Labels: []string{"bug"}, CreatedAt: created, UpdatedAt: created.Add(time.Hour)}
+	if err := eng.Handle(context.Background(), event); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if embedder.Calls != 0 {
+		t.Fatalf("embedder calls = %d, want 0 when stored vector matches", embedder.Calls)
+	}
+	if mockStore.searchVectorCalls != 1 {
+		t.Fatalf("expected vector search with reused embedding")
+	}
+	if mockStore.upsertVectorID != "" {
+		t.Fatalf("did not expect vector rewrite, got %q", mockStore.upsertVectorID)
+	}
+	if mockStore.upsertItem.ContentHash == "" || mockStore.upsertItem.EmbeddingModel != embedder.Model() {
+		t.Fatalf("expected fingerprint on upserted item: %+v", mockStore.upsertItem)
+	}
+	if !mockStore.upsertItem.CreatedAt.Equal(created) || !mockStore.upsertItem.UpdatedAt.Equal(created.Add(time.Hour)) {
+		t.Fatalf("expected GitHub timestamps on upserted item: %+v", mockStore.upsertItem)
+	}
+}
+
+func TestHandle_LongContentSearchesAndStoresChunks(t *testing.T) {
+	t.Helper()
+
+	mockStore := &mockSearchIndexer{}
+	eng := &Engine{
+		Embedder: &embed.MockEmbedder{Dims: 3},
+		Store:    mockStore,
+		Comments: &mockCommentManager{},
+	}
+
+	body := strings.Repeat("goroutine 1 [running]: store.Open(0xc000010000) /src/store.go:42\n", 200)
+	event := gh.Event{Type: "issue", Owner: "acme", Repo: "repo", Number: 7, Title: "crash on start", Body: body}
+	if err := eng.Handle(context.Background(), event); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	chunks := mockStore.chunkReplaces["issue/7"]
+	if len(chunks) < 2 {
+		t.Fatalf("stored chunks = %d, want several for a long body", len(chunks))
+	}
+	if mockStore.lastVectorQueries != len(chunks)+1 {
+		t.Fatalf("vector queries = %d, want item vector plus %d chunks", mockStore.lastVectorQueries, len(chunks))
+	}
+
+	// An unchanged item reuses its stored chunks instead of re-embedding them.
+	reuse := &mockSearchIndexer{storedVector: []float32{1, 0, 0}, storedChunks: chunks}
+	embedder := &embed.MockEmbedder{Dims: 3}
+	eng = &Engine{Embedder: embedder, Store: reuse, Comments: &mockCommentManager{}}
+	if err := eng.Handle(context.Background(), event); err != nil {
+		t.Fatalf("Handle() rescan error = %v", err)
+	}
+	if embedder.Calls != 0 || reuse.lastVectorQueries != len(chunks)+1 || reuse.chunkReplaces != nil {
+		t.Fatalf("rescan: embed calls = %d, queries = %d, chunk writes = %v", embedder.Calls, reuse.lastVectorQueries, reuse.chunkReplaces)
+	}
+}
+
+func TestHandle_LifecycleActionsSkipEmbeddingAndComments(t *testing.T) {
+	t.Helper()
+
+	tests := []struct {
+		name          string
+		event         gh.Event
+		metadataFound bool
+		wantDeleted   string
+		wantMetadata  bool
+		wantUpsert    bool
+	}{
+		{name: "closed issue", event: gh.Event{Type: "issue", Action: "closed", Number: 1, State: "closed"}, metadataFound: true, wantMetadata: true},
+		{name: "merged pr", event: gh.Event{Type: "pr", Action: "closed", Number: 2, State: "merged"}, metadataFound: true, wantMetadata: true},
+		{name: "assigned issue", event: gh.Event{Type: "issue", Action: "assigned", Number: 6, Assignees: []string{"alice"}}, metadataFound: true, wantMetadata: true},
+		{name: "pr ready for review", event: gh.Event{Type: "pr", Action: "ready_for_review", Number: 7}, metadataFound: true, wantMetadata: true},
+		{name: "labeled unknown issue", event: gh.Event{Type: "issue", Action: "labeled", Number: 3, Title: "t", Body: "b"}, wantMetadata: true, wantUpsert: true},
+		{name: "deleted issue", event: gh.Event{Type: "issue", Action: "deleted", Number: 4}, wantDeleted: "issue/4"},
+		{name: "transferred issue", event: gh.Event{Type: "issue", Action: "transferred", Number: 5}, wantDeleted: "issue/5"},
+		{name: "pinned issue", event: gh.Event{Type: "issue", Action: "pinned", Number: 8}, metadataFound: true, wantMetadata: true},
+		{name: "pr auto merge enabled", event: gh.Event{Type: "pr", Action: "auto_merge_enabled", Number: 9}, metadataFound: true, wantMetadata: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Helper()
+
+			mockStore := &mockSearchIndexer{metadataFound: tt.metadataFound}
+			mockComments := &mockCommentManager{}
+			embedder := &embed.MockEmbedder{Dims: 3}
+			eng := &Engine{Embedder: embedder, Store: mockStore, Comments: mockComments}
+
+			if err := eng.Handle(context.Background(), tt.event); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			if embedder.Calls != 0 || mockStore.searchVectorCalls != 0 {
+				t.Fatalf("lifecycle event should not embed or search")
+			}
+			if mockComments.calls != 0 {
+				t.Fatalf("lifecycle event should not touch comments")
+			}
+			if mockStore.deletedID != tt.wantDeleted {
+				t.Fatalf("deleted id = %q, want %q", mockStore.deletedID, tt.wantDeleted)
+			}
+			if (mockStore.metadataUpdate.ID != "") != tt.wantMetadata {
+				t.Fatalf("metadata update = %+v, want called=%v", mockStore.metadataUpdate, tt.wantMetadata)
+			}
+			if (mockStore.upsertItem.ID != "") != tt.wantUpsert {
+				t.Fatalf("upsert item = %+v, want called=%v", mockStore.upsertItem, tt.wantUpsert)
+			}
+		})
+	}
+}
+
+func TestClassifyEvent(t *testing.T) {
+	t.Helper()
+
+	tests := []struct {
+		action string
+		want   EventKind
+	}{
+		{action: "", want: EventKindContent},
+		{action: "opened", want: EventKindContent},
+		{action: "edited", want: EventKindContent},
+		{action: "synchronize", want: EventKindContent},
+		{action: "rescan", want: EventKindContent},
+		{action: "closed", want: EventKindMetadata},
+		{action: "reopened", want: EventKindMetadata},
+		{action: "labeled", want: EventKindMetadata},
+		{action: "deleted", want: EventKindRemoval},
+		{action: "transferred", want: EventKindRemoval},
+		// Actions the engine has no special handling for only refresh metadata.
+		{action: "pinned", want: EventKindMetadata},
+		{action: "unpinned", want: EventKindMetadata},
+		{action: "locked", want: EventKindMetadata},
+		{action: "unlocked", want: EventKindMetadata},
+		{action: "typed", want: EventKindMetadata},
+		{action: "untyped", want: EventKindMetadata},
+		{action: "review_requested", want: EventKindMetadata},
+		{action: "auto_merge_enabled", want: EventKindMetadata},
+		{action: "enqueued", want: EventKindMetadata},
+	}
+	for _, tt := range tests {
+		if got := ClassifyEvent(gh.Event{Type: "issue", Action: tt.action}); got != tt.want {
+			t.Fatalf("ClassifyEvent(%q) = %v, want %v", tt.action, got, tt.want)
+		}
+	}
+	if got := ClassifyEvent(gh.Event{Action: "created", Comment: &gh.IssueComment{}}); got != EventKindComment {
+		t.Fatalf("ClassifyEvent(comment) = %v, want %v", got, EventKindComment)
 	}
 }
 
@@ -99,30 +348,62 @@ func TestBuildEmbeddableContentPRModes(t *testing.T) {
 type mockSearchIndexer struct {
 	vectorResults []store.VectorResult
 	ftsResults    []store.FTSResult
+	nearResults   []store.NearDuplicateResult
+	storedVector  []float32
 
 	lastVectorExcludeID string
 	lastFTSExcludeID    string
+	lastVectorFilter    store.SearchFilter
+	lastFTSFilter       store.SearchFilter
+
+	searchVectorCalls int
+	lastVectorQueries int
 
 	upsertItem     store.ItemRecord
 	upsertVectorID string
+	deletedVector  string
+	pendingID      string
+	storedChunks   [][]float32
+	chunkReplaces  map[string][][]float32
+
+	metadataFound  bool
+	metadataUpdate store.ItemRecord
+	deletedID      string
+
+	numberIDs map[int]string
+	verdicts  map[string]store.PairVerdict
+	feedback  []store.PairFeedback
+	items     map[string]store.ItemRecord
 }
 
-func (m *mockSearchIndexer) SearchVector(ctx context.Context, queryEmbedding []float32, excludeID string, limit int) ([]store.VectorResult, error) {
+func (m *mockSearchIndexer) SearchVectors(ctx context.Context, queries [][]float32, excludeID string, limit int, filter store.SearchFilter) ([]store.VectorResult, error) {
 	_ = ctx
-	_ = queryEmbedding
 	_ = limit
+	m.searchVectorCalls++
+	m.lastVectorQueries = len(queries)
 	m.lastVectorExcludeID = excludeID
+	m.lastVectorFilter = filter
 	return append([]store.VectorResult(nil), m.vectorResults...), nil
 }
 
-func (m *mockSearchIndexer) SearchFTS(ctx context.Context, query string, excludeID string, limit int) ([]store.FTSResult, error) {
+func (m *mockSearchIndexer) SearchFTS(ctx context.Context, query string, excludeID string, limit int, filter store.SearchFilter) ([]store.FTSResult, error) {
 	_ = ctx
 	_ = query
 	_ = limit
 	m.lastFTSExcludeID = excludeID
+	m.lastFTSFilter = filter
 	return append([]store.FTSResult(nil), m.ftsResults...), nil
 }
 
+func (m *mockSearchIndexer) SearchNearDuplicates(ctx context.Context, title, body, excludeID string, limit int, filter store.SearchFilter) ([]store.NearDuplicateResult, error) {
+	_ = ctx
+	_ = title
+	_ = body
+	_ = excludeID
+	_ = limit
+	return append([]store.NearDuplicateResult(nil), m.nearResults...), nil
+}
+
 func (m *mockSearchIndexer) UpsertItem(ctx context.Context, rec store.ItemRecord) error {
 	_ = ctx
 	m.upsertItem = rec
@@ -136,8 +417,87 @@ func (m *mockSearchIndexer) UpsertVector(ctx context.Context, id string, embeddi
 	return nil
 }
 
+func (m *mockSearchIndexer) DeleteVector(ctx context.Context, id string) error {
+	_ = ctx
+	m.deletedVector = id
+	return nil
+}
+
+func (m *mockSearchIndexer) ReplaceChunkVectors(ctx context.Context, itemID string, chunks [][]float32) error {
+	_ = ctx
+	if m.chunkReplaces == nil {
+		m.chunkReplaces = map[string][][]float32{}
+	}
+	m.chunkReplaces[itemID] = chunks
+	return nil
+}
+
+func (m *mockSearchIndexer) LookupChunkVectors(ctx context.Context, itemID string) ([][]float32, error) {
+	_ = ctx
+	_ = itemID
+	return m.storedChunks, nil
+}
+
+func (m *mockSearchIndexer) MarkPendingEmbedding(ctx context.Context, id, reason string) error {
+	_ = ctx
+	_ = reason
+	m.pendingID = id
+	return nil
+}
+
+func (m *mockSearchIndexer) LookupEmbedding(ctx context.Context, id, contentHash, model string) ([]float32, bool, error) {
+	_ = ctx
+	_ = id
+	_ = contentHash
+	_ = model
+	if len(m.storedVector) == 0 {
+		return nil, false, nil
+	}
+	return m.storedVector, true, nil
+}
+
+func (m *mockSearchIndexer) UpdateItemMetadata(ctx context.Context, rec store.ItemRecord) (bool, error) {
+	_ = ctx
+	m.metadataUpdate = rec
+	return m.metadataFound, nil
+}
+
+func (m *mockSearchIndexer) DeleteItem(ctx context.Context, id string) error {
+	_ = ctx
+	m.deletedID = id
+	return nil
+}
+
+func (m *mockSearchIndexer) PairVerdictsFor(ctx context.Context, id string) (map[string]store.PairVerdict, error) {
+	_ = ctx
+	_ = id
+	return m.verdicts, nil
+}
+
+func (m *mockSearchIndexer) RecordPairFeedback(ctx context.Context, fb store.PairFeedback) error {
+	_ = ctx
+	m.feedback = append(m.feedback, fb)
+	return nil
+}
+
+func (m *mockSearchIndexer) LookupItemIDByNumber(ctx context.Context, number int) (string, bool, error) {
+	_ = ctx
+	id, ok := m.numberIDs[number]
+	return id, ok, nil
+}
+
+func (m *mockSearchIndexer) GetItem(ctx context.Context, id string) (store.ItemRecord, error) {
+	_ = ctx
+	rec, ok := m.items[id]
+	if !ok {
+		return store.ItemRecord{}, fmt.Errorf("item %s: %w", id, sql.ErrNoRows)
+	}
+	return rec, nil
+}
+
 type mockCommentManager struct {
-	body string
+	body  string
+	calls int
 }
 
 func (m *mockCommentManager) UpsertTriageComment(ctx context.Context, owner, repo string, number int, body string) (gh.CommentAction, error) {
@@ -145,6 +505,7 @@ func (m *mockCommentManager) UpsertTriageComment(ctx context.Context, owner, rep
 	_ = owner
 	_ = repo
 	_ = number
+	m.calls++
 	m.body = body
 	return gh.CommentActionNoop, nil
 }
@@ -0,0 +1,360 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"vector-triage/internal/store"
+)
+
+const (
+	defaultEvalDepth           = 20
+	defaultEvalPrecisionTarget = 0.95
+	defaultEvalRecallTarget    = 0.90
+)
+
+// DefaultEvalThresholds sweeps 0.50 to 0.98 in 0.02 steps.
+var DefaultEvalThresholds = func() []float64 {
+	out := make([]float64, 0, 25)
+	for i := 0; i <= 24; i++ {
+		out = append(out, math.Round((0.50+0.02*float64(i))*100)/100)
+	}
+	return out
+}()
+
+// DefaultEvalKs are the cutoffs reported as recall@k.
+var DefaultEvalKs = []int{1, 3, 5, 10}
+
+type EvalStore interface {
+	GetItem(ctx context.Context, id string) (store.ItemRecord, error)
+	LookupEmbedding(ctx context.Context, id, contentHash, model string) ([]float32, bool, error)
+	LookupChunkVectors(ctx context.Context, itemID string) ([][]float32, error)
+	SearchVectors(ctx context.Context, queries [][]float32, excludeID string, limit int, filter store.SearchFilter) ([]store.VectorResult, error)
+	SearchFTS(ctx context.Context, query string, excludeID string, limit int, filter store.SearchFilter) ([]store.FTSResult, error)
+	SearchNearDuplicates(ctx context.Context, title, body, excludeID string, limit int, filter store.SearchFilter) ([]store.NearDuplicateResult, error)
+}
+
+// LabeledPair is one ground-truth judgement between two indexed items.
+type LabeledPair struct {
+	A         string
+	B         string
+	Duplicate bool
+}
+
+type EvalOptions struct {
+	Thresholds []float64
+	Ks         []int
+	// Depth is how many candidates each backend returns before fusion.
+	Depth int
+	// PrecisionTarget picks the duplicate threshold; RecallTarget the similarity threshold.
+	PrecisionTarget float64
+	RecallTarget    float64
+	// Fusion is the strategy under test; nil means RRF.
+	Fusion store.FusionStrategy
+}
+
+type ThresholdMetrics struct {
+	Threshold      float64 `json:"threshold"`
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+	TruePositives  int     `json:"true_positives"`
+	FalsePositives int     `json:"false_positives"`
+	FalseNegatives int     `json:"false_negatives"`
+	// Unlabeled counts shown candidates with no judgement either way.
+	Unlabeled int `json:"unlabeled"`
+}
+
+type RecallAtK struct {
+	K      int     `json:"k"`
+	Recall float64 `json:"recall"`
+}
+
+// EvalReport summarizes retrieval quality over a labeled pair set. Pairs are
+// scored in both directions, since either item can be the one being triaged.
+type EvalReport struct {
+	Fusion         string             `json:"fusion"`
+	Queries        int                `json:"queries"`
+	Pairs          int                `json:"pairs"`
+	DuplicatePairs int                `json:"duplicate_pairs"`
+	SkippedItems   []string           `json:"skipped_items,omitempty"`
+	KeywordOnly    int                `json:"keyword_only_queries"`
+	MRR            float64            `json:"mrr"`
+	RecallAtK      []RecallAtK        `json:"recall_at_k"`
+	Sweep          []ThresholdMetrics `json:"sweep"`
+	// Recommended values are zero when no threshold meets the target.
+	RecommendedSimilarity float64 `json:"recommended_similarity_threshold"`
+	RecommendedDuplicate  float64 `json:"recommended_duplicate_threshold"`
+}
+
+type evalJudgement struct {
+	query     string
+	candidate string
+	duplicate bool
+}
+
+type evalHit struct {
+	rank       int
+	similarity float64
+}
+
+// Evaluate re-runs vector search, FTS and fusion for every item that appears in
+// a labeled pair, using its stored vector, then scores the rankings against
+// the labels. Other items are not searched, as nothing could score their
+// rankings. Maintainer overrides are deliberately not applied so labels cannot
+// leak into results.
+func Evaluate(ctx context.Context, st EvalStore, pairs []LabeledPair, opts EvalOptions) (EvalReport, error) {
+	var report EvalReport
+	if st == nil {
+		return report, errors.New("store dependency is required")
+	}
+	opts = opts.normalized()
+	report.Fusion = opts.Fusion.Name()
+
+	judgements := make([]evalJudgement, 0, len(pairs)*2)
+	queries := make([]string, 0)
+	seenQuery := map[string]struct{}{}
+	for _, pair := range pairs {
+		if pair.A == "" || pair.B == "" || pair.A == pair.B {
+			continue
+		}
+		report.Pairs++
+		if pair.Duplicate {
+			report.DuplicatePairs++
+		}
+		judgements = append(judgements,
+			evalJudgement{query: pair.A, candidate: pair.B, duplicate: pair.Duplicate},
+			evalJudgement{query: pair.B, candidate: pair.A, duplicate: pair.Duplicate},
+		)
+		for _, id := range []string{pair.A, pair.B} {
+			if _, ok := seenQuery[id]; !ok {
+				seenQuery[id] = struct{}{}
+				queries = append(queries, id)
+			}
+		}
+	}
+	sort.Strings(queries)
+
+	rankings := make(map[string][]store.FusedResult, len(queries))
+	for _, id := range queries {
+		results, keywordOnly, err := evalSearch(ctx, st, id, opts.Depth, opts.Fusion)
+		if errors.Is(err, sql.ErrNoRows) {
+			report.SkippedItems = append(report.SkippedItems, id)
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+		if keywordOnly {
+			report.KeywordOnly++
+		}
+		rankings[id] = results
+	}
+	report.Queries = len(rankings)
+
+	hits := make(map[string]map[string]evalHit, len(rankings))
+	for id, results := range rankings {
+		byID := make(map[string]evalHit, len(results))
+		for i, result := range results {
+			byID[result.ID] = evalHit{rank: i + 1, similarity: result.DisplaySimilarity}
+		}
+		hits[id] = byID
+	}
+
+	scored := make([]evalJudgement, 0, len(judgements))
+	for _, j := range judgements {
+		if _, ok := rankings[j.query]; ok {
+			scored = append(scored, j)
+		}
+	}
+
+	report.MRR = meanReciprocalRank(scored, hits)
+	report.RecallAtK = recallAtK(scored, hits, opts.Ks)
+	report.Sweep = sweepThresholds(scored, hits, rankings, opts.Thresholds)
+	report.RecommendedDuplicate, report.RecommendedSimilarity = recommendThresholds(report.Sweep, opts)
+	return report, nil
+}
+
+func (o EvalOptions) normalized() EvalOptions {
+	if len(o.Thresholds) == 0 {
+		o.Thresholds = DefaultEvalThresholds
+	}
+	thresholds := append([]float64{}, o.Thresholds...)
+	sort.Float64s(thresholds)
+	o.Thresholds = thresholds
+	if len(o.Ks) == 0 {
+		o.Ks = DefaultEvalKs
+	}
+	if o.Depth <= 0 {
+		o.Depth = defaultEvalDepth
+	}
+	if o.Fusion == nil {
+		o.Fusion = store.WeightedRRF{}
+	}
+	if o.PrecisionTarget <= 0 {
+		o.PrecisionTarget = defaultEvalPrecisionTarget
+	}
+	if o.RecallTarget <= 0 {
+		o.RecallTarget = defaultEvalRecallTarget
+	}
+	return o
+}
+
+// evalSearch mirrors Engine.Handle's retrieval with no threshold or search
+// filter applied, so the sweep sees every candidate the backends returned.
+func evalSearch(ctx context.Context, st EvalStore, id string, depth int, fusion store.FusionStrategy) ([]store.FusedResult, bool, error) {
+	rec, err := st.GetItem(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+
+	content := buildStoredItemContent(rec)
+	vector, found, err := st.LookupEmbedding(ctx, id, rec.ContentHash, rec.EmbeddingModel)
+	if err != nil {
+		return nil, false, fmt.Errorf("lookup stored embedding %s: %w", id, err)
+	}
+
+	var vecResults []store.VectorResult
+	if found {
+		chunks, err := st.LookupChunkVectors(ctx, id)
+		if err != nil {
+			return nil, false, fmt.Errorf("lookup chunk vectors %s: %w", id, err)
+		}
+		vecResults, err = st.SearchVectors(ctx, append([][]float32{vector}, chunks...), id, depth, store.SearchFilter{})
+		if err != nil {
+			return nil, false, fmt.Errorf("vector search %s: %w", id, err)
+		}
+	}
+	ftsResults, err := st.SearchFTS(ctx, content, id, depth, store.SearchFilter{})
+	if err != nil {
+		return nil, false, fmt.Errorf("fts search %s: %w", id, err)
+	}
+	nearResults, err := st.SearchNearDuplicates(ctx, rec.Title, rec.Body, id, depth, store.SearchFilter{})
+	if err != nil {
+		return nil, false, fmt.Errorf("near-duplicate search %s: %w", id, err)
+	}
+
+	fused := store.FuseResults(vecResults, ftsResults, nearResults, id, store.FuseConfig{
+		SimilarityThreshold: 0,
+		DuplicateThreshold:  1,
+		MaxResults:          depth * 2,
+		Strategy:            fusion,
+	})
+	return fused, !found, nil
+}
+
+func meanReciprocalRank(judgements []evalJudgement, hits map[string]map[string]evalHit) float64 {
+	best := map[string]int{}
+	for _, j := range judgements {
+		if !j.duplicate {
+			continue
+		}
+		rank := 0
+		if hit, ok := hits[j.query][j.candidate]; ok {
+			rank = hit.rank
+		}
+		current, seen := best[j.query]
+		if !seen || (rank > 0 && (current == 0 || rank < current)) {
+			best[j.query] = rank
+		}
+	}
+	if len(best) == 0 {
+		return 0
+	}
+
+	var sum float64
+	for _, rank := range best {
+		if rank > 0 {
+			sum += 1 / float64(rank)
+		}
+	}
+	return sum / float64(len(best))
+}
+
+func recallAtK(judgements []evalJudgement, hits map[string]map[string]evalHit, ks []int) []RecallAtK {
+	out := make([]RecallAtK, 0, len(ks))
+	for _, k := range ks {
+		var total, found int
+		for _, j := range judgements {
+			if !j.duplicate {
+				continue
+			}
+			total++
+			if hit, ok := hits[j.query][j.candidate]; ok && hit.rank <= k {
+				found++
+			}
+		}
+		out = append(out, RecallAtK{K: k, Recall: ratio(found, total)})
+	}
+	return out
+}
+
+func sweepThresholds(judgements []evalJudgement, hits map[string]map[string]evalHit, rankings map[string][]store.FusedResult, thresholds []float64) []ThresholdMetrics {
+	labeled := map[string]map[string]struct{}{}
+	for _, j := range judgements {
+		if labeled[j.query] == nil {
+			labeled[j.query] = map[string]struct{}{}
+		}
+		labeled[j.query][j.candidate] = struct{}{}
+	}
+
+	out := make([]ThresholdMetrics, 0, len(thresholds))
+	for _, t := range thresholds {
+		m := ThresholdMetrics{Threshold: t}
+		for _, j := range judgements {
+			hit, ok := hits[j.query][j.candidate]
+			shown := ok && hit.similarity >= t
+			switch {
+			case j.duplicate && shown:
+				m.TruePositives++
+			case j.duplicate:
+				m.FalseNegatives++
+			case shown:
+				m.FalsePositives++
+			}
+		}
+		for query, results := range rankings {
+			for _, result := range results {
+				if result.DisplaySimilarity < t {
+					continue
+				}
+				if _, ok := labeled[query][result.ID]; !ok {
+					m.Unlabeled++
+				}
+			}
+		}
+		m.Precision = ratio(m.TruePositives, m.TruePositives+m.FalsePositives)
+		m.Recall = ratio(m.TruePositives, m.TruePositives+m.FalseNegatives)
+		out = append(out, m)
+	}
+	return out
+}
+
+// recommendThresholds picks the lowest threshold whose precision meets the
+// target as the duplicate threshold, and the highest threshold that still
+// meets the recall target as the similarity threshold.
+func recommendThresholds(sweep []ThresholdMetrics, opts EvalOptions) (duplicate, similarity float64) {
+	for _, m := range sweep {
+		if m.TruePositives > 0 && m.Precision >= opts.PrecisionTarget {
+			duplicate = m.Threshold
+			break
+		}
+	}
+	for i := len(sweep) - 1; i >= 0; i-- {
+		if sweep[i].TruePositives > 0 && sweep[i].Recall >= opts.RecallTarget {
+			similarity = sweep[i].Threshold
+			break
+		}
+	}
+	return duplicate, similarity
+}
+
+func ratio(num, den int) float64 {
+	if den == 0 {
+		return 0
+	}
+	return float64(num) / float64(den)
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"vector-triage/internal/store"
+)
+
+func TestEvaluate_SweepsThresholdsAndRecommends(t *testing.T) {
+	t.Helper()
+
+	st := &fakeEvalStore{
+		items: map[string]store.ItemRecord{
+			"issue/1": {ID: "issue/1", Type: "issue", Number: 1, Title: "login hangs"},
+			"issue/2": {ID: "issue/2", Type: "issue", Number: 2, Title: "login timeout"},
+			"issue/3": {ID: "issue/3", Type: "issue", Number: 3, Title: "login page color"},
+		},
+		vectors: map[string][]store.VectorResult{
+			"issue/1": {
+				{ID: "issue/2", VecScore: 0.95},
+				{ID: "issue/3", VecScore: 0.80},
+				{ID: "issue/9", VecScore: 0.70},
+			},
+			"issue/2": {{ID: "issue/1", VecScore: 0.95}},
+			"issue/3": {{ID: "issue/1", VecScore: 0.80}},
+		},
+	}
+	pairs := []LabeledPair{
+		{A: "issue/1", B: "issue/2", Duplicate: true},
+		{A: "issue/1", B: "issue/3", Duplicate: false},
+		{A: "issue/404", B: "issue/405", Duplicate: false},
+	}
+
+	report, err := Evaluate(context.Background(), st, pairs, EvalOptions{Thresholds: []float64{0.96, 0.75, 0.85}})
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+
+	if report.Queries != 3 || report.Pairs != 3 || report.DuplicatePairs != 1 || len(report.SkippedItems) != 2 {
+		t.Fatalf("unexpected counts: %+v", report)
+	}
+	if report.MRR != 1 || report.RecallAtK[0].K != 1 || report.RecallAtK[0].Recall != 1 {
+		t.Fatalf("unexpected ranking metrics: mrr=%v recall@k=%+v", report.MRR, report.RecallAtK)
+	}
+
+	want := []ThresholdMetrics{
+		{Threshold: 0.75, Precision: 0.5, Recall: 1, TruePositives: 2, FalsePositives: 2},
+		{Threshold: 0.85, Precision: 1, Recall: 1, TruePositives: 2},
+		{Threshold: 0.96, FalseNegatives: 2},
+	}
+	if len(report.Sweep) != len(want) {
+		t.Fatalf("sweep = %+v", report.Sweep)
+	}
+	for i := range want {
+		if report.Sweep[i] != want[i] {
+			t.Fatalf("sweep[%d] = %+v, want %+v", i, report.Sweep[i], want[i])
+		}
+	}
+	if report.RecommendedDuplicate != 0.85 || report.RecommendedSimilarity != 0.85 {
+		t.Fatalf("recommendations = %v/%v, want 0.85/0.85", report.RecommendedSimilarity, report.RecommendedDuplicate)
+	}
+}
+
+type fakeEvalStore struct {
+	items   map[string]store.ItemRecord
+	vectors map[string][]store.VectorResult
+}
+
+func (f *fakeEvalStore) GetItem(ctx context.Context, id string) (store.ItemRecord, error) {
+	_ = ctx
+	rec, ok := f.items[id]
+	if !ok {
+		return store.ItemRecord{}, fmt.Errorf("item %s: %w", id, sql.ErrNoRows)
+	}
+	return rec, nil
+}
+
+func (f *fakeEvalStore) LookupEmbedding(ctx context.Context, id, contentHash, model string) ([]float32, bool, error) {
+	_ = ctx
+	_ = contentHash
+	_ = model
+	_, ok := f.vectors[id]
+	return []float32{1, 0, 0}, ok, nil
+}
+
+func (f *fakeEvalStore) LookupChunkVectors(ctx context.Context, itemID string) ([][]float32, error) {
+	_ = ctx
+	_ = itemID
+	return nil, nil
+}
+
+func (f *fakeEvalStore) SearchVectors(ctx context.Context, queries [][]float32, excludeID string, limit int, filter store.SearchFilter) ([]store.VectorResult, error) {
+	_ = ctx
+	_ = queries
+	_ = limit
+	return f.vectors[excludeID], nil
+}
+
+func (f *fakeEvalStore) SearchFTS(ctx context.Context, query string, excludeID string, limit int, filter store.SearchFilter) ([]store.FTSResult, error) {
+	_ = ctx
+	_ = query
+	_ = excludeID
+	_ = limit
+	return nil, nil
+}
+
+func (f *fakeEvalStore) SearchNearDuplicates(ctx context.Context, title, body, excludeID string, limit int, filter store.SearchFilter) ([]store.NearDuplicateResult, error) {
+	_ = ctx
+	_ = title
+	_ = body
+	_ = excludeID
+	_ = limit
+	return nil, nil
+}
@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	gh "vector-triage/internal/github"
+	"vector-triage/internal/store"
+)
+
+// DuplicateResolver finds the canonical item an issue was closed as a duplicate of.
+type DuplicateResolver interface {
+	FindDuplicateTarget(ctx context.Context, owner, repo string, number int) (int, bool, error)
+}
+
+// pinConfirmedDuplicates adds maintainer-confirmed duplicates that the search
+// did not retrieve, so a recorded verdict is always shown at the top.
+func (e *Engine) pinConfirmedDuplicates(ctx context.Context, results []store.FusedResult, overrides map[string]store.PairVerdict) ([]store.FusedResult, error) {
+	present := make(map[string]struct{}, len(results))
+	for _, result := range results {
+		present[result.ID] = struct{}{}
+	}
+
+	missing := make([]string, 0)
+	for id, verdict := range overrides {
+		if verdict != store.PairVerdictDuplicate {
+			continue
+		}
+		if _, ok := present[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return results, nil
+	}
+	sort.Strings(missing)
+
+	pinned := make([]store.FusedResult, 0, len(missing)+len(results))
+	for _, id := range missing {
+		rec, err := e.Store.GetItem(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("load confirmed duplicate %s: %w", id, err)
+		}
+		pinned = append(pinned, store.FusedResult{
+			ID:          rec.ID,
+			Type:        rec.Type,
+			Number:      rec.Number,
+			Title:       rec.Title,
+			State:       rec.State,
+			URL:         rec.URL,
+			IsDuplicate: true,
+			Confirmed:   true,
+		})
+	}
+
+	// Keep every confirmed row ahead of search hits, then re-apply the result cap.
+	split := 0
+	for split < len(results) && results[split].Confirmed {
+		split++
+	}
+	out := append([]store.FusedResult{}, results[:split]...)
+	out = append(out, pinned...)
+	out = append(out, results[split:]...)
+	if len(out) > e.maxResults() {
+		out = out[:e.maxResults()]
+	}
+	return out, nil
+}
+
+// recordClosedAsDuplicate turns "closed as duplicate" into a confirmed pair so
+// maintainers build up labeled data without running any command.
+func (e *Engine) recordClosedAsDuplicate(ctx context.Context, event gh.Event, currentID string) error {
+	if !isClosedAsDuplicate(event) || e.Duplicates == nil {
+		return nil
+	}
+
+	target, found, err := e.Duplicates.FindDuplicateTarget(ctx, event.Owner, event.Repo, event.Number)
+	if err != nil {
+		return fmt.Errorf("find duplicate target: %w", err)
+	}
+	if !found {
+		return nil
+	}
+
+	otherID, indexed, err := e.Store.LookupItemIDByNumber(ctx, target)
+	if err != nil {
+		return fmt.Errorf("resolve #%d: %w", target, err)
+	}
+	if !indexed || otherID == currentID {
+		return nil
+	}
+
+	if err := e.Store.RecordPairFeedback(ctx, store.PairFeedback{
+		ItemA:   currentID,
+		ItemB:   otherID,
+		Verdict: store.PairVerdictDuplicate,
+		Actor:   event.Sender,
+	}); err != nil {
+		return fmt.Errorf("record pair feedback: %w", err)
+	}
+	return nil
+}
+
+func isClosedAsDuplicate(event gh.Event) bool {
+	return event.Type == "issue" &&
+		strings.EqualFold(strings.TrimSpace(event.Action), "closed") &&
+		strings.EqualFold(strings.TrimSpace(event.StateReason), "duplicate")
+}
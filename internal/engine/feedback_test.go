@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"vector-triage/internal/embed"
+	gh "vector-triage/internal/github"
+	"vector-triage/internal/store"
+)
+
+func TestHandle_PinsConfirmedDuplicateNotRetrieved(t *testing.T) {
+	t.Helper()
+
+	mockStore := &mockSearchIndexer{
+		vectorResults: []store.VectorResult{{ID: "issue/2", Number: 2, Title: "near", VecScore: 0.95}},
+		verdicts: map[string]store.PairVerdict{
+			"issue/9": store.PairVerdictDuplicate,
+			"issue/2": store.PairVerdictNotDuplicate,
+		},
+		items: map[string]store.ItemRecord{
+			"issue/9": {ID: "issue/9", Type: "issue", Number: 9, Title: "original", State: "open"},
+		},
+	}
+	formatter := &captureFormatter{}
+	eng := &Engine{
+		Embedder:  &embed.MockEmbedder{Dims: 3},
+		Store:     mockStore,
+		Comments:  &mockCommentManager{},
+		Formatter: formatter,
+	}
+
+	event := gh.Event{Type: "issue", Action: "edited", Number: 1, Title: "login", Body: "hangs"}
+	if err := eng.Handle(context.Background(), event); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if len(formatter.results) != 1 {
+		t.Fatalf("results = %+v, want only the pinned duplicate", formatter.results)
+	}
+	got := formatter.results[0]
+	if got.ID != "issue/9" || !got.Confirmed || !got.IsDuplicate {
+		t.Fatalf("unexpected pinned result: %+v", got)
+	}
+}
+
+func TestHandle_ClosedAsDuplicateRecordsPair(t *testing.T) {
+	t.Helper()
+
+	mockStore := &mockSearchIndexer{metadataFound: true, numberIDs: map[int]string{4: "issue/4"}}
+	eng := &Engine{
+		Store:      mockStore,
+		Comments:   &mockCommentManager{},
+		Duplicates: fakeDuplicateResolver{12: 4},
+	}
+
+	event := gh.Event{Type: "issue", Action: "closed", Number: 12, State: "closed", StateReason: "duplicate", Sender: "maint"}
+	if err := eng.Handle(context.Background(), event); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if len(mockStore.feedback) != 1 {
+		t.Fatalf("feedback records = %d, want 1", len(mockStore.feedback))
+	}
+	fb := mockStore.feedback[0]
+	if fb.ItemA != "issue/12" || fb.ItemB != "issue/4" || fb.Verdict != store.PairVerdictDuplicate || fb.Actor != "maint" {
+		t.Fatalf("unexpected feedback: %+v", fb)
+	}
+
+	mockStore.feedback = nil
+	event.StateReason = "completed"
+	if err := eng.Handle(context.Background(), event); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if len(mockStore.feedback) != 0 {
+		t.Fatalf("completed close should not record feedback: %+v", mockStore.feedback)
+	}
+}
+
+type fakeDuplicateResolver map[int]int
+
+func (f fakeDuplicateResolver) FindDuplicateTarget(ctx context.Context, owner, repo string, number int) (int, bool, error) {
+	_ = ctx
+	_ = owner
+	_ = repo
+	target, ok := f[number]
+	return target, ok, nil
+}
+
+type captureFormatter struct {
+	results []store.FusedResult
+}
+
+func (c *captureFormatter) Format(event gh.Event, results []store.FusedResult) string {
+	_ = event
+	c.results = results
+	return "report"
+}
@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gh "vector-triage/internal/github"
+)
+
+// EventKind groups webhook actions by how much work they need.
+type EventKind int
+
+const (
+	// EventKindContent re-embeds, searches and comments (opened, edited,
+	// synchronize, a /triage rescan, or an event without an action).
+	EventKindContent EventKind = iota
+	// EventKindMetadata only refreshes state/labels on the indexed row. It is
+	// also the default for actions the engine does not know.
+	EventKindMetadata
+	// EventKindRemoval drops the item from the index entirely.
+	EventKindRemoval
+	// EventKindComment carries a possible /triage slash command.
+	EventKindComment
+)
+
+// ClassifyEvent maps an event action onto the work the engine should do.
+// Content actions are listed explicitly, so actions such as pinned, locked or
+// auto_merge_enabled never re-embed the item or touch the triage comment.
+func ClassifyEvent(event gh.Event) EventKind {
+	if event.Comment != nil {
+		return EventKindComment
+	}
+	switch strings.ToLower(strings.TrimSpace(event.Action)) {
+	case "", "opened", "edited", "synchronize", string(SlashCommandRescan):
+		return EventKindContent
+	case "deleted", "transferred":
+		return EventKindRemoval
+	default:
+		return EventKindMetadata
+	}
+}
+
+// handleMetadata refreshes state and labels without embedding or commenting.
+// Items that were never indexed are inserted and queued for a later embedding.
+func (e *Engine) handleMetadata(ctx context.Context, event gh.Event, id string) error {
+	rec := buildItemRecord(event, id)
+	found, err := e.Store.UpdateItemMetadata(ctx, rec)
+	if err != nil {
+		return fmt.Errorf("update item metadata: %w", err)
+	}
+	if err := e.recordClosedAsDuplicate(ctx, event, id); err != nil {
+		// Feedback capture is best effort; the state refresh already succeeded.
+		e.warn(err)
+	}
+	if found {
+		return nil
+	}
+
+	if err := e.Store.UpsertItem(ctx, rec); err != nil {
+		return fmt.Errorf("upsert item: %w", err)
+	}
+	if strings.TrimSpace(buildEmbeddableContent(event)) == "" {
+		return nil
+	}
+	if err := e.Store.MarkPendingEmbedding(ctx, id, "indexed from "+event.Action+" event"); err != nil {
+		return fmt.Errorf("mark pending embedding: %w", err)
+	}
+	return nil
+}
+
+func (e *Engine) handleRemoval(ctx context.Context, id string) error {
+	if err := e.Store.DeleteItem(ctx, id); err != nil {
+		return fmt.Errorf("delete item: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"vector-triage/internal/embed"
+	gh "vector-triage/internal/github"
+	"vector-triage/internal/ingest"
+	"vector-triage/internal/store"
+)
+
+const DefaultPendingEmbeddingLimit = 10
+
+type PendingEmbeddingStore interface {
+	ListPendingEmbeddings(ctx context.Context, limit int) ([]store.PendingEmbedding, error)
+	ClearPendingEmbedding(ctx context.Context, id string) error
+	GetItem(ctx context.Context, id string) (store.ItemRecord, error)
+	UpsertVector(ctx context.Context, id string, embedding []float32) error
+	ReplaceChunkVectors(ctx context.Context, itemID string, chunks [][]float32) error
+	SetEmbeddingFingerprint(ctx context.Context, id, contentHash, model string) error
+}
+
+// FillPendingEmbeddings embeds up to limit items that were indexed without a
+// vector during an earlier embedding outage. It returns how many were filled.
+func FillPendingEmbeddings(ctx context.Context, embedder embed.Embedder, st PendingEmbeddingStore, limit int) (int, error) {
+	if embedder == nil {
+		return 0, errors.New("embedder dependency is required")
+	}
+	if st == nil {
+		return 0, errors.New("store dependency is required")
+	}
+	if limit <= 0 {
+		limit = DefaultPendingEmbeddingLimit
+	}
+
+	pending, err := st.ListPendingEmbeddings(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]string, 0, len(pending))
+	contents := make([]string, 0, len(pending))
+	for _, p := range pending {
+		rec, err := st.GetItem(ctx, p.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			if err := st.ClearPendingEmbedding(ctx, p.ID); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		content := buildStoredItemContent(rec)
+		if strings.TrimSpace(content) == "" {
+			if err := st.ClearPendingEmbedding(ctx, p.ID); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		ids = append(ids, p.ID)
+		contents = append(contents, content)
+	}
+	if len(contents) == 0 {
+		return 0, nil
+	}
+
+	vectors, err := embedder.EmbedBatch(ctx, contents)
+	if err != nil {
+		return 0, fmt.Errorf("embed pending items: %w", err)
+	}
+	if len(vectors) != len(contents) {
+		return 0, fmt.Errorf("embed batch returned %d vectors for %d inputs", len(vectors), len(contents))
+	}
+
+	for i, vec := range vectors {
+		// Chunks go first: UpsertVector clears the pending entry, so a chunk
+		// failure leaves the item queued for the next run.
+		chunks, err := embedChunks(ctx, embedder, contents[i])
+		if err != nil {
+			return i, fmt.Errorf("embed chunks %s: %w", ids[i], err)
+		}
+		if err := st.ReplaceChunkVectors(ctx, ids[i], chunks); err != nil {
+			return i, fmt.Errorf("upsert chunk vectors %s: %w", ids[i], err)
+		}
+		if err := st.UpsertVector(ctx, ids[i], vec); err != nil {
+			return i, fmt.Errorf("upsert vector %s: %w", ids[i], err)
+		}
+		// Record what was embedded so the next event for the item reuses it.
+		if err := st.SetEmbeddingFingerprint(ctx, ids[i], ingest.ContentHash(contents[i]), embedder.Model()); err != nil {
+			return i, fmt.Errorf("set embedding fingerprint %s: %w", ids[i], err)
+		}
+	}
+	return len(vectors), nil
+}
+
+// buildStoredItemContent rebuilds embeddable text from an indexed row.
+// Diffs are not stored, so PRs fall back to title, body and file list.
+func buildStoredItemContent(rec store.ItemRecord) string {
+	return buildEmbeddableContent(gh.Event{
+		Type:  rec.Type,
+		Title: rec.Title,
+		Body:  rec.Body,
+		Files: rec.Files,
+	})
+}
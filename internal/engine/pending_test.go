@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"vector-triage/internal/embed"
+	"vector-triage/internal/ingest"
+	"vector-triage/internal/store"
+)
+
+func TestFillPendingEmbeddings_EmbedsStoredItemsAndDropsMissing(t *testing.T) {
+	t.Helper()
+
+	st := &fakePendingStore{
+		pending: []store.PendingEmbedding{{ID: "issue/1"}, {ID: "issue/404"}, {ID: "pr/2"}},
+		items: map[string]store.ItemRecord{
+			"issue/1": {ID: "issue/1", Type: "issue", Number: 1, Title: "login timeout", Body: "hangs"},
+			"pr/2":    {ID: "pr/2", Type: "pr", Number: 2, Title: "fix", Body: "retry", Files: []string{"auth.go"}},
+		},
+		vectors:      map[string][]float32{},
+		fingerprints: map[string]string{},
+	}
+
+	embedder := &embed.MockEmbedder{Dims: 3}
+	filled, err := FillPendingEmbeddings(context.Background(), embedder, st, 10)
+	if err != nil {
+		t.Fatalf("FillPendingEmbeddings() error = %v", err)
+	}
+	if filled != 2 {
+		t.Fatalf("filled = %d, want 2", filled)
+	}
+	if _, ok := st.vectors["issue/1"]; !ok {
+		t.Fatalf("expected vector for issue/1")
+	}
+	if _, ok := st.vectors["pr/2"]; !ok {
+		t.Fatalf("expected vector for pr/2")
+	}
+	wantHash := ingest.ContentHash(buildStoredItemContent(st.items["issue/1"]))
+	if got := st.fingerprints["issue/1"]; got != wantHash+" "+embedder.Model() {
+		t.Fatalf("fingerprint = %q, want the filled content hash and model", got)
+	}
+	if len(st.cleared) != 1 || st.cleared[0] != "issue/404" {
+		t.Fatalf("expected missing item cleared, got %v", st.cleared)
+	}
+}
+
+type fakePendingStore struct {
+	pending      []store.PendingEmbedding
+	items        map[string]store.ItemRecord
+	vectors      map[string][]float32
+	fingerprints map[string]string
+	cleared      []string
+}
+
+func (f *fakePendingStore) ListPendingEmbeddings(ctx context.Context, limit int) ([]store.PendingEmbedding, error) {
+	_ = ctx
+	_ = limit
+	return f.pending, nil
+}
+
+func (f *fakePendingStore) ClearPendingEmbedding(ctx context.Context, id string) error {
+	_ = ctx
+	f.cleared = append(f.cleared, id)
+	return nil
+}
+
+func (f *fakePendingStore) GetItem(ctx context.Context, id string) (store.ItemRecord, error) {
+	_ = ctx
+	rec, ok := f.items[id]
+	if !ok {
+		return store.ItemRecord{}, fmt.Errorf("get item %s: %w", id, sql.ErrNoRows)
+	}
+	return rec, nil
+}
+
+func (f *fakePendingStore) ReplaceChunkVectors(ctx context.Context, itemID string, chunks [][]float32) error {
+	_ = ctx
+	_ = itemID
+	_ = chunks
+	return nil
+}
+
+func (f *fakePendingStore) UpsertVector(ctx context.Context, id string, embedding []float32) error {
+	_ = ctx
+	f.vectors[id] = embedding
+	return nil
+}
+
+func (f *fakePendingStore) SetEmbeddingFingerprint(ctx context.Context, id, contentHash, model string) error {
+	_ = ctx
+	f.fingerprints[id] = contentHash + " " + model
+	return nil
+}
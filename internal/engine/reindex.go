@@ -0,0 +1,146 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"vector-triage/internal/embed"
+	"vector-triage/internal/store"
+)
+
+const defaultReindexBatchSize = 20
+
+type ReindexStore interface {
+	BeginReindex(ctx context.Context, target store.EmbeddingSpace) (int, error)
+	ListUnstagedItems(ctx context.Context, afterID string, limit int) ([]store.ItemRecord, error)
+	StageReindexChunks(ctx context.Context, id, contentHash string, chunks [][]float32) error
+	StageReindexVector(ctx context.Context, id, contentHash string, embedding []float32) error
+	CommitReindex(ctx context.Context, target store.EmbeddingSpace) (int, error)
+}
+
+type ReindexStats struct {
+	// Resumed counts vectors already staged by an earlier, interrupted run.
+	Resumed   int
+	Items     int
+	Embedded  int
+	Swapped   int
+	Committed bool
+}
+
+// Reindexer rebuilds every vector from the stored item rows with the current
+// embedder. New vectors are staged beside the live index and swapped in only
+// once all items are done, so an interrupted run leaves the old index intact
+// and the next run picks up where it stopped.
+type Reindexer struct {
+	Embedder  embed.Embedder
+	Store     ReindexStore
+	BatchSize int
+}
+
+func (r *Reindexer) Run(ctx context.Context) (ReindexStats, error) {
+	var stats ReindexStats
+	if r == nil {
+		return stats, errors.New("nil reindexer")
+	}
+	if r.Store == nil {
+		return stats, errors.New("store dependency is required")
+	}
+	if r.Embedder == nil {
+		return stats, errors.New("embedder dependency is required")
+	}
+
+	target := store.EmbeddingSpace{Model: r.Embedder.Model(), Dimensions: r.Embedder.Dimensions()}
+	resumed, err := r.Store.BeginReindex(ctx, target)
+	if err != nil {
+		return stats, fmt.Errorf("begin reindex: %w", err)
+	}
+	stats.Resumed = resumed
+
+	batchSize := r.batchSize()
+	afterID := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		items, err := r.Store.ListUnstagedItems(ctx, afterID, batchSize)
+		if err != nil {
+			return stats, fmt.Errorf("list items: %w", err)
+		}
+		if len(items) == 0 {
+			break
+		}
+		afterID = items[len(items)-1].ID
+		stats.Items += len(items)
+
+		embedded, err := r.stageBatch(ctx, items)
+		stats.Embedded += embedded
+		if err != nil {
+			// Staged vectors survive; the live index is untouched until commit.
+			return stats, err
+		}
+	}
+
+	swapped, err := r.Store.CommitReindex(ctx, target)
+	if err != nil {
+		return stats, fmt.Errorf("commit reindex: %w", err)
+	}
+	stats.Swapped = swapped
+	stats.Committed = true
+	return stats, nil
+}
+
+func (r *Reindexer) stageBatch(ctx context.Context, items []store.ItemRecord) (int, error) {
+	ids := make([]string, 0, len(items))
+	hashes := make([]string, 0, len(items))
+	contents := make([]string, 0, len(items))
+	for _, rec := range items {
+		content := buildStoredItemContent(rec)
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+		ids = append(ids, rec.ID)
+		hashes = append(hashes, rec.ContentHash)
+		contents = append(contents, content)
+	}
+	if len(contents) == 0 {
+		return 0, nil
+	}
+
+	// Embedders already honour Retry-After and back off; a batch that still
+	// fails (for example an exhausted rate limit) stops the run for a later resume.
+	vectors, err := r.Embedder.EmbedBatch(ctx, contents)
+	if err != nil {
+		return 0, fmt.Errorf("embed batch: %w", err)
+	}
+	if len(vectors) != len(contents) {
+		return 0, fmt.Errorf("embed batch returned %d vectors for %d inputs", len(vectors), len(contents))
+	}
+
+	for i, vec := range vectors {
+		if len(vec) != r.Embedder.Dimensions() {
+			return i, fmt.Errorf("embedding for %s has %d dimensions, expected %d", ids[i], len(vec), r.Embedder.Dimensions())
+		}
+		// The item vector is staged last; it marks the item as done for resume.
+		chunks, err := embedChunks(ctx, r.Embedder, contents[i])
+		if err != nil {
+			return i, fmt.Errorf("embed chunks %s: %w", ids[i], err)
+		}
+		if err := r.Store.StageReindexChunks(ctx, ids[i], hashes[i], chunks); err != nil {
+			return i, fmt.Errorf("stage chunks %s: %w", ids[i], err)
+		}
+		if err := r.Store.StageReindexVector(ctx, ids[i], hashes[i], vec); err != nil {
+			return i, fmt.Errorf("stage vector %s: %w", ids[i], err)
+		}
+	}
+	return len(vectors), nil
+}
+
+func (r *Reindexer) batchSize() int {
+	if r.BatchSize <= 0 {
+		return defaultReindexBatchSize
+	}
+	return r.BatchSize
+}
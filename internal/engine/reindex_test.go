@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"vector-triage/internal/embed"
+	"vector-triage/internal/store"
+)
+
+func TestReindexer_ResumesAfterFailureAndCommitsOnce(t *testing.T) {
+	t.Helper()
+
+	st := &fakeReindexStore{
+		items: []store.ItemRecord{
+			{ID: "issue/1", Type: "issue", Title: "a", Body: "b"},
+			{ID: "issue/2", Type: "issue"},
+			{ID: "pr/3", Type: "pr", Title: "c", Files: []string{"x.go"}},
+		},
+		staged: map[string][]float32{},
+	}
+
+	failing := &embed.MockEmbedder{Dims: 3, Err: errors.New("rate limited")}
+	r := &Reindexer{Embedder: failing, Store: st, BatchSize: 2}
+	stats, err := r.Run(context.Background())
+	if err == nil || stats.Committed || st.commits != 0 {
+		t.Fatalf("expected failed run without commit, stats=%+v err=%v", stats, err)
+	}
+
+	// Stage issue/1 as if an earlier batch had succeeded.
+	st.staged["issue/1"] = []float32{1, 0, 0}
+
+	embedder := &embed.MockEmbedder{Dims: 3}
+	r = &Reindexer{Embedder: embedder, Store: st, BatchSize: 2}
+	stats, err = r.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !stats.Committed || st.commits != 1 || stats.Resumed != 1 || stats.Embedded != 1 {
+		t.Fatalf("unexpected stats: %+v commits=%d", stats, st.commits)
+	}
+	if _, ok := st.staged["pr/3"]; !ok {
+		t.Fatalf("pr/3 should be staged before commit")
+	}
+	if st.target.Model != embedder.Model() || st.target.Dimensions != 3 {
+		t.Fatalf("target = %+v", st.target)
+	}
+}
+
+type fakeReindexStore struct {
+	items   []store.ItemRecord
+	staged  map[string][]float32
+	chunks  map[string][][]float32
+	target  store.EmbeddingSpace
+	commits int
+}
+
+func (f *fakeReindexStore) BeginReindex(ctx context.Context, target store.EmbeddingSpace) (int, error) {
+	_ = ctx
+	f.target = target
+	return len(f.staged), nil
+}
+
+func (f *fakeReindexStore) ListUnstagedItems(ctx context.Context, afterID string, limit int) ([]store.ItemRecord, error) {
+	_ = ctx
+	out := make([]store.ItemRecord, 0, limit)
+	for _, rec := range f.items {
+		if rec.ID <= afterID {
+			continue
+		}
+		if _, ok := f.staged[rec.ID]; ok {
+			continue
+		}
+		out = append(out, rec)
+		if len(out) == limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeReindexStore) StageReindexChunks(ctx context.Context, id, contentHash string, chunks [][]float32) error {
+	_ = ctx
+	_ = contentHash
+	if f.chunks == nil {
+		f.chunks = map[string][][]float32{}
+	}
+	f.chunks[id] = chunks
+	return nil
+}
+
+func (f *fakeReindexStore) StageReindexVector(ctx context.Context, id, contentHash string, embedding []float32) error {
+	_ = ctx
+	_ = contentHash
+	f.staged[id] = embedding
+	return nil
+}
+
+func (f *fakeReindexStore) CommitReindex(ctx context.Context, target store.EmbeddingSpace) (int, error) {
+	_ = ctx
+	_ = target
+	f.commits++
+	return len(f.staged), nil
+}
@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	gh "vector-triage/internal/github"
+	"vector-triage/internal/rerank"
+	"vector-triage/internal/store"
+)
+
+// rerankResults rescores the top fused candidates against the current item.
+// A candidate stays a duplicate only when fusion and the reranker agree, and
+// the rescored window is reordered by rerank score. Confirmed pairs are the
+// maintainers' call and are left alone. Any failure keeps the fused results.
+func (e *Engine) rerankResults(ctx context.Context, event gh.Event, results []store.FusedResult) []store.FusedResult {
+	if e.Reranker == nil || len(results) == 0 {
+		return results
+	}
+
+	start := 0
+	for start < len(results) && results[start].Confirmed {
+		start++
+	}
+	end := min(start+e.rerankTopN(), len(results))
+	if start == end {
+		return results
+	}
+
+	candidates := make([]rerank.Item, 0, end-start)
+	positions := make([]int, 0, end-start)
+	for i := start; i < end; i++ {
+		rec, err := e.Store.GetItem(ctx, results[i].ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			e.warn(fmt.Errorf("load rerank candidate %s, keeping fused ranking: %w", results[i].ID, err))
+			return results
+		}
+		candidates = append(candidates, rerank.Item{ID: rec.ID, Title: rec.Title, Body: rec.Body})
+		positions = append(positions, i)
+	}
+	if len(candidates) == 0 {
+		return results
+	}
+
+	query := rerank.Item{ID: store.BuildItemID(event.Type, event.Number), Title: event.Title, Body: event.Body}
+	scores, err := e.Reranker.Rerank(ctx, query, candidates)
+	if err == nil && len(scores) != len(candidates) {
+		err = fmt.Errorf("reranker returned %d scores for %d candidates", len(scores), len(candidates))
+	}
+	if err != nil {
+		e.warn(fmt.Errorf("rerank with %s, keeping fused ranking: %w", e.Reranker.Name(), err))
+		return results
+	}
+
+	out := append([]store.FusedResult{}, results...)
+	threshold := e.rerankThreshold()
+	for n, i := range positions {
+		out[i].RerankScore = scores[n]
+		out[i].Reranked = true
+		out[i].IsDuplicate = out[i].IsDuplicate && scores[n] >= threshold
+	}
+	window := out[start:end]
+	sort.SliceStable(window, func(i, j int) bool {
+		// Candidates the reranker could not see keep their place at the back.
+		if window[i].Reranked != window[j].Reranked {
+			return window[i].Reranked
+		}
+		return window[i].RerankScore > window[j].RerankScore
+	})
+	return out
+}
+
+func (e *Engine) rerankTopN() int {
+	if e.Config.RerankTopN <= 0 {
+		return rerank.DefaultTopN
+	}
+	return e.Config.RerankTopN
+}
+
+func (e *Engine) rerankThreshold() float64 {
+	if e.Config.RerankThreshold <= 0 {
+		return rerank.DefaultThreshold
+	}
+	return e.Config.RerankThreshold
+}
@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"vector-triage/internal/embed"
+	gh "vector-triage/internal/github"
+	"vector-triage/internal/rerank"
+	"vector-triage/internal/store"
+)
+
+func TestHandle_RerankerMustAgreeBeforeFlagging(t *testing.T) {
+	t.Helper()
+
+	mockStore := &mockSearchIndexer{
+		vectorResults: []store.VectorResult{
+			{ID: "issue/2", Number: 2, Title: "login page layout", VecScore: 0.97},
+			{ID: "issue/3", Number: 3, Title: "login times out", VecScore: 0.95},
+		},
+		items: map[string]store.ItemRecord{
+			"issue/2": {ID: "issue/2", Title: "login page layout", Body: "button misaligned"},
+			"issue/3": {ID: "issue/3", Title: "login times out", Body: "504 after 30s"},
+		},
+	}
+	reranker := &fakeReranker{scores: []float64{0.2, 0.9}}
+	formatter := &captureFormatter{}
+	eng := &Engine{
+		Embedder:  &embed.MockEmbedder{Vectors: [][]float32{{1, 0, 0}}, Dims: 3},
+		Store:     mockStore,
+		Comments:  &mockCommentManager{},
+		Formatter: formatter,
+		Reranker:  reranker,
+	}
+
+	event := gh.Event{Type: "issue", Number: 1, Title: "login timeout", Body: "504 on login"}
+	if err := eng.Handle(context.Background(), event); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if reranker.query.ID != "issue/1" || reranker.query.Title != "login timeout" {
+		t.Fatalf("rerank query = %+v", reranker.query)
+	}
+	if len(reranker.candidates) != 2 || reranker.candidates[0].Body != "button misaligned" {
+		t.Fatalf("rerank candidates = %+v", reranker.candidates)
+	}
+	results := formatter.results
+	if len(results) != 2 || results[0].ID != "issue/3" {
+		t.Fatalf("expected issue/3 reranked first, got %+v", results)
+	}
+	if !results[0].IsDuplicate || !results[0].Reranked || results[0].RerankScore != 0.9 {
+		t.Fatalf("expected issue/3 to stay a duplicate, got %+v", results[0])
+	}
+	if results[1].IsDuplicate || !results[1].Reranked {
+		t.Fatalf("expected reranker to veto issue/2, got %+v", results[1])
+	}
+}
+
+func TestHandle_RerankFailureKeepsFusedResults(t *testing.T) {
+	t.Helper()
+
+	mockStore := &mockSearchIndexer{
+		vectorResults: []store.VectorResult{{ID: "issue/2", Number: 2, Title: "near", VecScore: 0.97}},
+		items:         map[string]store.ItemRecord{"issue/2": {ID: "issue/2", Title: "near"}},
+	}
+	formatter := &captureFormatter{}
+	var warnings []error
+	eng := &Engine{
+		Embedder:  &embed.MockEmbedder{Vectors: [][]float32{{1, 0, 0}}, Dims: 3},
+		Store:     mockStore,
+		Comments:  &mockCommentManager{},
+		Formatter: formatter,
+		Reranker:  &fakeReranker{err: errors.New("rate limited")},
+		Warn:      func(err error) { warnings = append(warnings, err) },
+	}
+
+	event := gh.Event{Type: "issue", Number: 1, Title: "login timeout", Body: "hangs"}
+	if err := eng.Handle(context.Background(), event); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Error(), "keeping fused ranking") {
+		t.Fatalf("expected one rerank warning, got %v", warnings)
+	}
+	if len(formatter.results) != 1 || !formatter.results[0].IsDuplicate || formatter.results[0].Reranked {
+		t.Fatalf("expected fused duplicate to be kept, got %+v", formatter.results)
+	}
+}
+
+type fakeReranker struct {
+	scores []float64
+	err    error
+
+	query      rerank.Item
+	candidates []rerank.Item
+}
+
+func (f *fakeReranker) Name() string { return "fake" }
+
+func (f *fakeReranker) Rerank(ctx context.Context, query rerank.Item, candidates []rerank.Item) ([]float64, error) {
+	_ = ctx
+	f.query = query
+	f.candidates = candidates
+	return f.scores, f.err
+}
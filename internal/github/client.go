@@ -2,9 +2,11 @@ package github
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	gh "github.com/google/go-github/v67/github"
 )
@@ -137,6 +139,123 @@ func (c *Client) GetPullRequestDiff(ctx context.Context, owner, repo string, num
 	return diff, nil
 }
 
+// ListRepositoryItems returns one page of issues and pull requests updated at
+// or after since (everything when since is zero), least recently updated first.
+// nextPage=0 means the last page has been reached.
+func (c *Client) ListRepositoryItems(ctx context.Context, owner, repo string, since time.Time, page int) ([]Event, int, error) {
+	if page < 1 {
+		page = 1
+	}
+	opt := &gh.IssueListByRepoOptions{
+		State:       "all",
+		Sort:        "updated",
+		Direction:   "asc",
+		Since:       since,
+		ListOptions: gh.ListOptions{Page: page, PerPage: 100},
+	}
+
+	issues, resp, err := c.api.Issues.ListByRepo(ctx, owner, repo, opt)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list repository items: %w", err)
+	}
+
+	out := make([]Event, 0, len(issues))
+	for _, issue := range issues {
+		if issue == nil || issue.GetNumber() == 0 {
+			continue
+		}
+		out = append(out, eventFromIssue(owner, repo, issue))
+	}
+
+	nextPage := 0
+	if resp != nil {
+		nextPage = resp.NextPage
+	}
+	return out, nextPage, nil
+}
+
+// GetPermissionLevel returns the collaborator's repository role (admin, maintain,
+// write, triage, read or none), preferring the fine-grained role name when present.
+func (c *Client) GetPermissionLevel(ctx context.Context, owner, repo, user string) (string, error) {
+	level, _, err := c.api.Repositories.GetPermissionLevel(ctx, owner, repo, user)
+	if err != nil {
+		return "", fmt.Errorf("get permission level: %w", err)
+	}
+	if role := strings.TrimSpace(level.GetRoleName()); role != "" {
+		return strings.ToLower(role), nil
+	}
+	return strings.ToLower(level.GetPermission()), nil
+}
+
+// FindDuplicateTarget scans an issue's comments, newest first, for GitHub's
+// "Duplicate of #N" convention and returns N.
+func (c *Client) FindDuplicateTarget(ctx context.Context, owner, repo string, number int) (int, bool, error) {
+	comments, err := c.ListIssueComments(ctx, owner, repo, number)
+	if err != nil {
+		return 0, false, err
+	}
+	for i := len(comments) - 1; i >= 0; i-- {
+		if strings.Contains(comments[i].Body, CommentMarker) {
+			continue
+		}
+		if target, ok := ParseDuplicateReference(comments[i].Body); ok && target != number {
+			return target, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// IsRateLimitError reports whether err was caused by a primary or secondary GitHub rate limit.
+func IsRateLimitError(err error) bool {
+	var rateErr *gh.RateLimitError
+	var abuseErr *gh.AbuseRateLimitError
+	return errors.As(err, &rateErr) || errors.As(err, &abuseErr)
+}
+
+func eventFromIssue(owner, repo string, issue *gh.Issue) Event {
+	labels := make([]string, 0, len(issue.Labels))
+	for _, label := range issue.Labels {
+		if strings.TrimSpace(label.GetName()) != "" {
+			labels = append(labels, label.GetName())
+		}
+	}
+
+	out := Event{
+		Type:   "issue",
+		Owner:  owner,
+		Repo:   repo,
+		Number: issue.GetNumber(),
+		Title:  issue.GetTitle(),
+		Body:   issue.GetBody(),
+		Labels: labels,
+		State:  issue.GetState(),
+		URL:    issue.GetHTMLURL(),
+
+		CreatedAt: issue.GetCreatedAt().Time,
+		UpdatedAt: issue.GetUpdatedAt().Time,
+		ClosedAt:  issue.GetClosedAt().Time,
+		Milestone: issue.GetMilestone().GetTitle(),
+		Draft:     issue.GetDraft(),
+
+		StateReason: issue.GetStateReason(),
+	}
+	if issue.User != nil {
+		out.Author = issue.User.GetLogin()
+	}
+	for _, assignee := range issue.Assignees {
+		if login := assignee.GetLogin(); strings.TrimSpace(login) != "" {
+			out.Assignees = append(out.Assignees, login)
+		}
+	}
+	if issue.IsPullRequest() {
+		out.Type = "pr"
+		if issue.PullRequestLinks.MergedAt != nil {
+			out.State = "merged"
+		}
+	}
+	return out
+}
+
 func issueCommentFromAPI(cm *gh.IssueComment) IssueComment {
 	out := IssueComment{}
 	if cm == nil {
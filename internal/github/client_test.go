@@ -9,6 +9,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	gh "github.com/google/go-github/v67/github"
 )
@@ -24,6 +25,8 @@ func TestParseEventFile_Issue(t *testing.T) {
     "body": "App hangs after 30s",
     "state": "open",
     "html_url": "https://github.com/acme/repo/issues/12",
+    "created_at": "2021-04-05T10:00:00Z",
+    "updated_at": "2021-05-06T11:30:00Z",
     "user": {"login": "alice"},
     "labels": [{"name":"bug"}, {"name":"auth"}]
   }
@@ -41,6 +44,9 @@ func TestParseEventFile_Issue(t *testing.T) {
 	if len(event.Labels) != 2 {
 		t.Fatalf("labels len = %d, want 2", len(event.Labels))
 	}
+	if !event.CreatedAt.Equal(time.Date(2021, 4, 5, 10, 0, 0, 0, time.UTC)) || !event.UpdatedAt.Equal(time.Date(2021, 5, 6, 11, 30, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected timestamps: created %v, updated %v", event.CreatedAt, event.UpdatedAt)
+	}
 }
 
 func TestParseEventFile_PullRequestTarget(t *testing.T) {
@@ -55,7 +61,12 @@ func TestParseEventFile_PullRequestTarget(t *testing.T) {
     "state": "open",
     "merged": false,
     "html_url": "https://github.com/acme/repo/pull/7",
+    "draft": true,
     "user": {"login": "bob"},
+    "labels": [{"name":"auth"}],
+    "assignees": [{"login":"carol"}],
+    "milestone": {"title":"v2.1"},
+    "base": {"ref":"release/2.x"},
     "files": ["src/auth.go", " README.md "],
     "diff": "@@ -1 +1 @@"
   }
@@ -79,6 +90,135 @@ func TestParseEventFile_PullRequestTarget(t *testing.T) {
 	if event.Diff == "" {
 		t.Fatalf("expected diff in event")
 	}
+	if !event.Draft || event.BaseBranch != "release/2.x" || event.Milestone != "v2.1" {
+		t.Fatalf("unexpected pr metadata: %+v", event)
+	}
+	if len(event.Labels) != 1 || len(event.Assignees) != 1 || event.Assignees[0] != "carol" {
+		t.Fatalf("unexpected pr labels/assignees: %+v", event)
+	}
+}
+
+func TestParseEventFile_MergedPullRequestClosed(t *testing.T) {
+	t.Helper()
+
+	payload := `{
+  "action": "closed",
+  "pull_request": {
+    "number": 8,
+    "title": "Fix auth",
+    "state": "closed",
+    "merged": true,
+    "user": {"login": "bob"}
+  }
+}`
+	path := writeTempPayload(t, payload)
+
+	event, err := ParseEventFile("pull_request_target", path, "acme/repo")
+	if err != nil {
+		t.Fatalf("ParseEventFile() error = %v", err)
+	}
+	if event.Action != "closed" || event.State != "merged" {
+		t.Fatalf("unexpected merged pr event: %+v", event)
+	}
+}
+
+func TestParseEventFile_IssueClosedAsDuplicate(t *testing.T) {
+	t.Helper()
+
+	payload := `{
+  "action": "closed",
+  "issue": {
+    "number": 12,
+    "title": "Login broken",
+    "state": "closed",
+    "state_reason": "duplicate",
+    "user": {"login": "alice"}
+  },
+  "sender": {"login": "maint"}
+}`
+	path := writeTempPayload(t, payload)
+
+	event, err := ParseEventFile("issues", path, "acme/repo")
+	if err != nil {
+		t.Fatalf("ParseEventFile() error = %v", err)
+	}
+	if event.StateReason != "duplicate" || event.Sender != "maint" {
+		t.Fatalf("unexpected close metadata: %+v", event)
+	}
+}
+
+func TestParseDuplicateReference(t *testing.T) {
+	t.Helper()
+
+	cases := map[string]int{
+		"Duplicate of #42":                    42,
+		"Thanks!\n  duplicate of #7 probably": 7,
+		"> **Possible duplicate** of #5":      0,
+		"not a duplicate of #3":               0,
+	}
+	for body, want := range cases {
+		got, ok := ParseDuplicateReference(body)
+		if ok != (want > 0) || got != want {
+			t.Fatalf("ParseDuplicateReference(%q) = %d, %v; want %d", body, got, ok, want)
+		}
+	}
+}
+
+func TestParseEventFile_IssueComment(t *testing.T) {
+	t.Helper()
+
+	payload := `{
+  "action": "created",
+  "issue": {
+    "number": 21,
+    "title": "Flaky login",
+    "body": "times out",
+    "state": "open",
+    "user": {"login": "alice"},
+    "pull_request": {"url": "https://api.github.com/repos/acme/repo/pulls/21"}
+  },
+  "comment": {"id": 99, "body": "/triage rescan", "user": {"login": "maint"}}
+}`
+	path := writeTempPayload(t, payload)
+
+	event, err := ParseEventFile("issue_comment", path, "acme/repo")
+	if err != nil {
+		t.Fatalf("ParseEventFile() error = %v", err)
+	}
+	if event.Type != "pr" || event.Number != 21 || event.Title != "Flaky login" {
+		t.Fatalf("unexpected comment parent: %+v", event)
+	}
+	if event.Comment == nil || event.Comment.ID != 99 || event.Comment.Author != "maint" || event.Comment.Body != "/triage rescan" {
+		t.Fatalf("unexpected comment: %+v", event.Comment)
+	}
+}
+
+func TestClient_GetPermissionLevel(t *testing.T) {
+	t.Helper()
+
+	transport := &recordingTransport{
+		handler: func(r *http.Request, body []byte) (*http.Response, error) {
+			switch r.URL.Path {
+			case "/repos/acme/repo/collaborators/maint/permission":
+				return jsonResponse(200, `{"permission":"write","role_name":"maintain"}`), nil
+			case "/repos/acme/repo/collaborators/reader/permission":
+				return jsonResponse(200, `{"permission":"read"}`), nil
+			default:
+				return jsonResponse(404, `{"message":"not found"}`), nil
+			}
+		},
+	}
+
+	client := NewClientFromGoGitHub(newGoGitHubClientWithTransport(transport))
+
+	level, err := client.GetPermissionLevel(context.Background(), "acme", "repo", "maint")
+	if err != nil || level != "maintain" {
+		t.Fatalf("GetPermissionLevel(maint) = %q, %v", level, err)
+	}
+	level, err = client.GetPermissionLevel(context.Background(), "acme", "repo", "reader")
+	if err != nil || level != "read" {
+		t.Fatalf("GetPermissionLevel(reader) = %q, %v", level, err)
+	}
 }
 
 func TestParseRepository(t *testing.T) {
@@ -192,6 +332,47 @@ func TestClient_PRFilesAndDiff(t *testing.T) {
 	}
 }
 
+func TestClient_ListRepositoryItems(t *testing.T) {
+	t.Helper()
+
+	transport := &recordingTransport{
+		handler: func(r *http.Request, body []byte) (*http.Response, error) {
+			if r.Method != http.MethodGet || r.URL.Path != "/repos/acme/repo/issues" {
+				return jsonResponse(404, `{"message":"not found"}`), nil
+			}
+			q := r.URL.Query()
+			if q.Get("state") != "all" || q.Get("sort") != "updated" || q.Get("direction") != "asc" || q.Get("page") != "2" || q.Get("since") != "2026-01-01T00:00:00Z" {
+				t.Fatalf("unexpected list query: %s", r.URL.RawQuery)
+			}
+			resp := jsonResponse(200, `[
+  {"number":3,"title":"Crash","body":"boom","state":"open","html_url":"https://github.com/acme/repo/issues/3","user":{"login":"alice"},"labels":[{"name":"bug"}]},
+  {"number":4,"title":"Fix crash","body":"fix","state":"closed","html_url":"https://github.com/acme/repo/pull/4","user":{"login":"bob"},"pull_request":{"url":"x","merged_at":"2026-01-02T00:00:00Z"}}
+]`)
+			resp.Header.Set("Link", `<https://api.github.com/repos/acme/repo/issues?page=3>; rel="next"`)
+			return resp, nil
+		},
+	}
+
+	client := NewClientFromGoGitHub(newGoGitHubClientWithTransport(transport))
+
+	items, next, err := client.ListRepositoryItems(context.Background(), "acme", "repo", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), 2)
+	if err != nil {
+		t.Fatalf("ListRepositoryItems() error = %v", err)
+	}
+	if next != 3 {
+		t.Fatalf("next page = %d, want 3", next)
+	}
+	if len(items) != 2 {
+		t.Fatalf("items len = %d, want 2", len(items))
+	}
+	if items[0].Type != "issue" || items[0].Number != 3 || items[0].Author != "alice" || len(items[0].Labels) != 1 {
+		t.Fatalf("unexpected issue item: %+v", items[0])
+	}
+	if items[1].Type != "pr" || items[1].State != "merged" {
+		t.Fatalf("unexpected pr item: %+v", items[1])
+	}
+}
+
 func TestNewClient_RequiresToken(t *testing.T) {
 	t.Helper()
 	if _, err := NewClient("", nil); err == nil {
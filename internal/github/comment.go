@@ -2,11 +2,16 @@ package github
 
 import (
 	"context"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
 const CommentMarker = "<!-- triage-bot:v1 -->"
 
+// duplicateReferencePattern matches GitHub's "Duplicate of #123" close convention.
+var duplicateReferencePattern = regexp.MustCompile(`(?im)^\s*duplicate of #(\d+)\b`)
+
 type CommentAction string
 
 const (
@@ -81,3 +86,16 @@ func normalizeCommentBody(body string) string {
 	}
 	return CommentMarker + "\n" + body
 }
+
+// ParseDuplicateReference extracts N from a "Duplicate of #N" line in a comment body.
+func ParseDuplicateReference(body string) (int, bool) {
+	match := duplicateReferencePattern.FindStringSubmatch(body)
+	if match == nil {
+		return 0, false
+	}
+	number, err := strconv.Atoi(match[1])
+	if err != nil || number <= 0 {
+		return 0, false
+	}
+	return number, true
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 )
 
 // Event is the normalized payload consumed by the triage engine.
@@ -25,8 +26,28 @@ type Event struct {
 	State  string
 	URL    string
 
+	// CreatedAt and UpdatedAt are GitHub's timestamps for the issue or PR;
+	// ClosedAt is zero while it is open.
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	ClosedAt  time.Time
+
+	Milestone string
+	Assignees []string
+	// Draft and BaseBranch are only set for pull requests.
+	Draft      bool
+	BaseBranch string
+
+	// StateReason is GitHub's close reason (completed, not_planned, duplicate).
+	StateReason string
+	// Sender is the user who triggered the event.
+	Sender string
+
 	Diff  string
 	Files []string
+
+	// Comment is set for issue_comment events.
+	Comment *IssueComment
 }
 
 func ParseRepository(repository string) (owner string, repo string, err error) {
@@ -55,6 +76,8 @@ func ParseEventFile(eventName, eventPath, repository string) (Event, error) {
 		return parseIssueEvent(payload, owner, repo)
 	case "pull_request", "pull_request_target":
 		return parsePullRequestEvent(payload, owner, repo)
+	case "issue_comment":
+		return parseIssueCommentEvent(payload, owner, repo)
 	default:
 		return Event{}, fmt.Errorf("unsupported event name %q", eventName)
 	}
@@ -70,13 +93,6 @@ func parseIssueEvent(payload []byte, owner, repo string) (Event, error) {
 		return Event{}, errors.New("issue number missing in event payload")
 	}
 
-	labels := make([]string, 0, len(in.Issue.Labels))
-	for _, label := range in.Issue.Labels {
-		if strings.TrimSpace(label.Name) != "" {
-			labels = append(labels, label.Name)
-		}
-	}
-
 	return Event{
 		Type:   "issue",
 		Action: in.Action,
@@ -86,9 +102,18 @@ func parseIssueEvent(payload []byte, owner, repo string) (Event, error) {
 		Title:  in.Issue.Title,
 		Body:   in.Issue.Body,
 		Author: in.Issue.User.Login,
-		Labels: labels,
+		Labels: labelNames(in.Issue.Labels),
 		State:  in.Issue.State,
 		URL:    in.Issue.HTMLURL,
+
+		CreatedAt: in.Issue.CreatedAt,
+		UpdatedAt: in.Issue.UpdatedAt,
+		ClosedAt:  in.Issue.ClosedAt,
+		Milestone: milestoneTitle(in.Issue.Milestone),
+		Assignees: userLogins(in.Issue.Assignees),
+
+		StateReason: in.Issue.StateReason,
+		Sender:      in.Sender.Login,
 	}, nil
 }
 
@@ -118,13 +143,97 @@ func parsePullRequestEvent(payload []byte, owner, repo string) (Event, error) {
 		Title:  in.PullRequest.Title,
 		Body:   in.PullRequest.Body,
 		Author: in.PullRequest.User.Login,
+		Labels: labelNames(in.PullRequest.Labels),
 		State:  state,
 		URL:    in.PullRequest.HTMLURL,
 		Diff:   in.PullRequest.Diff,
 		Files:  files,
+
+		CreatedAt:  in.PullRequest.CreatedAt,
+		UpdatedAt:  in.PullRequest.UpdatedAt,
+		ClosedAt:   in.PullRequest.ClosedAt,
+		Milestone:  milestoneTitle(in.PullRequest.Milestone),
+		Assignees:  userLogins(in.PullRequest.Assignees),
+		Draft:      in.PullRequest.Draft,
+		BaseBranch: in.PullRequest.Base.Ref,
+
+		Sender: in.Sender.Login,
 	}, nil
 }
 
+// parseIssueCommentEvent keeps the parent issue/PR fields so a comment command can re-run triage.
+func parseIssueCommentEvent(payload []byte, owner, repo string) (Event, error) {
+	var in issueCommentEventPayload
+	if err := json.Unmarshal(payload, &in); err != nil {
+		return Event{}, fmt.Errorf("decode issue comment event: %w", err)
+	}
+
+	if in.Issue.Number == 0 {
+		return Event{}, errors.New("issue number missing in comment event payload")
+	}
+
+	kind := "issue"
+	if in.Issue.PullRequest != nil {
+		kind = "pr"
+	}
+
+	return Event{
+		Type:   kind,
+		Action: in.Action,
+		Owner:  owner,
+		Repo:   repo,
+		Number: in.Issue.Number,
+		Title:  in.Issue.Title,
+		Body:   in.Issue.Body,
+		Author: in.Issue.User.Login,
+		Labels: labelNames(in.Issue.Labels),
+		State:  in.Issue.State,
+		URL:    in.Issue.HTMLURL,
+
+		CreatedAt: in.Issue.CreatedAt,
+		UpdatedAt: in.Issue.UpdatedAt,
+		ClosedAt:  in.Issue.ClosedAt,
+		Milestone: milestoneTitle(in.Issue.Milestone),
+		Assignees: userLogins(in.Issue.Assignees),
+		Draft:     in.Issue.Draft,
+
+		StateReason: in.Issue.StateReason,
+
+		Comment: &IssueComment{
+			ID:     in.Comment.ID,
+			Body:   in.Comment.Body,
+			Author: in.Comment.User.Login,
+		},
+	}, nil
+}
+
+func labelNames(labels []payloadLabel) []string {
+	out := make([]string, 0, len(labels))
+	for _, label := range labels {
+		if strings.TrimSpace(label.Name) != "" {
+			out = append(out, label.Name)
+		}
+	}
+	return out
+}
+
+func userLogins(users []payloadUser) []string {
+	out := make([]string, 0, len(users))
+	for _, user := range users {
+		if strings.TrimSpace(user.Login) != "" {
+			out = append(out, user.Login)
+		}
+	}
+	return out
+}
+
+func milestoneTitle(m *payloadMilestone) string {
+	if m == nil {
+		return ""
+	}
+	return m.Title
+}
+
 func normalizeFilePaths(paths []string) []string {
 	out := make([]string, 0, len(paths))
 	for _, p := range paths {
@@ -137,21 +246,37 @@ func normalizeFilePaths(paths []string) []string {
 	return out
 }
 
+type payloadLabel struct {
+	Name string `json:"name"`
+}
+
+type payloadUser struct {
+	Login string `json:"login"`
+}
+
+type payloadMilestone struct {
+	Title string `json:"title"`
+}
+
 type issueEventPayload struct {
 	Action string `json:"action"`
 	Issue  struct {
-		Number  int    `json:"number"`
-		Title   string `json:"title"`
-		Body    string `json:"body"`
-		State   string `json:"state"`
-		HTMLURL string `json:"html_url"`
-		User    struct {
-			Login string `json:"login"`
-		} `json:"user"`
-		Labels []struct {
-			Name string `json:"name"`
-		} `json:"labels"`
+		Number      int       `json:"number"`
+		Title       string    `json:"title"`
+		Body        string    `json:"body"`
+		State       string    `json:"state"`
+		StateReason string    `json:"state_reason"`
+		HTMLURL     string    `json:"html_url"`
+		CreatedAt   time.Time `json:"created_at"`
+		UpdatedAt   time.Time `json:"updated_at"`
+		ClosedAt    time.Time `json:"closed_at"`
+
+		User      payloadUser       `json:"user"`
+		Labels    []payloadLabel    `json:"labels"`
+		Assignees []payloadUser     `json:"assignees"`
+		Milestone *payloadMilestone `json:"milestone"`
 	} `json:"issue"`
+	Sender payloadUser `json:"sender"`
 }
 
 type pullRequestEventPayload struct {
@@ -164,12 +289,52 @@ type pullRequestEventPayload struct {
 		Merged  bool   `json:"merged"`
 		HTMLURL string `json:"html_url"`
 
+		Draft bool `json:"draft"`
+
+		CreatedAt time.Time `json:"created_at"`
+		UpdatedAt time.Time `json:"updated_at"`
+		ClosedAt  time.Time `json:"closed_at"`
+
 		// Optional convenience fields used by tests and local fixtures.
 		Diff  string   `json:"diff"`
 		Files []string `json:"files"`
 
+		User      payloadUser       `json:"user"`
+		Labels    []payloadLabel    `json:"labels"`
+		Assignees []payloadUser     `json:"assignees"`
+		Milestone *payloadMilestone `json:"milestone"`
+		Base      struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	} `json:"pull_request"`
+	Sender payloadUser `json:"sender"`
+}
+
+type issueCommentEventPayload struct {
+	Action string `json:"action"`
+	Issue  struct {
+		Number      int              `json:"number"`
+		Title       string           `json:"title"`
+		Body        string           `json:"body"`
+		State       string           `json:"state"`
+		HTMLURL     string           `json:"html_url"`
+		StateReason string           `json:"state_reason"`
+		Draft       bool             `json:"draft"`
+		CreatedAt   time.Time        `json:"created_at"`
+		UpdatedAt   time.Time        `json:"updated_at"`
+		ClosedAt    time.Time        `json:"closed_at"`
+		PullRequest *json.RawMessage `json:"pull_request"`
+
+		User      payloadUser       `json:"user"`
+		Labels    []payloadLabel    `json:"labels"`
+		Assignees []payloadUser     `json:"assignees"`
+		Milestone *payloadMilestone `json:"milestone"`
+	} `json:"issue"`
+	Comment struct {
+		ID   int64  `json:"id"`
+		Body string `json:"body"`
 		User struct {
 			Login string `json:"login"`
 		} `json:"user"`
-	} `json:"pull_request"`
+	} `json:"comment"`
 }
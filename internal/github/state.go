@@ -6,65 +6,165 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
-const defaultIndexFileName = "index.db"
+const (
+	defaultIndexFileName = "index.db"
+	defaultPushAttempts  = 3
 
-type CommandRunner interface {
-	Run(ctx context.Context, dir string, name string, args ...string) (string, error)
+	// StateBackendBranch keeps the index on an orphan branch.
+	StateBackendBranch = "branch"
+	// StateBackendRelease keeps the index as assets of a release.
+	StateBackendRelease = "release"
+	// StateBackendActionsCache keeps the index in the Actions cache service.
+	StateBackendActionsCache = "actions-cache"
+	// StateBackendLocal keeps the index in a directory on the runner.
+	StateBackendLocal = "local"
+
+	// BranchTransportREST reads and writes the branch through the Git Data API.
+	BranchTransportREST = "rest"
+	// BranchTransportGit reads and writes the branch by running the git CLI.
+	BranchTransportGit = "git"
+)
+
+// ErrPushRejected reports that the stored index changed since it was pulled.
+var ErrPushRejected = errors.New("index was updated by another run")
+
+// StateBackend stores the packed index files under a name: the branch, the
+// release tag, the cache key prefix or the directory, depending on the backend.
+type StateBackend interface {
+	// Fetch copies the latest stored files into dstDir and returns the
+	// version they belong to. found=false means nothing is stored yet.
+	Fetch(ctx context.Context, name, dstDir string) (version string, found bool, err error)
+	// Publish replaces the stored files with those in srcDir and returns the
+	// new version. Unless force is set the latest version must still be
+	// expect ("" meaning nothing is stored); otherwise ErrPushRejected is
+	// returned.
+	Publish(ctx context.Context, name, srcDir, expect string, force bool) (version string, err error)
 }
 
-type ExecRunner struct{}
+// StateBackendConfig holds what NewStateBackend needs for any backend.
+type StateBackendConfig struct {
+	Owner string
+	Repo  string
+	Token string
 
-func (ExecRunner) Run(ctx context.Context, dir string, name string, args ...string) (string, error) {
-	cmd := exec.CommandContext(ctx, name, args...)
-	cmd.Dir = dir
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return string(output), fmt.Errorf("%s %s: %w (%s)", name, strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	// Transport is how the branch backend talks to GitHub; "" means git.
+	Transport string
+	// Directory is where the local backend keeps the index.
+	Directory string
+	// CacheURL and CacheToken reach the Actions cache service; on a runner
+	// they are ACTIONS_RESULTS_URL and ACTIONS_RUNTIME_TOKEN.
+	CacheURL   string
+	CacheToken string
+}
+
+// StateBackendNames lists the backends NewStateBackend accepts.
+func StateBackendNames() []string {
+	return []string{StateBackendBranch, StateBackendRelease, StateBackendActionsCache, StateBackendLocal}
+}
+
+// BranchTransportNames lists the transports the branch backend accepts.
+func BranchTransportNames() []string {
+	return []string{BranchTransportGit, BranchTransportREST}
+}
+
+// NewStateBackend builds a state backend by name; "" selects the branch.
+func NewStateBackend(name string, cfg StateBackendConfig) (StateBackend, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", StateBackendBranch:
+		return newBranchBackend(cfg)
+	case StateBackendRelease:
+		client, err := NewClient(cfg.Token, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &ReleaseBackend{Client: client, Owner: cfg.Owner, Repo: cfg.Repo}, nil
+	case StateBackendActionsCache:
+		if strings.TrimSpace(cfg.CacheURL) == "" || strings.TrimSpace(cfg.CacheToken) == "" {
+			return nil, errors.New("actions cache backend needs ACTIONS_RESULTS_URL and ACTIONS_RUNTIME_TOKEN")
+		}
+		return &ActionsCacheBackend{URL: cfg.CacheURL, Token: cfg.CacheToken}, nil
+	case StateBackendLocal:
+		if strings.TrimSpace(cfg.Directory) == "" {
+			return nil, errors.New("local backend needs a directory")
+		}
+		return &LocalBackend{Dir: cfg.Directory}, nil
+	default:
+		return nil, fmt.Errorf("unknown state backend %q (supported: %s)", name, strings.Join(StateBackendNames(), ", "))
 	}
-	return string(output), nil
 }
 
+func newBranchBackend(cfg StateBackendConfig) (StateBackend, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Transport)) {
+	case "", BranchTransportGit:
+		return &GitBackend{Owner: cfg.Owner, Repo: cfg.Repo, Token: cfg.Token}, nil
+	case BranchTransportREST:
+		client, err := NewClient(cfg.Token, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &RESTBackend{Client: client, Owner: cfg.Owner, Repo: cfg.Repo}, nil
+	default:
+		return nil, fmt.Errorf("unknown branch transport %q (supported: %s)", cfg.Transport, strings.Join(BranchTransportNames(), ", "))
+	}
+}
+
+// StateManager keeps index.db in a StateBackend, gzip compressed and split
+// into parts listed in a checksummed manifest. Pull records the version it
+// fetched, and Push only replaces that version, so concurrent runs cannot
+// silently overwrite each other.
 type StateManager struct {
-	Owner  string
-	Repo   string
-	Token  string
+	Backend StateBackend
+	// Branch names the stored index: the orphan branch, or the release tag,
+	// cache key prefix or directory of the other backends.
 	Branch string
-	Runner CommandRunner
+
+	// Merge folds a newer remote index, downloaded to remotePath, into the
+	// index being pushed. When set, a rejected Push re-pulls, merges and
+	// retries; without it the rejection is returned.
+	Merge func(ctx context.Context, remotePath string) error
+	// MaxPushAttempts bounds those retries (default 3).
+	MaxPushAttempts int
+	// PartSize caps each stored part in bytes (default DefaultStatePartSize).
+	PartSize int64
+
+	// lease is the stored version the next Push expects; "" means nothing
+	// may be stored yet. It is only meaningful once pulled is set.
+	lease  string
+	pulled bool
 }
 
-func (m StateManager) branchName() string {
+func (m *StateManager) branchName() string {
 	if strings.TrimSpace(m.Branch) == "" {
 		return "triage-index"
 	}
 	return m.Branch
 }
 
-func (m StateManager) runner() CommandRunner {
-	if m.Runner == nil {
-		return ExecRunner{}
+func (m *StateManager) backend() (StateBackend, error) {
+	if m.Backend == nil {
+		return nil, errors.New("state backend is required")
 	}
-	return m.Runner
+	return m.Backend, nil
 }
 
-func (m StateManager) remoteURL() (string, error) {
-	if strings.TrimSpace(m.Token) == "" {
-		return "", errors.New("token is required for state manager")
+func (m *StateManager) pushAttempts() int {
+	if m.MaxPushAttempts <= 0 {
+		return defaultPushAttempts
 	}
-	if strings.TrimSpace(m.Owner) == "" || strings.TrimSpace(m.Repo) == "" {
-		return "", errors.New("owner/repo is required for state manager")
-	}
-	return fmt.Sprintf("https://x-access-token:%s@github.com/%s/%s.git", m.Token, m.Owner, m.Repo), nil
+	return m.MaxPushAttempts
 }
 
-// Pull downloads index.db from the configured orphan branch.
-// found=false means the branch does not exist yet (first-run case).
-func (m StateManager) Pull(ctx context.Context, dstPath string) (found bool, err error) {
-	url, err := m.remoteURL()
+// Pull downloads index.db from the backend, verifying and reassembling its
+// parts, and remembers the fetched version for the next Push. Branches that
+// still hold a single uncompressed index.db are read as is.
+// found=false means nothing is stored yet (first-run case).
+func (m *StateManager) Pull(ctx context.Context, dstPath string) (found bool, err error) {
+	backend, err := m.backend()
 	if err != nil {
 		return false, err
 	}
@@ -78,40 +178,25 @@ func (m StateManager) Pull(ctx context.Context, dstPath string) (found bool, err
 	}
 	defer os.RemoveAll(tmpDir)
 
-	r := m.runner()
-	if _, err := r.Run(ctx, tmpDir, "git", "init"); err != nil {
-		return false, err
-	}
-	if _, err := r.Run(ctx, tmpDir, "git", "remote", "add", "origin", url); err != nil {
+	version, found, err := backend.Fetch(ctx, m.branchName(), tmpDir)
+	if err != nil {
 		return false, err
 	}
-
-	branch := m.branchName()
-	if out, err := r.Run(ctx, tmpDir, "git", "fetch", "origin", branch, "--depth=1"); err != nil {
-		if isMissingBranchError(out) || isMissingBranchError(err.Error()) {
-			return false, nil
+	if found {
+		if err := unpackIndex(tmpDir, dstPath); err != nil {
+			return false, err
 		}
-		return false, err
-	}
-
-	if _, err := r.Run(ctx, tmpDir, "git", "checkout", "FETCH_HEAD", "--", defaultIndexFileName); err != nil {
-		return false, err
-	}
-
-	src := filepath.Join(tmpDir, defaultIndexFileName)
-	if _, err := os.Stat(src); err != nil {
-		return false, fmt.Errorf("pulled index file missing: %w", err)
 	}
-	if err := copyFile(src, dstPath); err != nil {
-		return false, fmt.Errorf("copy pulled index file: %w", err)
-	}
-
-	return true, nil
+	m.lease, m.pulled = version, true
+	return found, nil
 }
 
-// Push uploads index.db to the configured orphan branch.
-func (m StateManager) Push(ctx context.Context, srcPath string) error {
-	url, err := m.remoteURL()
+// Push uploads index.db to the backend as a new version, leased against the
+// version Pull saw. If another run pushed in between, Push pulls its index,
+// hands it to Merge and tries again, up to MaxPushAttempts. A Push without a
+// prior Pull has nothing to lease against and overwrites whatever is stored.
+func (m *StateManager) Push(ctx context.Context, srcPath string) error {
+	backend, err := m.backend()
 	if err != nil {
 		return err
 	}
@@ -122,49 +207,104 @@ func (m StateManager) Push(ctx context.Context, srcPath string) error {
 		return fmt.Errorf("source index file missing: %w", err)
 	}
 
+	for attempt := 1; ; attempt++ {
+		version, err := m.publish(ctx, backend, srcPath)
+		if err == nil {
+			if version != "" {
+				m.lease, m.pulled = version, true
+			}
+			return nil
+		}
+		if !errors.Is(err, ErrPushRejected) || m.Merge == nil || attempt >= m.pushAttempts() {
+			return err
+		}
+		if err := m.mergeRemote(ctx); err != nil {
+			return fmt.Errorf("merge concurrent index update: %w", err)
+		}
+	}
+}
+
+// publish packs the current index, which a merge may have just changed, and
+// hands the parts to the backend.
+func (m *StateManager) publish(ctx context.Context, backend StateBackend, srcPath string) (string, error) {
 	tmpDir, err := os.MkdirTemp("", "triage-state-push-*")
 	if err != nil {
-		return fmt.Errorf("create temp dir: %w", err)
+		return "", fmt.Errorf("create temp dir: %w", err)
 	}
 	defer os.RemoveAll(tmpDir)
 
-	r := m.runner()
-	if _, err := r.Run(ctx, tmpDir, "git", "init"); err != nil {
-		return err
+	if err := packIndex(srcPath, tmpDir, m.PartSize); err != nil {
+		return "", err
 	}
-	if _, err := r.Run(ctx, tmpDir, "git", "remote", "add", "origin", url); err != nil {
-		return err
+	return backend.Publish(ctx, m.branchName(), tmpDir, m.lease, !m.pulled)
+}
+
+// latestGeneration picks the highest generation number among names, for the
+// backends that store each version under a new number. ok=false when none
+// parse.
+func latestGeneration(names []string) (gen int, ok bool) {
+	for _, name := range names {
+		n, err := strconv.Atoi(name)
+		if err != nil || n < 1 {
+			continue
+		}
+		if n > gen {
+			gen, ok = n, true
+		}
 	}
+	return gen, ok
+}
 
-	branch := m.branchName()
-	if _, err := r.Run(ctx, tmpDir, "git", "checkout", "--orphan", branch); err != nil {
-		return err
+// nextGeneration checks a lease against the latest stored generation and
+// returns the number to publish under. Those backends refuse to create a
+// generation twice, so two runs racing for the same number cannot both win.
+func nextGeneration(latest int, found bool, expect string, force bool) (int, error) {
+	current := ""
+	if found {
+		current = strconv.Itoa(latest)
+	}
+	if !force && current != expect {
+		return 0, fmt.Errorf("%w: latest version is %q, expected %q", ErrPushRejected, current, expect)
 	}
-	_, _ = r.Run(ctx, tmpDir, "git", "rm", "-rf", ".") // can fail on empty tree; safe to ignore
+	return latest + 1, nil
+}
 
-	dst := filepath.Join(tmpDir, defaultIndexFileName)
-	if err := copyFile(srcPath, dst); err != nil {
-		return fmt.Errorf("copy index file for push: %w", err)
+// mergeRemote pulls the index that won the race (moving the lease to it) and
+// merges it into the local one.
+func (m *StateManager) mergeRemote(ctx context.Context) error {
+	tmpDir, err := os.MkdirTemp("", "triage-state-merge-*")
+	if err != nil {
+		return fmt.Errorf("create temp dir: %w", err)
 	}
+	defer os.RemoveAll(tmpDir)
 
-	if _, err := r.Run(ctx, tmpDir, "git", "add", defaultIndexFileName); err != nil {
+	remotePath := filepath.Join(tmpDir, defaultIndexFileName)
+	found, err := m.Pull(ctx, remotePath)
+	if err != nil || !found {
+		// A deleted index needs no merge; the lease now expects it missing.
 		return err
 	}
-	if _, err := r.Run(ctx, tmpDir, "git", "-c", "user.name=triage-bot", "-c", "user.email=triage-bot@users.noreply.github.com", "commit", "-m", "Update triage index [skip ci]"); err != nil {
+	return m.Merge(ctx, remotePath)
+}
+
+// copyFiles copies the regular files directly inside srcDir (so not .git) to
+// dstDir.
+func copyFiles(srcDir, dstDir string) error {
+	entries, err := os.ReadDir(srcDir)
+	if err != nil {
 		return err
 	}
-	if _, err := r.Run(ctx, tmpDir, "git", "push", "origin", branch, "--force"); err != nil {
-		return err
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		if err := copyFile(filepath.Join(srcDir, entry.Name()), filepath.Join(dstDir, entry.Name())); err != nil {
+			return err
+		}
 	}
-
 	return nil
 }
 
-func isMissingBranchError(raw string) bool {
-	raw = strings.ToLower(raw)
-	return strings.Contains(raw, "couldn't find remote ref") || strings.Contains(raw, "unknown revision")
-}
-
 func copyFile(src, dst string) error {
 	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
 		return err
@@ -0,0 +1,331 @@
+package github
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const actionsCacheService = "twirp/github.actions.results.api.v1.CacheService/"
+
+// actionsCacheMaxSkips bounds how many reserved but unfinished keys Publish
+// steps over before giving up.
+const actionsCacheMaxSkips = 5
+
+// actionsCacheVersion separates our entries from caches other tools store
+// under the same key; the service treats it as an opaque hash.
+var actionsCacheVersion = func() string {
+	sum := sha256.Sum256([]byte("vector-triage index v1"))
+	return hex.EncodeToString(sum[:])
+}()
+
+// ActionsCacheBackend keeps the index in the Actions cache service, stored as
+// a tar of the packed files under the key "<name>-<n>". Cache entries are
+// immutable and a key can only be reserved once, so the first run to reserve
+// the next number is the only one to store it; a restore by the "<name>-"
+// prefix finds the newest. A run that dies between reserving and finalizing
+// leaves its key taken forever, so Publish steps over reserved keys that were
+// never stored, and a run whose entry was stepped over while it uploaded sees
+// a newer one after finalizing and merges it. Entries unused for 7 days are
+// evicted, so a cache-backed index should be rebuilt with backfill when that
+// happens.
+type ActionsCacheBackend struct {
+	// URL is ACTIONS_RESULTS_URL and Token is ACTIONS_RUNTIME_TOKEN.
+	URL        string
+	Token      string
+	HTTPClient *http.Client
+}
+
+type cacheServiceError struct {
+	Status int    `json:"-"`
+	Code   string `json:"code"`
+	Msg    string `json:"msg"`
+}
+
+func (e *cacheServiceError) Error() string {
+	return fmt.Sprintf("actions cache service: %d %s: %s", e.Status, e.Code, e.Msg)
+}
+
+func (b *ActionsCacheBackend) client() *http.Client {
+	if b.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return b.HTTPClient
+}
+
+// Fetch downloads and unpacks the newest entry.
+func (b *ActionsCacheBackend) Fetch(ctx context.Context, name, dstDir string) (string, bool, error) {
+	gen, downloadURL, found, err := b.lookup(ctx, name)
+	if err != nil || !found {
+		return "", false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return "", false, err
+	}
+	resp, err := b.client().Do(req)
+	if err != nil {
+		return "", false, fmt.Errorf("download cached index: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", false, fmt.Errorf("download cached index: status %d", resp.StatusCode)
+	}
+	if err := os.MkdirAll(dstDir, 0o755); err != nil {
+		return "", false, err
+	}
+	if err := extractFiles(resp.Body, dstDir); err != nil {
+		return "", false, fmt.Errorf("unpack cached index: %w", err)
+	}
+	return strconv.Itoa(gen), true, nil
+}
+
+// Publish reserves the next key, uploads the tar to the signed URL the
+// service hands out and finalizes the entry.
+func (b *ActionsCacheBackend) Publish(ctx context.Context, name, srcDir, expect string, force bool) (string, error) {
+	latest, _, found, err := b.lookup(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	gen, err := nextGeneration(latest, found, expect, force)
+	if err != nil {
+		return "", err
+	}
+
+	archive, err := os.CreateTemp("", "triage-state-cache-*.tar")
+	if err != nil {
+		return "", fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+	if err := archiveFiles(srcDir, archive); err != nil {
+		return "", fmt.Errorf("archive index files: %w", err)
+	}
+	size, err := archive.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", err
+	}
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	gen, uploadURL, err := b.reserve(ctx, name, gen)
+	if err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("%s-%d", name, gen)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, uploadURL, io.NopCloser(archive))
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = size
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := b.client().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("upload cached index: %w", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("upload cached index: status %d", resp.StatusCode)
+	}
+
+	var finalized struct {
+		OK bool `json:"ok"`
+	}
+	if err := b.call(ctx, "FinalizeCacheEntryUpload", map[string]any{"key": key, "version": actionsCacheVersion, "size_bytes": strconv.FormatInt(size, 10)}, &finalized); err != nil {
+		return "", fmt.Errorf("finalize cache entry: %w", err)
+	}
+	if !finalized.OK {
+		return "", fmt.Errorf("finalize cache entry %s: not accepted", key)
+	}
+
+	// A run that stepped over this key while it uploaded stored a newer
+	// entry without these updates; merging it is now this run's job.
+	newest, _, found, err := b.lookup(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if found && newest > gen {
+		return "", fmt.Errorf("%w: cache key %s was superseded by %s-%d", ErrPushRejected, key, name, newest)
+	}
+	return strconv.Itoa(gen), nil
+}
+
+// reserve claims the first free key from gen up and returns its number and
+// upload URL. A taken key that holds a stored entry means another run won;
+// one that was only reserved is stepped over.
+func (b *ActionsCacheBackend) reserve(ctx context.Context, name string, gen int) (int, string, error) {
+	for skips := 0; ; skips++ {
+		key := fmt.Sprintf("%s-%d", name, gen)
+		var created struct {
+			OK              bool   `json:"ok"`
+			SignedUploadURL string `json:"signed_upload_url"`
+		}
+		err := b.call(ctx, "CreateCacheEntry", map[string]any{"key": key, "version": actionsCacheVersion}, &created)
+		var serviceErr *cacheServiceError
+		taken := (err == nil && !created.OK) || (errors.As(err, &serviceErr) && (serviceErr.Status == http.StatusConflict || serviceErr.Code == "already_exists"))
+		if !taken {
+			if err != nil {
+				return 0, "", fmt.Errorf("reserve cache entry: %w", err)
+			}
+			return gen, created.SignedUploadURL, nil
+		}
+
+		stored, err := b.stored(ctx, key)
+		if err != nil {
+			return 0, "", err
+		}
+		if stored {
+			return 0, "", fmt.Errorf("%w: cache key %s was stored by another run", ErrPushRejected, key)
+		}
+		if skips >= actionsCacheMaxSkips {
+			return 0, "", fmt.Errorf("reserve cache entry: %s and the %d keys before it are reserved but were never stored", key, skips)
+		}
+		gen++
+	}
+}
+
+// stored reports whether key holds a finalized entry rather than only a
+// reservation.
+func (b *ActionsCacheBackend) stored(ctx context.Context, key string) (bool, error) {
+	var out struct {
+		OK         bool   `json:"ok"`
+		MatchedKey string `json:"matched_key"`
+	}
+	if err := b.call(ctx, "GetCacheEntryDownloadURL", map[string]any{"key": key, "version": actionsCacheVersion}, &out); err != nil {
+		var serviceErr *cacheServiceError
+		if errors.As(err, &serviceErr) && serviceErr.Status == http.StatusNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("look up cache entry %s: %w", key, err)
+	}
+	return out.OK && out.MatchedKey == key, nil
+}
+
+// lookup finds the newest entry under the name's key prefix.
+func (b *ActionsCacheBackend) lookup(ctx context.Context, name string) (gen int, downloadURL string, found bool, err error) {
+	prefix := name + "-"
+	var out struct {
+		OK                bool   `json:"ok"`
+		SignedDownloadURL string `json:"signed_download_url"`
+		MatchedKey        string `json:"matched_key"`
+	}
+	if err := b.call(ctx, "GetCacheEntryDownloadURL", map[string]any{
+		"key":          prefix,
+		"restore_keys": []string{prefix},
+		"version":      actionsCacheVersion,
+	}, &out); err != nil {
+		var serviceErr *cacheServiceError
+		if errors.As(err, &serviceErr) && serviceErr.Status == http.StatusNotFound {
+			return 0, "", false, nil
+		}
+		return 0, "", false, fmt.Errorf("look up cached index: %w", err)
+	}
+	if !out.OK {
+		return 0, "", false, nil
+	}
+	gen, ok := latestGeneration([]string{strings.TrimPrefix(out.MatchedKey, prefix)})
+	if !ok {
+		return 0, "", false, fmt.Errorf("look up cached index: unexpected key %q", out.MatchedKey)
+	}
+	return gen, out.SignedDownloadURL, true, nil
+}
+
+func (b *ActionsCacheBackend) call(ctx context.Context, method string, in, out any) error {
+	if strings.TrimSpace(b.URL) == "" || strings.TrimSpace(b.Token) == "" {
+		return errors.New("actions cache URL and token are required")
+	}
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	endpoint := strings.TrimRight(b.URL, "/") + "/" + actionsCacheService + method
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+b.Token)
+
+	resp, err := b.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		serviceErr := &cacheServiceError{Status: resp.StatusCode}
+		if json.Unmarshal(raw, serviceErr) != nil || serviceErr.Msg == "" {
+			serviceErr.Msg = strings.TrimSpace(string(raw))
+		}
+		return serviceErr
+	}
+	return json.Unmarshal(raw, out)
+}
+
+func archiveFiles(srcDir string, w io.Writer) error {
+	entries, err := os.ReadDir(srcDir)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(w)
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(srcDir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: entry.Name(), Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func extractFiles(r io.Reader, dstDir string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg || filepath.Base(header.Name) != header.Name {
+			continue
+		}
+		out, err := os.Create(filepath.Join(dstDir, header.Name))
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, tr); err != nil {
+			_ = out.Close()
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestActionsCacheBackend_PullPushAndReservationRace(t *testing.T) {
+	t.Helper()
+
+	fake := &fakeCacheService{entries: map[string]*fakeCacheEntry{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	fake.baseURL = server.URL
+	backend := &ActionsCacheBackend{URL: server.URL + "/", Token: "runtime-token"}
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	first := &StateManager{Backend: backend}
+	firstPath := filepath.Join(dir, "first.db")
+	if found, err := first.Pull(ctx, firstPath); err != nil || found {
+		t.Fatalf("Pull() on empty cache = %v, %v; want not found", found, err)
+	}
+	writeIndex(t, firstPath, "v1")
+	if err := first.Push(ctx, firstPath); err != nil {
+		t.Fatalf("first Push() error = %v", err)
+	}
+
+	winner, loser := &StateManager{Backend: backend}, &StateManager{Backend: backend}
+	winnerPath, loserPath := filepath.Join(dir, "winner.db"), filepath.Join(dir, "loser.db")
+	if _, err := winner.Pull(ctx, winnerPath); err != nil {
+		t.Fatalf("winner Pull() error = %v", err)
+	}
+	if _, err := loser.Pull(ctx, loserPath); err != nil {
+		t.Fatalf("loser Pull() error = %v", err)
+	}
+	writeIndex(t, winnerPath, "v1+winner")
+	if err := winner.Push(ctx, winnerPath); err != nil {
+		t.Fatalf("winner Push() error = %v", err)
+	}
+	loser.Merge = func(ctx context.Context, remotePath string) error {
+		_ = ctx
+		writeIndex(t, loserPath, readIndex(t, remotePath)+"+loser")
+		return nil
+	}
+	writeIndex(t, loserPath, "v1+loser")
+	if err := loser.Push(ctx, loserPath); err != nil {
+		t.Fatalf("loser Push() error = %v", err)
+	}
+
+	checkPath := filepath.Join(dir, "check.db")
+	checker := &StateManager{Backend: backend}
+	if _, err := checker.Pull(ctx, checkPath); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if got := readIndex(t, checkPath); got != "v1+winner+loser" {
+		t.Fatalf("cached index = %q, want both runs' updates", got)
+	}
+
+	// Another run stored the next key since this lease was taken.
+	fake.entries["triage-index-4"] = &fakeCacheEntry{order: 99, content: []byte("x"), finalized: true}
+	if _, err := backend.Publish(ctx, "triage-index", t.TempDir(), "3", false); !errors.Is(err, ErrPushRejected) {
+		t.Fatalf("Publish() onto a stored key error = %v, want ErrPushRejected", err)
+	}
+}
+
+func TestActionsCacheBackend_StepsOverAbandonedReservations(t *testing.T) {
+	t.Helper()
+
+	fake := &fakeCacheService{entries: map[string]*fakeCacheEntry{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	fake.baseURL = server.URL
+	backend := &ActionsCacheBackend{URL: server.URL + "/", Token: "runtime-token"}
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "index.db")
+	writeIndex(t, path, "v1")
+	if err := (&StateManager{Backend: backend}).Push(ctx, path); err != nil {
+		t.Fatalf("first Push() error = %v", err)
+	}
+
+	// A run reserved the next key and died before finalizing it.
+	fake.created++
+	fake.entries["triage-index-2"] = &fakeCacheEntry{order: fake.created}
+	run := &StateManager{Backend: backend}
+	if _, err := run.Pull(ctx, path); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	writeIndex(t, path, "v2")
+	if err := run.Push(ctx, path); err != nil {
+		t.Fatalf("Push() past a reserved key error = %v", err)
+	}
+	if run.lease != "3" {
+		t.Fatalf("lease = %q, want the key after the reserved one", run.lease)
+	}
+
+	// Another run steps over this run's reservation and stores first; this
+	// run must merge it after finalizing.
+	fake.onFinalize = func(key string) {
+		if key != "triage-index-4" {
+			return
+		}
+		fake.created++
+		fake.entries["triage-index-5"] = &fakeCacheEntry{order: fake.created, content: fake.entries[key].content, finalized: true}
+		fake.onFinalize = nil
+	}
+	var merged int
+	run.Merge = func(ctx context.Context, remotePath string) error {
+		_ = ctx
+		merged++
+		writeIndex(t, path, readIndex(t, remotePath)+"+merged")
+		return nil
+	}
+	writeIndex(t, path, "v3")
+	if err := run.Push(ctx, path); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if merged != 1 || run.lease != "6" {
+		t.Fatalf("merged = %d lease = %q, want one merge and key 6", merged, run.lease)
+	}
+
+	// Too many abandoned reservations is an error rather than a retry loop.
+	for n := 7; n <= 7+actionsCacheMaxSkips; n++ {
+		fake.created++
+		fake.entries["triage-index-"+strconv.Itoa(n)] = &fakeCacheEntry{order: fake.created}
+	}
+	if err := run.Push(ctx, path); err == nil || errors.Is(err, ErrPushRejected) {
+		t.Fatalf("Push() past too many reservations error = %v, want a plain error", err)
+	}
+}
+
+type fakeCacheEntry struct {
+	order     int
+	content   []byte
+	finalized bool
+}
+
+// fakeCacheService serves the cache service's Twirp methods and the signed
+// blob URLs they hand out.
+type fakeCacheService struct {
+	mu      sync.Mutex
+	baseURL string
+	created int
+	entries map[string]*fakeCacheEntry
+	// onFinalize, when set, runs after an entry is finalized.
+	onFinalize func(key string)
+}
+
+func (f *fakeCacheService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	reply := func(status int, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(v)
+	}
+
+	if key, ok := strings.CutPrefix(r.URL.Path, "/blob/"); ok {
+		entry := f.entries[key]
+		switch {
+		case entry == nil:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPut && r.Header.Get("x-ms-blob-type") == "BlockBlob":
+			entry.content, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodGet && entry.finalized:
+			_, _ = w.Write(entry.content)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		return
+	}
+
+	method, ok := strings.CutPrefix(r.URL.Path, "/"+actionsCacheService)
+	if !ok || r.Header.Get("Authorization") != "Bearer runtime-token" {
+		reply(http.StatusUnauthorized, map[string]string{"code": "unauthenticated", "msg": "bad token"})
+		return
+	}
+	var req struct {
+		Key         string   `json:"key"`
+		RestoreKeys []string `json:"restore_keys"`
+		Version     string   `json:"version"`
+		SizeBytes   string   `json:"size_bytes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Version != actionsCacheVersion {
+		reply(http.StatusBadRequest, map[string]string{"code": "malformed", "msg": "bad request"})
+		return
+	}
+
+	switch method {
+	case "GetCacheEntryDownloadURL":
+		// An exact key match wins, then the newest entry under a restore prefix.
+		best, bestKey := (*fakeCacheEntry)(nil), ""
+		if entry := f.entries[req.Key]; entry != nil && entry.finalized {
+			best, bestKey = entry, req.Key
+		} else if len(req.RestoreKeys) > 0 {
+			for key, entry := range f.entries {
+				if entry.finalized && strings.HasPrefix(key, req.RestoreKeys[0]) && (best == nil || entry.order > best.order) {
+					best, bestKey = entry, key
+				}
+			}
+		}
+		if best == nil {
+			reply(http.StatusOK, map[string]any{"ok": false})
+			return
+		}
+		reply(http.StatusOK, map[string]any{"ok": true, "matched_key": bestKey, "signed_download_url": f.baseURL + "/blob/" + bestKey})
+	case "CreateCacheEntry":
+		if _, exists := f.entries[req.Key]; exists {
+			reply(http.StatusConflict, map[string]string{"code": "already_exists", "msg": "cache entry exists"})
+			return
+		}
+		f.created++
+		f.entries[req.Key] = &fakeCacheEntry{order: f.created}
+		reply(http.StatusOK, map[string]any{"ok": true, "signed_upload_url": f.baseURL + "/blob/" + req.Key})
+	case "FinalizeCacheEntryUpload":
+		entry := f.entries[req.Key]
+		if entry == nil || req.SizeBytes != strconv.Itoa(len(entry.content)) {
+			reply(http.StatusOK, map[string]any{"ok": false})
+			return
+		}
+		entry.finalized = true
+		if f.onFinalize != nil {
+			f.onFinalize(req.Key)
+		}
+		reply(http.StatusOK, map[string]any{"ok": true, "entry_id": strconv.Itoa(entry.order)})
+	default:
+		reply(http.StatusNotFound, map[string]string{"code": "bad_route", "msg": method})
+	}
+}
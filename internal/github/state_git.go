@@ -0,0 +1,180 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+type CommandRunner interface {
+	Run(ctx context.Context, dir string, name string, args ...string) (string, error)
+}
+
+type ExecRunner struct{}
+
+func (ExecRunner) Run(ctx context.Context, dir string, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(output), fmt.Errorf("%s %s: %w (%s)", name, strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// GitBackend runs the git CLI against an HTTPS remote that carries the token.
+// Errors and command output have the token redacted before they are returned.
+type GitBackend struct {
+	Owner  string
+	Repo   string
+	Token  string
+	Runner CommandRunner
+}
+
+func (b *GitBackend) runner() CommandRunner {
+	if b.Runner == nil {
+		return ExecRunner{}
+	}
+	return b.Runner
+}
+
+func (b *GitBackend) remoteURL() (string, error) {
+	if strings.TrimSpace(b.Token) == "" {
+		return "", errors.New("token is required for state manager")
+	}
+	if strings.TrimSpace(b.Owner) == "" || strings.TrimSpace(b.Repo) == "" {
+		return "", errors.New("owner/repo is required for state manager")
+	}
+	return fmt.Sprintf("https://x-access-token:%s@github.com/%s/%s.git", b.Token, b.Owner, b.Repo), nil
+}
+
+// git runs one git command, keeping the token out of what it returns.
+func (b *GitBackend) git(ctx context.Context, dir string, args ...string) (string, error) {
+	out, err := b.runner().Run(ctx, dir, "git", args...)
+	out = redactToken(out, b.Token)
+	if err != nil {
+		// Not wrapped: the original error still carries the token.
+		return out, errors.New(redactToken(err.Error(), b.Token))
+	}
+	return out, nil
+}
+
+// Fetch checks out the branch tip and copies its files.
+func (b *GitBackend) Fetch(ctx context.Context, branch, dstDir string) (string, bool, error) {
+	url, err := b.remoteURL()
+	if err != nil {
+		return "", false, err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "triage-state-pull-*")
+	if err != nil {
+		return "", false, fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if _, err := b.git(ctx, tmpDir, "init"); err != nil {
+		return "", false, err
+	}
+	if _, err := b.git(ctx, tmpDir, "remote", "add", "origin", url); err != nil {
+		return "", false, err
+	}
+
+	if out, err := b.git(ctx, tmpDir, "fetch", "origin", branch, "--depth=1"); err != nil {
+		if isMissingBranchError(out) || isMissingBranchError(err.Error()) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	sha, err := b.git(ctx, tmpDir, "rev-parse", "FETCH_HEAD")
+	if err != nil {
+		return "", false, err
+	}
+	if sha = strings.TrimSpace(sha); sha == "" {
+		return "", false, errors.New("resolve fetched index commit: empty sha")
+	}
+
+	if _, err := b.git(ctx, tmpDir, "checkout", "FETCH_HEAD", "--", "."); err != nil {
+		return "", false, err
+	}
+	if err := copyFiles(tmpDir, dstDir); err != nil {
+		return "", false, fmt.Errorf("copy pulled index files: %w", err)
+	}
+	return sha, true, nil
+}
+
+// Publish commits the files in srcDir on a fresh orphan branch and pushes it,
+// with --force-with-lease unless force is set.
+func (b *GitBackend) Publish(ctx context.Context, branch, srcDir, expect string, force bool) (string, error) {
+	url, err := b.remoteURL()
+	if err != nil {
+		return "", err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "triage-state-push-*")
+	if err != nil {
+		return "", fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if _, err := b.git(ctx, tmpDir, "init"); err != nil {
+		return "", err
+	}
+	if _, err := b.git(ctx, tmpDir, "remote", "add", "origin", url); err != nil {
+		return "", err
+	}
+	if _, err := b.git(ctx, tmpDir, "checkout", "--orphan", branch); err != nil {
+		return "", err
+	}
+	_, _ = b.git(ctx, tmpDir, "rm", "-rf", ".") // can fail on empty tree; safe to ignore
+
+	if err := copyFiles(srcDir, tmpDir); err != nil {
+		return "", fmt.Errorf("copy index files for push: %w", err)
+	}
+
+	if _, err := b.git(ctx, tmpDir, "add", "--all"); err != nil {
+		return "", err
+	}
+	if _, err := b.git(ctx, tmpDir, "-c", "user.name=triage-bot", "-c", "user.email=triage-bot@users.noreply.github.com", "commit", "-m", "Update triage index [skip ci]"); err != nil {
+		return "", err
+	}
+
+	lease := "--force"
+	if !force {
+		lease = fmt.Sprintf("--force-with-lease=refs/heads/%s:%s", branch, expect)
+	}
+	if out, err := b.git(ctx, tmpDir, "push", "origin", branch, lease); err != nil {
+		if isStaleLeaseError(out) || isStaleLeaseError(err.Error()) {
+			return "", fmt.Errorf("%w: %v", ErrPushRejected, err)
+		}
+		return "", err
+	}
+
+	sha, err := b.git(ctx, tmpDir, "rev-parse", "HEAD")
+	if err != nil {
+		// The push landed; the next Pull re-reads the lease.
+		return "", nil
+	}
+	return strings.TrimSpace(sha), nil
+}
+
+func isMissingBranchError(raw string) bool {
+	raw = strings.ToLower(raw)
+	return strings.Contains(raw, "couldn't find remote ref") || strings.Contains(raw, "unknown revision")
+}
+
+// isStaleLeaseError spots a push refused because the branch moved: a failed
+// lease reports "stale info", a server-side race a rejected update.
+func isStaleLeaseError(raw string) bool {
+	raw = strings.ToLower(raw)
+	return strings.Contains(raw, "stale info") || strings.Contains(raw, "fetch first") || strings.Contains(raw, "non-fast-forward")
+}
+
+func redactToken(raw, token string) string {
+	if strings.TrimSpace(token) == "" {
+		return raw
+	}
+	return strings.ReplaceAll(raw, token, "***")
+}
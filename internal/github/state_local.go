@@ -0,0 +1,114 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// LocalBackend keeps the index in a directory, for self-hosted runners with
+// persistent disk and for tests. Every version lands in a numbered
+// subdirectory (<Dir>/<name>/<n>) that is staged elsewhere and renamed into
+// place, so readers never see a half-written version and renaming onto a
+// number another run already took fails.
+type LocalBackend struct {
+	Dir string
+}
+
+func (b *LocalBackend) root(name string) (string, error) {
+	if b.Dir == "" {
+		return "", errors.New("directory is required for local state backend")
+	}
+	if filepath.Base(name) != name {
+		return "", fmt.Errorf("invalid index name %q", name)
+	}
+	return filepath.Join(b.Dir, name), nil
+}
+
+// Fetch copies the files of the latest version.
+func (b *LocalBackend) Fetch(ctx context.Context, name, dstDir string) (string, bool, error) {
+	_ = ctx
+	root, err := b.root(name)
+	if err != nil {
+		return "", false, err
+	}
+	gen, found, err := localGeneration(root)
+	if err != nil || !found {
+		return "", false, err
+	}
+	if err := os.MkdirAll(dstDir, 0o755); err != nil {
+		return "", false, err
+	}
+	if err := copyFiles(filepath.Join(root, strconv.Itoa(gen)), dstDir); err != nil {
+		return "", false, fmt.Errorf("copy stored index files: %w", err)
+	}
+	return strconv.Itoa(gen), true, nil
+}
+
+// Publish stores srcDir as the next version and drops all but the previous
+// one, which a concurrent Fetch may still be copying.
+func (b *LocalBackend) Publish(ctx context.Context, name, srcDir, expect string, force bool) (string, error) {
+	_ = ctx
+	root, err := b.root(name)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return "", fmt.Errorf("create index directory: %w", err)
+	}
+	latest, found, err := localGeneration(root)
+	if err != nil {
+		return "", err
+	}
+	gen, err := nextGeneration(latest, found, expect, force)
+	if err != nil {
+		return "", err
+	}
+
+	staging, err := os.MkdirTemp(root, ".staging-*")
+	if err != nil {
+		return "", fmt.Errorf("create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+	if err := copyFiles(srcDir, staging); err != nil {
+		return "", fmt.Errorf("stage index files: %w", err)
+	}
+	if err := os.Rename(staging, filepath.Join(root, strconv.Itoa(gen))); err != nil {
+		if _, statErr := os.Stat(filepath.Join(root, strconv.Itoa(gen))); statErr == nil {
+			return "", fmt.Errorf("%w: version %d was stored by another run", ErrPushRejected, gen)
+		}
+		return "", fmt.Errorf("store index files: %w", err)
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return strconv.Itoa(gen), nil
+	}
+	for _, entry := range entries {
+		if n, err := strconv.Atoi(entry.Name()); err == nil && n < gen-1 {
+			_ = os.RemoveAll(filepath.Join(root, entry.Name()))
+		}
+	}
+	return strconv.Itoa(gen), nil
+}
+
+func localGeneration(root string) (int, bool, error) {
+	entries, err := os.ReadDir(root)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("list index directory: %w", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	gen, found := latestGeneration(names)
+	return gen, found, nil
+}
@@ -0,0 +1,75 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLocalBackend_PullPushAndMergeOnRace(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := filepath.Join(dir, "store")
+	backend := &LocalBackend{Dir: store}
+
+	first := &StateManager{Backend: backend}
+	firstPath := filepath.Join(dir, "first.db")
+	if found, err := first.Pull(ctx, firstPath); err != nil || found {
+		t.Fatalf("Pull() on empty directory = %v, %v; want not found", found, err)
+	}
+	writeIndex(t, firstPath, "v1")
+	if err := first.Push(ctx, firstPath); err != nil {
+		t.Fatalf("first Push() error = %v", err)
+	}
+
+	winner, loser := &StateManager{Backend: backend}, &StateManager{Backend: backend}
+	winnerPath, loserPath := filepath.Join(dir, "winner.db"), filepath.Join(dir, "loser.db")
+	if _, err := winner.Pull(ctx, winnerPath); err != nil {
+		t.Fatalf("winner Pull() error = %v", err)
+	}
+	if _, err := loser.Pull(ctx, loserPath); err != nil {
+		t.Fatalf("loser Pull() error = %v", err)
+	}
+	writeIndex(t, winnerPath, "v1+winner")
+	if err := winner.Push(ctx, winnerPath); err != nil {
+		t.Fatalf("winner Push() error = %v", err)
+	}
+
+	writeIndex(t, loserPath, "v1+loser")
+	if err := loser.Push(ctx, loserPath); !errors.Is(err, ErrPushRejected) {
+		t.Fatalf("loser Push() without Merge error = %v, want ErrPushRejected", err)
+	}
+	loser.Merge = func(ctx context.Context, remotePath string) error {
+		_ = ctx
+		writeIndex(t, loserPath, readIndex(t, remotePath)+"+loser")
+		return nil
+	}
+	if err := loser.Push(ctx, loserPath); err != nil {
+		t.Fatalf("loser Push() error = %v", err)
+	}
+
+	checkPath := filepath.Join(dir, "check.db")
+	if _, err := (&StateManager{Backend: backend}).Pull(ctx, checkPath); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if got := readIndex(t, checkPath); got != "v1+winner+loser" {
+		t.Fatalf("stored index = %q, want both runs' updates", got)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(store, "triage-index"))
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	var versions []string
+	for _, entry := range entries {
+		versions = append(versions, entry.Name())
+	}
+	if !slices.Equal(versions, []string{"2", "3"}) {
+		t.Fatalf("stored versions = %v, want the latest and the one it replaced", versions)
+	}
+}
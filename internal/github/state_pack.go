@@ -0,0 +1,236 @@
+package github
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// DefaultStatePartSize keeps every stored part well under GitHub's 50MB
+	// warning and 100MB hard limit per file.
+	DefaultStatePartSize = 32 << 20
+
+	indexManifestName    = "index.manifest.json"
+	indexPartPrefix      = "index.db.gz."
+	indexManifestVersion = 1
+	indexFormatGzip      = "gzip"
+)
+
+// indexManifest describes how index.db is stored on the branch: gzip
+// compressed, then split into parts that are concatenated in order.
+type indexManifest struct {
+	Version int         `json:"version"`
+	Format  string      `json:"format"`
+	Size    int64       `json:"size"`
+	SHA256  string      `json:"sha256"`
+	Parts   []indexPart `json:"parts"`
+}
+
+type indexPart struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// packIndex writes srcPath into dir as compressed parts of at most partSize
+// bytes plus the manifest that lists them.
+func packIndex(srcPath, dir string, partSize int64) error {
+	if partSize <= 0 {
+		partSize = DefaultStatePartSize
+	}
+	in, err := os.Open(srcPath)
+	if err != nil {
+		return fmt.Errorf("open index file: %w", err)
+	}
+	defer in.Close()
+
+	parts := &partWriter{dir: dir, partSize: partSize}
+	zw := gzip.NewWriter(parts)
+	sum := sha256.New()
+	size, err := io.Copy(zw, io.TeeReader(in, sum))
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := parts.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("compress index file: %w", err)
+	}
+
+	manifest, err := json.MarshalIndent(indexManifest{
+		Version: indexManifestVersion,
+		Format:  indexFormatGzip,
+		Size:    size,
+		SHA256:  hex.EncodeToString(sum.Sum(nil)),
+		Parts:   parts.parts,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode index manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, indexManifestName), manifest, 0o644); err != nil {
+		return fmt.Errorf("write index manifest: %w", err)
+	}
+	return nil
+}
+
+// unpackIndex rebuilds index.db at dstPath from the files Fetch left in dir.
+// Every part and the reassembled file are checked against the manifest.
+// Branches written before the manifest existed hold a plain index.db, which
+// is copied as is.
+func unpackIndex(dir, dstPath string) error {
+	raw, err := os.ReadFile(filepath.Join(dir, indexManifestName))
+	if errors.Is(err, os.ErrNotExist) {
+		legacy := filepath.Join(dir, defaultIndexFileName)
+		if _, err := os.Stat(legacy); err != nil {
+			return fmt.Errorf("pulled index file missing: %w", err)
+		}
+		if err := copyFile(legacy, dstPath); err != nil {
+			return fmt.Errorf("copy pulled index file: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read index manifest: %w", err)
+	}
+
+	var manifest indexManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return fmt.Errorf("decode index manifest: %w", err)
+	}
+	if manifest.Version != indexManifestVersion || manifest.Format != indexFormatGzip {
+		return fmt.Errorf("unsupported index manifest (version %d, format %q)", manifest.Version, manifest.Format)
+	}
+
+	readers := make([]io.Reader, 0, len(manifest.Parts))
+	for _, part := range manifest.Parts {
+		if filepath.Base(part.Name) != part.Name {
+			return fmt.Errorf("invalid index part name %q", part.Name)
+		}
+		f, err := os.Open(filepath.Join(dir, part.Name))
+		if err != nil {
+			return fmt.Errorf("open index part: %w", err)
+		}
+		defer f.Close()
+		if err := checkDigest(f, part.Size, part.SHA256); err != nil {
+			return fmt.Errorf("index part %s: %w", part.Name, err)
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("rewind index part: %w", err)
+		}
+		readers = append(readers, f)
+	}
+
+	zr, err := gzip.NewReader(io.MultiReader(readers...))
+	if err != nil {
+		return fmt.Errorf("decompress index: %w", err)
+	}
+	defer zr.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dstPath), ".triage-index-*")
+	if err != nil {
+		return fmt.Errorf("create index file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	sum := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, sum), zr)
+	if err != nil {
+		return fmt.Errorf("decompress index: %w", err)
+	}
+	if size != manifest.Size || hex.EncodeToString(sum.Sum(nil)) != manifest.SHA256 {
+		return errors.New("reassembled index does not match its manifest checksum")
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), dstPath); err != nil {
+		return fmt.Errorf("replace index file: %w", err)
+	}
+	return nil
+}
+
+func checkDigest(r io.Reader, wantSize int64, wantSHA string) error {
+	sum := sha256.New()
+	size, err := io.Copy(sum, r)
+	if err != nil {
+		return err
+	}
+	if size != wantSize || hex.EncodeToString(sum.Sum(nil)) != wantSHA {
+		return errors.New("checksum mismatch")
+	}
+	return nil
+}
+
+// partWriter spreads a stream over numbered files of at most partSize bytes,
+// recording each one's size and checksum.
+type partWriter struct {
+	dir      string
+	partSize int64
+
+	file  *os.File
+	sum   hash.Hash
+	size  int64
+	parts []indexPart
+}
+
+func (w *partWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if w.file == nil {
+			name := fmt.Sprintf("%s%03d", indexPartPrefix, len(w.parts))
+			f, err := os.Create(filepath.Join(w.dir, name))
+			if err != nil {
+				return written, err
+			}
+			w.file, w.sum, w.size = f, sha256.New(), 0
+			w.parts = append(w.parts, indexPart{Name: name})
+		}
+		n := int(min(int64(len(p)), w.partSize-w.size))
+		if _, err := w.file.Write(p[:n]); err != nil {
+			return written, err
+		}
+		w.sum.Write(p[:n])
+		w.size += int64(n)
+		written += n
+		p = p[n:]
+		if w.size == w.partSize {
+			if err := w.finishPart(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// Close finishes the part being written, if any.
+func (w *partWriter) Close() error {
+	if w.file == nil {
+		return nil
+	}
+	return w.finishPart()
+}
+
+func (w *partWriter) finishPart() error {
+	last := &w.parts[len(w.parts)-1]
+	last.Size = w.size
+	last.SHA256 = hex.EncodeToString(w.sum.Sum(nil))
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
@@ -0,0 +1,114 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPackIndex_SplitsAndReassembles(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "index.db")
+	// Random bytes barely compress, so a small part size forces several parts.
+	content := make([]byte, 10_000)
+	rand.New(rand.NewSource(1)).Read(content)
+	if err := os.WriteFile(src, content, 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	packed := filepath.Join(dir, "packed")
+	if err := os.Mkdir(packed, 0o755); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+	if err := packIndex(src, packed, 4096); err != nil {
+		t.Fatalf("packIndex() error = %v", err)
+	}
+	manifest := readManifest(t, packed)
+	if len(manifest.Parts) != 3 || manifest.Size != int64(len(content)) || manifest.Format != indexFormatGzip {
+		t.Fatalf("manifest = %+v, want 3 gzip parts for %d bytes", manifest, len(content))
+	}
+	for i, part := range manifest.Parts {
+		info, err := os.Stat(filepath.Join(packed, part.Name))
+		if err != nil || info.Size() != part.Size || (i < 2 && part.Size != 4096) {
+			t.Fatalf("part %s: stat = %v, %v; manifest size %d", part.Name, info, err, part.Size)
+		}
+	}
+
+	dst := filepath.Join(dir, "out", "index.db")
+	if err := unpackIndex(packed, dst); err != nil {
+		t.Fatalf("unpackIndex() error = %v", err)
+	}
+	got, err := os.ReadFile(dst)
+	if err != nil || !bytes.Equal(got, content) {
+		t.Fatalf("reassembled index differs (err = %v)", err)
+	}
+}
+
+func TestUnpackIndex_RejectsCorruptPart(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "index.db")
+	if err := os.WriteFile(src, []byte(strings.Repeat("sqlite page ", 500)), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	packed := filepath.Join(dir, "packed")
+	if err := os.Mkdir(packed, 0o755); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+	if err := packIndex(src, packed, 0); err != nil {
+		t.Fatalf("packIndex() error = %v", err)
+	}
+	part := filepath.Join(packed, readManifest(t, packed).Parts[0].Name)
+	raw, err := os.ReadFile(part)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	raw[len(raw)/2] ^= 0xff
+	if err := os.WriteFile(part, raw, 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	dst := filepath.Join(dir, "pulled.db")
+	if err := unpackIndex(packed, dst); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("unpackIndex() error = %v, want checksum mismatch", err)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Fatalf("corrupt index should not be written: %v", err)
+	}
+}
+
+func TestUnpackIndex_ReadsLegacySingleFile(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, defaultIndexFileName), []byte("legacy"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	dst := filepath.Join(t.TempDir(), "index.db")
+	if err := unpackIndex(dir, dst); err != nil {
+		t.Fatalf("unpackIndex() error = %v", err)
+	}
+	if got, err := os.ReadFile(dst); err != nil || string(got) != "legacy" {
+		t.Fatalf("legacy index = %q, %v", got, err)
+	}
+}
+
+func readManifest(t *testing.T, dir string) indexManifest {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join(dir, indexManifestName))
+	if err != nil {
+		t.Fatalf("ReadFile(manifest) error = %v", err)
+	}
+	var manifest indexManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		t.Fatalf("Unmarshal(manifest) error = %v", err)
+	}
+	return manifest
+}
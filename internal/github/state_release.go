@@ -0,0 +1,255 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	gh "github.com/google/go-github/v67/github"
+)
+
+// ReleaseBackend keeps the index as assets of a prerelease on its own tag,
+// for repositories where bots may not push branches. Each version uploads
+// its files as "<n>.<file>" with the manifest last, so a version only counts
+// once it is complete. Assets cannot be swapped atomically, so Publish
+// uploads under a fresh number and then looks for a rival version completed
+// since the lease; if one exists it withdraws its own upload and reports
+// ErrPushRejected.
+type ReleaseBackend struct {
+	Client *Client
+	Owner  string
+	Repo   string
+}
+
+func (b *ReleaseBackend) api() (*gh.Client, error) {
+	if b.Client == nil || b.Client.api == nil {
+		return nil, errors.New("github client is required for state manager")
+	}
+	if strings.TrimSpace(b.Owner) == "" || strings.TrimSpace(b.Repo) == "" {
+		return nil, errors.New("owner/repo is required for state manager")
+	}
+	return b.Client.api, nil
+}
+
+// Fetch downloads the assets of the latest complete version.
+func (b *ReleaseBackend) Fetch(ctx context.Context, name, dstDir string) (string, bool, error) {
+	api, err := b.api()
+	if err != nil {
+		return "", false, err
+	}
+	release, _, err := api.Repositories.GetReleaseByTag(ctx, b.Owner, b.Repo, name)
+	if hasStatus(err, http.StatusNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("get index release: %w", err)
+	}
+	assets, err := b.listAssets(ctx, api, release.GetID())
+	if err != nil {
+		return "", false, err
+	}
+	gen, found := releaseGeneration(assets)
+	if !found {
+		return "", false, nil
+	}
+
+	if err := os.MkdirAll(dstDir, 0o755); err != nil {
+		return "", false, err
+	}
+	prefix := strconv.Itoa(gen) + "."
+	for _, asset := range assets {
+		file, ok := strings.CutPrefix(asset.GetName(), prefix)
+		if !ok || filepath.Base(file) != file {
+			continue
+		}
+		if err := b.download(ctx, api, asset.GetID(), filepath.Join(dstDir, file)); err != nil {
+			return "", false, fmt.Errorf("download %s: %w", asset.GetName(), err)
+		}
+	}
+	return strconv.Itoa(gen), true, nil
+}
+
+// Publish uploads srcDir as the next version, creating the release on first
+// use, then deletes older versions except the one it replaced.
+func (b *ReleaseBackend) Publish(ctx context.Context, name, srcDir, expect string, force bool) (string, error) {
+	api, err := b.api()
+	if err != nil {
+		return "", err
+	}
+	release, err := b.ensureRelease(ctx, api, name)
+	if err != nil {
+		return "", err
+	}
+	assets, err := b.listAssets(ctx, api, release.GetID())
+	if err != nil {
+		return "", err
+	}
+	latest, found := releaseGeneration(assets)
+	if _, err := nextGeneration(latest, found, expect, force); err != nil {
+		return "", err
+	}
+	// Skip numbers left behind by uploads that never completed.
+	gen := 1
+	for _, asset := range assets {
+		if n, ok := assetGeneration(asset.GetName()); ok && n >= gen {
+			gen = n + 1
+		}
+	}
+
+	files, err := os.ReadDir(srcDir)
+	if err != nil {
+		return "", fmt.Errorf("list index files for push: %w", err)
+	}
+	var names []string
+	for _, file := range files {
+		if file.Type().IsRegular() && file.Name() != indexManifestName {
+			names = append(names, file.Name())
+		}
+	}
+	slices.Sort(names)
+	names = append(names, indexManifestName)
+
+	var uploaded []int64
+	withdraw := func() {
+		for _, id := range uploaded {
+			_, _ = api.Repositories.DeleteReleaseAsset(ctx, b.Owner, b.Repo, id)
+		}
+	}
+	for _, file := range names {
+		id, err := b.upload(ctx, api, release.GetID(), fmt.Sprintf("%d.%s", gen, file), filepath.Join(srcDir, file))
+		if err != nil {
+			withdraw()
+			if hasStatus(err, http.StatusUnprocessableEntity) {
+				return "", fmt.Errorf("%w: version %d was taken by another run", ErrPushRejected, gen)
+			}
+			return "", fmt.Errorf("upload %s: %w", file, err)
+		}
+		uploaded = append(uploaded, id)
+	}
+
+	if !force {
+		// Two runs racing under different numbers both completed only if
+		// each checks after its own manifest landed; the later check always
+		// sees the other, so at most one of them keeps its version.
+		after, err := b.listAssets(ctx, api, release.GetID())
+		if err != nil {
+			withdraw()
+			return "", err
+		}
+		since, _ := strconv.Atoi(expect)
+		for _, asset := range after {
+			n, ok := strings.CutSuffix(asset.GetName(), "."+indexManifestName)
+			if rival, err := strconv.Atoi(n); ok && err == nil && rival > since && rival != gen {
+				withdraw()
+				return "", fmt.Errorf("%w: version %d was stored by another run", ErrPushRejected, rival)
+			}
+		}
+	}
+
+	// Keep the version this one replaced; a concurrent Fetch may be reading it.
+	for _, asset := range assets {
+		if n, ok := assetGeneration(asset.GetName()); ok && n < gen && n != latest {
+			_, _ = api.Repositories.DeleteReleaseAsset(ctx, b.Owner, b.Repo, asset.GetID())
+		}
+	}
+	return strconv.Itoa(gen), nil
+}
+
+func (b *ReleaseBackend) ensureRelease(ctx context.Context, api *gh.Client, tag string) (*gh.RepositoryRelease, error) {
+	release, _, err := api.Repositories.GetReleaseByTag(ctx, b.Owner, b.Repo, tag)
+	if err == nil {
+		return release, nil
+	}
+	if !hasStatus(err, http.StatusNotFound) {
+		return nil, fmt.Errorf("get index release: %w", err)
+	}
+	release, _, err = api.Repositories.CreateRelease(ctx, b.Owner, b.Repo, &gh.RepositoryRelease{
+		TagName:    gh.String(tag),
+		Name:       gh.String("Triage index"),
+		Body:       gh.String("Storage for the triage bot's index. Managed automatically; do not edit."),
+		Prerelease: gh.Bool(true),
+		MakeLatest: gh.String("false"),
+	})
+	if hasStatus(err, http.StatusUnprocessableEntity) {
+		// Another run created it first.
+		release, _, err = api.Repositories.GetReleaseByTag(ctx, b.Owner, b.Repo, tag)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create index release: %w", err)
+	}
+	return release, nil
+}
+
+func (b *ReleaseBackend) listAssets(ctx context.Context, api *gh.Client, releaseID int64) ([]*gh.ReleaseAsset, error) {
+	opt := &gh.ListOptions{PerPage: 100}
+	var out []*gh.ReleaseAsset
+	for {
+		assets, resp, err := api.Repositories.ListReleaseAssets(ctx, b.Owner, b.Repo, releaseID, opt)
+		if err != nil {
+			return nil, fmt.Errorf("list index assets: %w", err)
+		}
+		out = append(out, assets...)
+		if resp == nil || resp.NextPage == 0 {
+			return out, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+func (b *ReleaseBackend) upload(ctx context.Context, api *gh.Client, releaseID int64, name, path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	asset, _, err := api.Repositories.UploadReleaseAsset(ctx, b.Owner, b.Repo, releaseID, &gh.UploadOptions{Name: name}, f)
+	if err != nil {
+		return 0, err
+	}
+	return asset.GetID(), nil
+}
+
+func (b *ReleaseBackend) download(ctx context.Context, api *gh.Client, assetID int64, dst string) error {
+	// Assets redirect to storage that must not see the token.
+	rc, _, err := api.Repositories.DownloadReleaseAsset(ctx, b.Owner, b.Repo, assetID, http.DefaultClient)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// releaseGeneration is the latest version whose manifest was uploaded.
+func releaseGeneration(assets []*gh.ReleaseAsset) (int, bool) {
+	var names []string
+	for _, asset := range assets {
+		if gen, ok := strings.CutSuffix(asset.GetName(), "."+indexManifestName); ok {
+			names = append(names, gen)
+		}
+	}
+	return latestGeneration(names)
+}
+
+func assetGeneration(name string) (int, bool) {
+	gen, _, ok := strings.Cut(name, ".")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(gen)
+	return n, err == nil
+}
@@ -0,0 +1,186 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	gh "github.com/google/go-github/v67/github"
+)
+
+func TestReleaseBackend_PushWithdrawsWhenRivalCompletes(t *testing.T) {
+	t.Helper()
+
+	fake := &fakeReleases{assets: map[int64]fakeAsset{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	api := gh.NewClient(nil)
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	api.BaseURL, api.UploadURL = baseURL, baseURL
+	backend := &ReleaseBackend{Client: NewClientFromGoGitHub(api), Owner: "acme", Repo: "repo"}
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	first := &StateManager{Backend: backend}
+	firstPath := filepath.Join(dir, "first.db")
+	if found, err := first.Pull(ctx, firstPath); err != nil || found {
+		t.Fatalf("Pull() without release = %v, %v; want not found", found, err)
+	}
+	writeIndex(t, firstPath, "v1")
+	if err := first.Push(ctx, firstPath); err != nil {
+		t.Fatalf("first Push() error = %v", err)
+	}
+	if !fake.created {
+		t.Fatalf("expected the index release to be created")
+	}
+
+	// While the second run uploads version 2, a rival finishes version 3.
+	local := filepath.Join(dir, "local.db")
+	manager := &StateManager{Backend: backend}
+	if _, err := manager.Pull(ctx, local); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	fake.onUpload = func(name string) {
+		if name == "2."+indexManifestName {
+			fake.copyVersion(1, 3)
+			fake.onUpload = nil
+		}
+	}
+	var merged []string
+	manager.Merge = func(ctx context.Context, remotePath string) error {
+		_ = ctx
+		merged = append(merged, readIndex(t, remotePath))
+		writeIndex(t, local, "v1+rival+local")
+		return nil
+	}
+	writeIndex(t, local, "v1+local")
+	if err := manager.Push(ctx, local); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if len(merged) != 1 || merged[0] != "v1" {
+		t.Fatalf("merged = %v, want the rival's index once", merged)
+	}
+
+	checkPath := filepath.Join(dir, "check.db")
+	if _, err := (&StateManager{Backend: backend}).Pull(ctx, checkPath); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if got := readIndex(t, checkPath); got != "v1+rival+local" {
+		t.Fatalf("stored index = %q, want the merged retry", got)
+	}
+	if versions := fake.versions(); !slices.Equal(versions, []int{3, 4}) {
+		t.Fatalf("stored versions = %v, want the withdrawn and replaced ones gone", versions)
+	}
+}
+
+type fakeAsset struct {
+	name    string
+	content []byte
+}
+
+// fakeReleases serves one release and its assets the way ReleaseBackend
+// uses them.
+type fakeReleases struct {
+	mu       sync.Mutex
+	created  bool
+	nextID   int64
+	assets   map[int64]fakeAsset
+	onUpload func(name string)
+}
+
+func (f *fakeReleases) copyVersion(from, to int) {
+	for _, asset := range f.assets {
+		if rest, ok := strings.CutPrefix(asset.name, strconv.Itoa(from)+"."); ok {
+			f.nextID++
+			f.assets[f.nextID] = fakeAsset{name: fmt.Sprintf("%d.%s", to, rest), content: asset.content}
+		}
+	}
+}
+
+func (f *fakeReleases) versions() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []int
+	for _, asset := range f.assets {
+		if n, ok := assetGeneration(asset.name); ok && !slices.Contains(out, n) {
+			out = append(out, n)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+func (f *fakeReleases) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	reply := func(status int, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(v)
+	}
+	release := map[string]any{"id": 7, "tag_name": "triage-index"}
+	path := strings.TrimPrefix(r.URL.Path, "/repos/acme/repo/releases")
+
+	switch {
+	case r.Method == http.MethodGet && path == "/tags/triage-index":
+		if !f.created {
+			reply(http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		reply(http.StatusOK, release)
+	case r.Method == http.MethodPost && path == "":
+		f.created = true
+		reply(http.StatusCreated, release)
+	case r.Method == http.MethodGet && path == "/7/assets":
+		var out []map[string]any
+		for id, asset := range f.assets {
+			out = append(out, map[string]any{"id": id, "name": asset.name})
+		}
+		reply(http.StatusOK, out)
+	case r.Method == http.MethodPost && path == "/7/assets":
+		name := r.URL.Query().Get("name")
+		for _, asset := range f.assets {
+			if asset.name == name {
+				reply(http.StatusUnprocessableEntity, map[string]string{"message": "already_exists"})
+				return
+			}
+		}
+		if f.onUpload != nil {
+			f.onUpload(name)
+		}
+		content, _ := io.ReadAll(r.Body)
+		f.nextID++
+		f.assets[f.nextID] = fakeAsset{name: name, content: content}
+		reply(http.StatusCreated, map[string]any{"id": f.nextID, "name": name})
+	case strings.HasPrefix(path, "/assets/"):
+		id, _ := strconv.ParseInt(strings.TrimPrefix(path, "/assets/"), 10, 64)
+		asset, ok := f.assets[id]
+		if !ok {
+			reply(http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(f.assets, id)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(asset.content)
+	default:
+		reply(http.StatusNotFound, map[string]string{"message": "Not Found"})
+	}
+}
@@ -0,0 +1,184 @@
+package github
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gh "github.com/google/go-github/v67/github"
+)
+
+// RESTBackend stores the index with the Git Data API (blobs, trees, commits
+// and refs), so no git binary is needed and the token only travels in the
+// Authorization header. The API's only compare-and-swap for refs is the
+// fast-forward check, which would keep every index snapshot in the branch
+// history. Publish instead re-reads the branch just before force-moving it to
+// a fresh orphan commit and re-reads it again afterwards, which narrows a
+// concurrent push to a window of one request but cannot close it; the git
+// transport, the default, has a real lease.
+type RESTBackend struct {
+	Client *Client
+	Owner  string
+	Repo   string
+}
+
+func (b *RESTBackend) api() (*gh.Client, error) {
+	if b.Client == nil || b.Client.api == nil {
+		return nil, errors.New("github client is required for state manager")
+	}
+	if strings.TrimSpace(b.Owner) == "" || strings.TrimSpace(b.Repo) == "" {
+		return nil, errors.New("owner/repo is required for state manager")
+	}
+	return b.Client.api, nil
+}
+
+// Fetch downloads every file in the tree of the branch tip.
+func (b *RESTBackend) Fetch(ctx context.Context, branch, dstDir string) (string, bool, error) {
+	api, err := b.api()
+	if err != nil {
+		return "", false, err
+	}
+
+	commitSHA, found, err := b.branchHead(ctx, api, branch)
+	if err != nil || !found {
+		return "", false, err
+	}
+	commit, _, err := api.Git.GetCommit(ctx, b.Owner, b.Repo, commitSHA)
+	if err != nil {
+		return "", false, fmt.Errorf("get index commit: %w", err)
+	}
+	tree, _, err := api.Git.GetTree(ctx, b.Owner, b.Repo, commit.GetTree().GetSHA(), false)
+	if err != nil {
+		return "", false, fmt.Errorf("get index tree: %w", err)
+	}
+	if err := os.MkdirAll(dstDir, 0o755); err != nil {
+		return "", false, err
+	}
+	for _, entry := range tree.Entries {
+		name := entry.GetPath()
+		if entry.GetType() != "blob" || filepath.Base(name) != name {
+			continue
+		}
+		content, _, err := api.Git.GetBlobRaw(ctx, b.Owner, b.Repo, entry.GetSHA())
+		if err != nil {
+			return "", false, fmt.Errorf("download %s: %w", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(dstDir, name), content, 0o644); err != nil {
+			return "", false, fmt.Errorf("copy pulled index file: %w", err)
+		}
+	}
+	return commitSHA, true, nil
+}
+
+// Publish uploads each file in srcDir as a blob, wraps them in a parentless
+// commit and points the branch at it, so the branch only ever holds the
+// latest index.
+func (b *RESTBackend) Publish(ctx context.Context, branch, srcDir, expect string, force bool) (string, error) {
+	api, err := b.api()
+	if err != nil {
+		return "", err
+	}
+
+	files, err := os.ReadDir(srcDir)
+	if err != nil {
+		return "", fmt.Errorf("list index files for push: %w", err)
+	}
+	var entries []*gh.TreeEntry
+	for _, file := range files {
+		if !file.Type().IsRegular() {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(srcDir, file.Name()))
+		if err != nil {
+			return "", fmt.Errorf("read index file for push: %w", err)
+		}
+		blob, _, err := api.Git.CreateBlob(ctx, b.Owner, b.Repo, &gh.Blob{
+			Content:  gh.String(base64.StdEncoding.EncodeToString(content)),
+			Encoding: gh.String("base64"),
+		})
+		if err != nil {
+			return "", fmt.Errorf("create blob for %s: %w", file.Name(), err)
+		}
+		entries = append(entries, &gh.TreeEntry{
+			Path: gh.String(file.Name()),
+			Mode: gh.String("100644"),
+			Type: gh.String("blob"),
+			SHA:  blob.SHA,
+		})
+	}
+	tree, _, err := api.Git.CreateTree(ctx, b.Owner, b.Repo, "", entries)
+	if err != nil {
+		return "", fmt.Errorf("create index tree: %w", err)
+	}
+	commit, _, err := api.Git.CreateCommit(ctx, b.Owner, b.Repo, &gh.Commit{
+		Message: gh.String("Update triage index [skip ci]"),
+		Tree:    &gh.Tree{SHA: tree.SHA},
+		Author:  &gh.CommitAuthor{Name: gh.String("triage-bot"), Email: gh.String("triage-bot@users.noreply.github.com")},
+	}, nil)
+	if err != nil {
+		return "", fmt.Errorf("create index commit: %w", err)
+	}
+
+	current, exists, err := b.branchHead(ctx, api, branch)
+	if err != nil {
+		return "", err
+	}
+	if !force && current != expect {
+		return "", fmt.Errorf("%w: branch %s is at %q, expected %q", ErrPushRejected, branch, current, expect)
+	}
+
+	ref := &gh.Reference{Ref: gh.String("refs/heads/" + branch), Object: &gh.GitObject{SHA: commit.SHA}}
+	if exists {
+		_, _, err = api.Git.UpdateRef(ctx, b.Owner, b.Repo, ref, true)
+	} else {
+		// Creating a ref that already exists fails, so this case is atomic.
+		_, _, err = api.Git.CreateRef(ctx, b.Owner, b.Repo, ref)
+	}
+	if err != nil {
+		// 422 means the branch appeared or vanished since it was read.
+		if !force && hasStatus(err, http.StatusUnprocessableEntity) {
+			return "", fmt.Errorf("%w: %v", ErrPushRejected, err)
+		}
+		return "", fmt.Errorf("update index branch: %w", err)
+	}
+
+	// A run that moved the branch right after this one replaced this index;
+	// reporting a rejection makes the manager merge it and push again.
+	if !force {
+		landed, _, err := b.branchHead(ctx, api, branch)
+		if err != nil {
+			return "", err
+		}
+		if landed != commit.GetSHA() {
+			return "", fmt.Errorf("%w: branch %s moved to %q right after this push", ErrPushRejected, branch, landed)
+		}
+	}
+	return commit.GetSHA(), nil
+}
+
+// branchHead returns the commit the branch points at; found=false when the
+// branch (or the whole repository) is still empty.
+func (b *RESTBackend) branchHead(ctx context.Context, api *gh.Client, branch string) (string, bool, error) {
+	ref, _, err := api.Git.GetRef(ctx, b.Owner, b.Repo, "heads/"+branch)
+	if err != nil {
+		if hasStatus(err, http.StatusNotFound) || hasStatus(err, http.StatusConflict) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("get index branch: %w", err)
+	}
+	sha := ref.GetObject().GetSHA()
+	if sha == "" {
+		return "", false, errors.New("resolve index branch: empty sha")
+	}
+	return sha, true, nil
+}
+
+func hasStatus(err error, status int) bool {
+	var errResp *gh.ErrorResponse
+	return errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == status
+}
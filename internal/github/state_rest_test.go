@@ -0,0 +1,376 @@
+package github
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	gh "github.com/google/go-github/v67/github"
+)
+
+func TestRESTBackend_PullPushAndMergeOnRace(t *testing.T) {
+	t.Helper()
+
+	fake := newFakeGitData()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	backend := &RESTBackend{Client: newFakeGitDataClient(t, server.URL), Owner: "acme", Repo: "repo"}
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	first := &StateManager{Backend: backend}
+	firstPath := filepath.Join(dir, "first.db")
+	found, err := first.Pull(ctx, firstPath)
+	if err != nil || found {
+		t.Fatalf("Pull() on empty repo = %v, %v; want not found", found, err)
+	}
+	writeIndex(t, firstPath, "v1")
+	if err := first.Push(ctx, firstPath); err != nil {
+		t.Fatalf("first Push() error = %v", err)
+	}
+
+	// Two runs start from v1; the first to push wins, the second must merge.
+	winner := &StateManager{Backend: backend}
+	loser := &StateManager{Backend: backend}
+	winnerPath, loserPath := filepath.Join(dir, "winner.db"), filepath.Join(dir, "loser.db")
+	for _, pull := range []struct {
+		m    *StateManager
+		path string
+	}{{winner, winnerPath}, {loser, loserPath}} {
+		found, err := pull.m.Pull(ctx, pull.path)
+		if err != nil || !found {
+			t.Fatalf("Pull() = %v, %v; want found", found, err)
+		}
+		if got := readIndex(t, pull.path); got != "v1" {
+			t.Fatalf("pulled index = %q, want v1", got)
+		}
+	}
+	writeIndex(t, winnerPath, "v1+winner")
+	if err := winner.Push(ctx, winnerPath); err != nil {
+		t.Fatalf("winner Push() error = %v", err)
+	}
+
+	var merged []string
+	loser.Merge = func(ctx context.Context, remotePath string) error {
+		_ = ctx
+		remote := readIndex(t, remotePath)
+		merged = append(merged, remote)
+		writeIndex(t, loserPath, remote+"+loser")
+		return nil
+	}
+	writeIndex(t, loserPath, "v1+loser")
+	if err := loser.Push(ctx, loserPath); err != nil {
+		t.Fatalf("loser Push() error = %v", err)
+	}
+	if len(merged) != 1 || merged[0] != "v1+winner" {
+		t.Fatalf("merged = %v, want the winner's index once", merged)
+	}
+	checkPath := filepath.Join(dir, "check.db")
+	if _, err := (&StateManager{Backend: backend}).Pull(ctx, checkPath); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if got := readIndex(t, checkPath); got != "v1+winner+loser" {
+		t.Fatalf("branch content = %q, want both runs' updates", got)
+	}
+	if files := fake.branchFiles("triage-index"); !slices.Equal(files, []string{"index.db.gz.000", "index.manifest.json"}) {
+		t.Fatalf("branch files = %v, want a compressed part and its manifest", files)
+	}
+	if got := fake.commitCount("triage-index"); got != 1 {
+		t.Fatalf("branch has %d commits, want a single orphan commit", got)
+	}
+
+	// Without Merge the rejection is returned as is.
+	stale := &StateManager{Backend: backend, lease: "deadbeef", pulled: true}
+	if err := stale.Push(ctx, loserPath); !errors.Is(err, ErrPushRejected) {
+		t.Fatalf("stale Push() error = %v, want ErrPushRejected", err)
+	}
+}
+
+func TestRESTBackend_PublishKeepsOneOrphanCommit(t *testing.T) {
+	t.Helper()
+
+	fake := newFakeGitData()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	backend := &RESTBackend{Client: newFakeGitDataClient(t, server.URL), Owner: "acme", Repo: "repo"}
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	expect := ""
+	for i := 1; i <= 5; i++ {
+		writeIndex(t, filepath.Join(dir, "index.db"), fmt.Sprintf("v%d", i))
+		version, err := backend.Publish(ctx, "triage-index", dir, expect, false)
+		if err != nil {
+			t.Fatalf("Publish(%d) error = %v", i, err)
+		}
+		if got := fake.commitCount("triage-index"); got != 1 {
+			t.Fatalf("after %d publishes the branch has %d commits, want 1", i, got)
+		}
+		expect = version
+	}
+
+	if _, err := backend.Publish(ctx, "triage-index", dir, "", false); !errors.Is(err, ErrPushRejected) {
+		t.Fatalf("Publish() onto an existing branch error = %v, want ErrPushRejected", err)
+	}
+	if _, err := backend.Publish(ctx, "triage-index", dir, "deadbeef", false); !errors.Is(err, ErrPushRejected) {
+		t.Fatalf("Publish() with a stale lease error = %v, want ErrPushRejected", err)
+	}
+
+	// Another run moves the branch right after this push; this run must
+	// notice and merge instead of assuming its index is stored.
+	fake.afterRefUpdate = func() {
+		other := fake.sha("commit")
+		fake.commits[other] = fake.commits[expect]
+		fake.refs["refs/heads/triage-index"] = other
+	}
+	if _, err := backend.Publish(ctx, "triage-index", dir, expect, false); !errors.Is(err, ErrPushRejected) {
+		t.Fatalf("Publish() overtaken by another run error = %v, want ErrPushRejected", err)
+	}
+	fake.afterRefUpdate = nil
+}
+
+func TestNewStateBackend(t *testing.T) {
+	t.Helper()
+
+	base := StateBackendConfig{Owner: "acme", Repo: "repo", Token: "tkn"}
+	for name, want := range map[string]string{
+		"":              "*github.GitBackend",
+		"branch":        "*github.GitBackend",
+		"release":       "*github.ReleaseBackend",
+		"actions-cache": "*github.ActionsCacheBackend",
+		"local":         "*github.LocalBackend",
+	} {
+		cfg := base
+		cfg.Directory, cfg.CacheURL, cfg.CacheToken = t.TempDir(), "https://results.example/", "rt"
+		backend, err := NewStateBackend(name, cfg)
+		if err != nil || fmt.Sprintf("%T", backend) != want {
+			t.Fatalf("NewStateBackend(%q) = %T, %v; want %s", name, backend, err, want)
+		}
+	}
+	rest := base
+	rest.Transport = BranchTransportREST
+	if backend, err := NewStateBackend(StateBackendBranch, rest); err != nil {
+		t.Fatalf("NewStateBackend(branch over rest) error = %v", err)
+	} else if _, ok := backend.(*RESTBackend); !ok {
+		t.Fatalf("rest transport backend = %T, want *RESTBackend", backend)
+	}
+	for _, name := range []string{"svn", StateBackendLocal, StateBackendActionsCache} {
+		if _, err := NewStateBackend(name, base); err == nil {
+			t.Fatalf("expected error for %q without its settings", name)
+		}
+	}
+}
+
+func newFakeGitDataClient(t *testing.T, serverURL string) *Client {
+	t.Helper()
+	api := gh.NewClient(nil)
+	baseURL, err := url.Parse(serverURL + "/")
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	api.BaseURL = baseURL
+	return NewClientFromGoGitHub(api)
+}
+
+func writeIndex(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func readIndex(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	return string(content)
+}
+
+// fakeGitData serves the slice of the Git Data API that RESTBackend uses.
+type fakeGitData struct {
+	mu      sync.Mutex
+	next    int
+	refs    map[string]string
+	blobs   map[string][]byte
+	trees   map[string]map[string]string
+	commits map[string]string
+	parents map[string]string
+	// afterRefUpdate, when set, runs after a branch is moved, to simulate a
+	// concurrent push.
+	afterRefUpdate func()
+}
+
+func newFakeGitData() *fakeGitData {
+	return &fakeGitData{
+		refs:    map[string]string{},
+		blobs:   map[string][]byte{},
+		trees:   map[string]map[string]string{},
+		commits: map[string]string{},
+		parents: map[string]string{},
+	}
+}
+
+func (f *fakeGitData) sha(kind string) string {
+	f.next++
+	return fmt.Sprintf("%s%d", kind, f.next)
+}
+
+func (f *fakeGitData) branchFiles(branch string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var names []string
+	for name := range f.trees[f.commits[f.refs["refs/heads/"+branch]]] {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// commitCount is the length of the branch's first-parent history.
+func (f *fakeGitData) commitCount(branch string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for commit := f.refs["refs/heads/"+branch]; commit != ""; commit = f.parents[commit] {
+		n++
+	}
+	return n
+}
+
+// descends reports whether commit has ancestor in its first-parent chain.
+func (f *fakeGitData) descends(commit, ancestor string) bool {
+	for ; commit != ""; commit = f.parents[commit] {
+		if commit == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeGitData) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/repos/acme/repo/git/")
+	var body map[string]any
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}
+	reply := func(status int, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(v)
+	}
+	notFound := func() { reply(http.StatusNotFound, map[string]string{"message": "Not Found"}) }
+
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "ref/"):
+		ref := "refs/" + strings.TrimPrefix(path, "ref/")
+		sha, ok := f.refs[ref]
+		if !ok {
+			notFound()
+			return
+		}
+		reply(http.StatusOK, map[string]any{"ref": ref, "object": map[string]string{"sha": sha, "type": "commit"}})
+	case r.Method == http.MethodPost && path == "refs":
+		ref, sha := body["ref"].(string), body["sha"].(string)
+		if _, ok := f.refs[ref]; ok {
+			reply(http.StatusUnprocessableEntity, map[string]string{"message": "Reference already exists"})
+			return
+		}
+		f.refs[ref] = sha
+		reply(http.StatusCreated, map[string]any{"ref": ref, "object": map[string]string{"sha": sha}})
+	case r.Method == http.MethodPatch && strings.HasPrefix(path, "refs/"):
+		ref := path
+		current, ok := f.refs[ref]
+		if !ok {
+			reply(http.StatusUnprocessableEntity, map[string]string{"message": "Reference does not exist"})
+			return
+		}
+		if force, _ := body["force"].(bool); !force && !f.descends(body["sha"].(string), current) {
+			reply(http.StatusUnprocessableEntity, map[string]string{"message": "Update is not a fast forward"})
+			return
+		}
+		f.refs[ref] = body["sha"].(string)
+		if f.afterRefUpdate != nil {
+			f.afterRefUpdate()
+		}
+		reply(http.StatusOK, map[string]any{"ref": ref, "object": map[string]string{"sha": f.refs[ref]}})
+	case r.Method == http.MethodPost && path == "blobs":
+		content, err := base64.StdEncoding.DecodeString(body["content"].(string))
+		if err != nil || body["encoding"] != "base64" {
+			reply(http.StatusBadRequest, map[string]string{"message": "bad blob"})
+			return
+		}
+		sha := f.sha("blob")
+		f.blobs[sha] = content
+		reply(http.StatusCreated, map[string]string{"sha": sha})
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "blobs/"):
+		content, ok := f.blobs[strings.TrimPrefix(path, "blobs/")]
+		if !ok || r.Header.Get("Accept") != "application/vnd.github.v3.raw" {
+			notFound()
+			return
+		}
+		_, _ = w.Write(content)
+	case r.Method == http.MethodPost && path == "trees":
+		entries := map[string]string{}
+		for _, raw := range body["tree"].([]any) {
+			entry := raw.(map[string]any)
+			entries[entry["path"].(string)] = entry["sha"].(string)
+		}
+		sha := f.sha("tree")
+		f.trees[sha] = entries
+		reply(http.StatusCreated, map[string]string{"sha": sha})
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "trees/"):
+		sha := strings.TrimPrefix(path, "trees/")
+		entries, ok := f.trees[sha]
+		if !ok {
+			notFound()
+			return
+		}
+		var out []map[string]string
+		for name, blob := range entries {
+			out = append(out, map[string]string{"path": name, "type": "blob", "mode": "100644", "sha": blob})
+		}
+		reply(http.StatusOK, map[string]any{"sha": sha, "tree": out})
+	case r.Method == http.MethodPost && path == "commits":
+		parents, _ := body["parents"].([]any)
+		if len(parents) > 1 {
+			reply(http.StatusBadRequest, map[string]string{"message": "index commits have at most one parent"})
+			return
+		}
+		sha := f.sha("commit")
+		for _, parent := range parents {
+			if _, ok := f.commits[parent.(string)]; !ok {
+				reply(http.StatusUnprocessableEntity, map[string]string{"message": "Parent SHA does not exist"})
+				return
+			}
+			f.parents[sha] = parent.(string)
+		}
+		f.commits[sha] = body["tree"].(string)
+		reply(http.StatusCreated, map[string]any{"sha": sha, "tree": map[string]string{"sha": f.commits[sha]}})
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "commits/"):
+		sha := strings.TrimPrefix(path, "commits/")
+		tree, ok := f.commits[sha]
+		if !ok {
+			notFound()
+			return
+		}
+		reply(http.StatusOK, map[string]any{"sha": sha, "tree": map[string]string{"sha": tree}})
+	default:
+		notFound()
+	}
+}
@@ -155,3 +155,20 @@ func TestBuildPRContent_EmptyTitleBodyRules(t *testing.T) {
 		t.Fatalf("empty result = %q", empty)
 	}
 }
+
+func TestContentHash(t *testing.T) {
+	t.Helper()
+
+	a := ContentHash("Issue: Login timeout\n\nhangs")
+	b := ContentHash("Issue: Login timeout\n\nhangs")
+	c := ContentHash("Issue: Login timeout\n\ncrashes")
+	if a == "" || a != b {
+		t.Fatalf("ContentHash() not stable: %q vs %q", a, b)
+	}
+	if a == c {
+		t.Fatalf("ContentHash() did not change with content")
+	}
+	if got := ContentHash("  "); got != "" {
+		t.Fatalf("ContentHash(blank) = %q, want empty", got)
+	}
+}
@@ -0,0 +1,167 @@
+package rerank
+
+import (
+	"context"
+	"math"
+	"strings"
+	"unicode"
+)
+
+var heuristicStopWords = map[string]struct{}{
+	"a": {}, "an": {}, "and": {}, "are": {}, "as": {}, "at": {}, "be": {}, "but": {}, "by": {}, "can": {},
+	"do": {}, "does": {}, "for": {}, "from": {}, "has": {}, "have": {}, "i": {}, "if": {}, "in": {}, "is": {},
+	"it": {}, "its": {}, "not": {}, "of": {}, "on": {}, "or": {}, "so": {}, "that": {}, "the": {}, "this": {},
+	"to": {}, "was": {}, "we": {}, "when": {}, "with": {}, "will": {}, "would": {}, "you": {},
+}
+
+// Heuristic is the offline default reranker. It looks for lexical evidence
+// that two items describe the same problem: overlapping title terms, similar
+// body wording, and shared code-like tokens such as identifiers, paths and
+// error codes. The three signals are combined as a noisy-OR, so one strong
+// signal is enough but topic words shared by a whole component are not.
+type Heuristic struct{}
+
+func (Heuristic) Name() string { return NameHeuristic }
+
+func (Heuristic) Rerank(ctx context.Context, query Item, candidates []Item) ([]float64, error) {
+	_ = ctx
+	q := newTextProfile(query)
+	scores := make([]float64, len(candidates))
+	for i, candidate := range candidates {
+		scores[i] = q.agreement(newTextProfile(candidate))
+	}
+	return scores, nil
+}
+
+type textProfile struct {
+	title   map[string]struct{}
+	body    map[string]float64
+	signals map[string]struct{}
+}
+
+func newTextProfile(item Item) textProfile {
+	p := textProfile{
+		title:   map[string]struct{}{},
+		body:    map[string]float64{},
+		signals: map[string]struct{}{},
+	}
+	for _, term := range heuristicTerms(item.Title) {
+		p.title[term] = struct{}{}
+	}
+	for _, term := range heuristicTerms(item.Body) {
+		p.body[term]++
+	}
+	for _, text := range []string{item.Title, item.Body} {
+		for _, field := range strings.Fields(text) {
+			if signal, ok := codeSignal(field); ok {
+				p.signals[signal] = struct{}{}
+			}
+		}
+	}
+	return p
+}
+
+func (p textProfile) agreement(other textProfile) float64 {
+	title := jaccard(p.title, other.title)
+	body := cosine(p.body, other.body)
+	signals := 0.0
+	if shared := intersection(p.signals, other.signals); shared > 0 {
+		signals = float64(shared) / float64(min(len(p.signals), len(other.signals)))
+	}
+	return 1 - (1-title)*(1-body)*(1-signals)
+}
+
+func heuristicTerms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := make([]string, 0, len(words))
+	for _, word := range words {
+		if len(word) < 2 {
+			continue
+		}
+		if _, stop := heuristicStopWords[word]; stop {
+			continue
+		}
+		out = append(out, stem(word))
+	}
+	return out
+}
+
+// stem strips the most common English inflections so "crashes", "crashed"
+// and "crashing" compare equal.
+func stem(word string) string {
+	for _, suffix := range []string{"ing", "es", "ed", "s"} {
+		if strings.HasSuffix(word, suffix) && len(word)-len(suffix) >= 3 {
+			return strings.TrimSuffix(word, suffix)
+		}
+	}
+	return word
+}
+
+// codeSignal reports whether a whitespace-separated token looks like code or
+// an error identifier rather than prose: dotted or underscored names, paths,
+// calls, CamelCase words and mixed letter/digit codes.
+func codeSignal(field string) (string, bool) {
+	token := strings.Trim(field, "`'\"()[]{}<>,;:!?.")
+	if len(token) < 4 {
+		return "", false
+	}
+	hasLetter, hasDigit, camel := false, false, false
+	prevLower := false
+	for _, r := range token {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+			if unicode.IsUpper(r) && prevLower {
+				camel = true
+			}
+			prevLower = unicode.IsLower(r)
+		case unicode.IsDigit(r):
+			hasDigit = true
+			prevLower = false
+		default:
+			prevLower = false
+		}
+	}
+	if !hasLetter {
+		return "", false
+	}
+	if camel || (hasDigit && hasLetter) || strings.ContainsAny(token, "._/(") || strings.Contains(token, "::") {
+		return strings.ToLower(token), true
+	}
+	return "", false
+}
+
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := intersection(a, b)
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+func intersection(a, b map[string]struct{}) int {
+	shared := 0
+	for key := range a {
+		if _, ok := b[key]; ok {
+			shared++
+		}
+	}
+	return shared
+}
+
+func cosine(a, b map[string]float64) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for term, weight := range a {
+		dot += weight * b[term]
+		normA += weight * weight
+	}
+	for _, weight := range b {
+		normB += weight * weight
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
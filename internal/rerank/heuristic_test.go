@@ -0,0 +1,58 @@
+package rerank
+
+import (
+	"context"
+	"testing"
+)
+
+func TestHeuristic_PrefersSameProblemOverSameComponent(t *testing.T) {
+	t.Helper()
+
+	query := Item{
+		ID:    "issue/10",
+		Title: "Backfill crashes with database locked",
+		Body:  "Running backfill on a large repo fails with `SQLITE_BUSY` from store.UpsertItem after a few minutes.",
+	}
+	candidates := []Item{
+		{
+			ID:    "issue/4",
+			Title: "Backfill fails: database is locked",
+			Body:  "store.UpsertItem returns SQLITE_BUSY when the backfill runs for a while.",
+		},
+		{
+			ID:    "issue/7",
+			Title: "Backfill should skip archived repos",
+			Body:  "It would be nice if archived repositories were ignored.",
+		},
+	}
+
+	scores, err := Heuristic{}.Rerank(context.Background(), query, candidates)
+	if err != nil {
+		t.Fatalf("Rerank() error = %v", err)
+	}
+	if scores[0] < DefaultThreshold {
+		t.Fatalf("same-problem score = %.3f, want >= %.2f", scores[0], DefaultThreshold)
+	}
+	if scores[1] >= DefaultThreshold {
+		t.Fatalf("same-component score = %.3f, want < %.2f", scores[1], DefaultThreshold)
+	}
+}
+
+func TestCodeSignal(t *testing.T) {
+	t.Helper()
+
+	cases := map[string]bool{
+		"`store.UpsertItem`": true,
+		"SQLITE_BUSY,":       true,
+		"E1234":              true,
+		"internal/engine":    true,
+		"database":           false,
+		"2024":               false,
+		"end.":               false,
+	}
+	for field, want := range cases {
+		if _, got := codeSignal(field); got != want {
+			t.Fatalf("codeSignal(%q) = %v, want %v", field, got, want)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"vector-triage/internal/retry"
+)
+
+const (
+	DefaultJudgeEndpoint = "https://models.inference.ai.azure.com/chat/completions"
+	DefaultJudgeModel    = "gpt-4o-mini"
+
+	// judgeMaxBodyChars keeps a pair well inside small-model context windows.
+	judgeMaxBodyChars = 4000
+)
+
+const judgeSystemPrompt = `You compare two GitHub issues or pull requests from the same repository and decide whether they report the same underlying problem or make the same change.
+Sharing a component, a feature area or a few words is not enough; different symptoms, different root causes or different requests are not duplicates.
+Answer only with JSON that matches the schema. confidence is how sure you are of your duplicate verdict, from 0 to 1.`
+
+// judgeSchema is the strict output schema the model must follow.
+var judgeSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"duplicate":  map[string]any{"type": "boolean"},
+		"confidence": map[string]any{"type": "number"},
+		"reason":     map[string]any{"type": "string"},
+	},
+	"required":             []string{"duplicate", "confidence", "reason"},
+	"additionalProperties": false,
+}
+
+// GitHubModelsJudge asks a chat model on GitHub Models whether each
+// (query, candidate) pair is a duplicate. Pairs are judged one request at a
+// time so a verdict never depends on which other candidates were retrieved.
+type GitHubModelsJudge struct {
+	token      string
+	endpoint   string
+	model      string
+	maxRetries int
+	client     *http.Client
+	sleep      func(time.Duration)
+}
+
+func NewGitHubModelsJudge(cfg Config) (*GitHubModelsJudge, error) {
+	if strings.TrimSpace(cfg.Token) == "" {
+		return nil, errors.New("github token is required")
+	}
+	endpoint := strings.TrimSpace(cfg.Endpoint)
+	if endpoint == "" {
+		endpoint = DefaultJudgeEndpoint
+	}
+	model := strings.TrimSpace(cfg.Model)
+	if model == "" {
+		model = DefaultJudgeModel
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	maxRetries := cfg.MaxRetries
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: timeout}
+	}
+	sleep := cfg.Sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+	return &GitHubModelsJudge{
+		token:      cfg.Token,
+		endpoint:   endpoint,
+		model:      model,
+		maxRetries: maxRetries,
+		client:     client,
+		sleep:      sleep,
+	}, nil
+}
+
+func (*GitHubModelsJudge) Name() string { return NameGitHubModels }
+
+func (g *GitHubModelsJudge) Model() string { return g.model }
+
+// Rerank judges the candidates in order and stops at the first pair that
+// cannot be judged, so a failing endpoint costs one pair's retries rather than
+// one per candidate.
+func (g *GitHubModelsJudge) Rerank(ctx context.Context, query Item, candidates []Item) ([]float64, error) {
+	scores := make([]float64, len(candidates))
+	for i, candidate := range candidates {
+		verdict, err := g.judge(ctx, query, candidate)
+		if err != nil {
+			return nil, fmt.Errorf("judge %s against %s: %w", query.ID, candidate.ID, err)
+		}
+		scores[i] = verdict.score()
+	}
+	return scores, nil
+}
+
+type judgeVerdict struct {
+	Duplicate  *bool    `json:"duplicate"`
+	Confidence *float64 `json:"confidence"`
+	Reason     *string  `json:"reason"`
+}
+
+// score turns the verdict into a duplicate probability: a confident "no" is
+// as far from a duplicate as a confident "yes" is close to one.
+func (v judgeVerdict) score() float64 {
+	if *v.Duplicate {
+		return *v.Confidence
+	}
+	return 1 - *v.Confidence
+}
+
+func (g *GitHubModelsJudge) judge(ctx context.Context, query, candidate Item) (judgeVerdict, error) {
+	payload := chatRequest{
+		Model:       g.model,
+		Temperature: 0,
+		Messages: []chatMessage{
+			{Role: "system", Content: judgeSystemPrompt},
+			{Role: "user", Content: judgePrompt(query, candidate)},
+		},
+		ResponseFormat: responseFormat{
+			Type: "json_schema",
+			JSONSchema: jsonSchemaFormat{
+				Name:   "duplicate_verdict",
+				Strict: true,
+				Schema: judgeSchema,
+			},
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return judgeVerdict{}, fmt.Errorf("marshal judge request: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= g.maxRetries; attempt++ {
+		raw, retryAfter, err := g.post(ctx, body)
+		if err == nil {
+			// A response that breaks the schema will not fix itself on retry.
+			return decodeVerdict(raw)
+		}
+		var statusErr *judgeStatusError
+		if errors.As(err, &statusErr) && !statusErr.retryable() {
+			// A bad token, a missing model or a malformed request fails the
+			// same way every time; retrying only burns rate limit.
+			return judgeVerdict{}, err
+		}
+		lastErr = err
+		if attempt == g.maxRetries || ctx.Err() != nil {
+			break
+		}
+		wait := retryAfter
+		if wait <= 0 {
+			wait = retry.Backoff(attempt)
+		}
+		g.sleep(wait)
+	}
+	return judgeVerdict{}, fmt.Errorf("judge request failed after %d attempts: %w", g.maxRetries+1, lastErr)
+}
+
+func judgePrompt(query, candidate Item) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Item A (new):\nTitle: %s\nBody:\n%s\n\n", query.Title, clip(query.Body, judgeMaxBodyChars))
+	fmt.Fprintf(&b, "Item B (existing):\nTitle: %s\nBody:\n%s\n\n", candidate.Title, clip(candidate.Body, judgeMaxBodyChars))
+	b.WriteString("Are A and B duplicates?")
+	return b.String()
+}
+
+func (g *GitHubModelsJudge) post(ctx context.Context, body []byte) ([]byte, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, fmt.Errorf("build judge request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+g.token)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("send judge request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		retryAfter := retry.ParseRetryAfter(resp.Header.Get("Retry-After"))
+		bodyText, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, retryAfter, &judgeStatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(bodyText))}
+	}
+	out, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("read judge response: %w", err)
+	}
+	return out, 0, nil
+}
+
+// judgeStatusError is a non-2xx answer from the judge endpoint.
+type judgeStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *judgeStatusError) Error() string {
+	return fmt.Sprintf("judge request failed: status=%d body=%s", e.StatusCode, e.Body)
+}
+
+// retryable reports whether the status is worth another attempt: rate limits
+// and server errors are, every other client error is not.
+func (e *judgeStatusError) retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// decodeVerdict reads the model's message and holds it to the schema: every
+// field present, nothing extra, and a confidence inside [0, 1].
+func decodeVerdict(raw []byte) (judgeVerdict, error) {
+	var resp chatResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return judgeVerdict{}, fmt.Errorf("decode judge response: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return judgeVerdict{}, errors.New("judge response has no choices")
+	}
+	content := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if content == "" {
+		return judgeVerdict{}, errors.New("judge response message is empty")
+	}
+
+	dec := json.NewDecoder(strings.NewReader(content))
+	dec.DisallowUnknownFields()
+	var verdict judgeVerdict
+	if err := dec.Decode(&verdict); err != nil {
+		return judgeVerdict{}, fmt.Errorf("judge verdict does not match schema: %w", err)
+	}
+	if dec.More() {
+		return judgeVerdict{}, errors.New("judge verdict has trailing content")
+	}
+	if verdict.Duplicate == nil || verdict.Confidence == nil || verdict.Reason == nil {
+		return judgeVerdict{}, errors.New("judge verdict is missing a required field")
+	}
+	if *verdict.Confidence < 0 || *verdict.Confidence > 1 {
+		return judgeVerdict{}, fmt.Errorf("judge confidence %v is outside [0, 1]", *verdict.Confidence)
+	}
+	return verdict, nil
+}
+
+func clip(text string, maxChars int) string {
+	runes := []rune(text)
+	if len(runes) <= maxChars {
+		return text
+	}
+	return string(runes[:maxChars])
+}
+
+type chatRequest struct {
+	Model          string         `json:"model"`
+	Temperature    float64        `json:"temperature"`
+	Messages       []chatMessage  `json:"messages"`
+	ResponseFormat responseFormat `json:"response_format"`
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type responseFormat struct {
+	Type       string           `json:"type"`
+	JSONSchema jsonSchemaFormat `json:"json_schema"`
+}
+
+type jsonSchemaFormat struct {
+	Name   string         `json:"name"`
+	Strict bool           `json:"strict"`
+	Schema map[string]any `json:"schema"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
@@ -0,0 +1,181 @@
+package rerank
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGitHubModelsJudge_SendsStrictSchemaAndScoresVerdicts(t *testing.T) {
+	t.Helper()
+
+	verdicts := []string{
+		`{"duplicate":true,"confidence":0.9,"reason":"same timeout"}`,
+		`{"duplicate":false,"confidence":0.8,"reason":"different page"}`,
+	}
+	calls := 0
+	client := &http.Client{
+		Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			if got := r.Header.Get("Authorization"); got != "Bearer token-123" {
+				t.Fatalf("authorization header = %q", got)
+			}
+			var req chatRequest
+			raw, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(raw, &req); err != nil {
+				t.Fatalf("decode request: %v", err)
+			}
+			if req.Model != DefaultJudgeModel || req.ResponseFormat.Type != "json_schema" || !req.ResponseFormat.JSONSchema.Strict {
+				t.Fatalf("unexpected request: %s", raw)
+			}
+			if req.ResponseFormat.JSONSchema.Schema["additionalProperties"] != false {
+				t.Fatalf("schema must forbid extra properties: %s", raw)
+			}
+			if len(req.Messages) != 2 || !strings.Contains(req.Messages[1].Content, "login times out") {
+				t.Fatalf("unexpected messages: %+v", req.Messages)
+			}
+			content, _ := json.Marshal(verdicts[calls])
+			calls++
+			return jsonResponse(http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":`+string(content)+`}}]}`), nil
+		}),
+	}
+
+	judge, err := NewGitHubModelsJudge(Config{Token: "token-123", HTTPClient: client})
+	if err != nil {
+		t.Fatalf("NewGitHubModelsJudge() error = %v", err)
+	}
+	scores, err := judge.Rerank(context.Background(),
+		Item{ID: "issue/1", Title: "login times out"},
+		[]Item{{ID: "issue/2", Title: "login timeout"}, {ID: "issue/3", Title: "login layout"}})
+	if err != nil {
+		t.Fatalf("Rerank() error = %v", err)
+	}
+	if len(scores) != 2 || scores[0] != 0.9 || scores[1] < 0.19 || scores[1] > 0.21 {
+		t.Fatalf("scores = %v, want [0.9 0.2]", scores)
+	}
+}
+
+func TestGitHubModelsJudge_RetriesThenRejectsOffSchemaVerdict(t *testing.T) {
+	t.Helper()
+
+	calls := 0
+	client := &http.Client{
+		Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			_ = r
+			calls++
+			if calls == 1 {
+				resp := jsonResponse(http.StatusTooManyRequests, `{"error":"rate limited"}`)
+				resp.Header.Set("Retry-After", "3")
+				return resp, nil
+			}
+			return jsonResponse(http.StatusOK, `{"choices":[{"message":{"content":"{\"duplicate\":true,\"confidence\":0.9,\"reason\":\"x\",\"extra\":1}"}}]}`), nil
+		}),
+	}
+	var sleeps []time.Duration
+	judge, err := NewGitHubModelsJudge(Config{
+		Token:      "token-123",
+		MaxRetries: 2,
+		HTTPClient: client,
+		Sleep:      func(d time.Duration) { sleeps = append(sleeps, d) },
+	})
+	if err != nil {
+		t.Fatalf("NewGitHubModelsJudge() error = %v", err)
+	}
+
+	_, err = judge.Rerank(context.Background(), Item{ID: "issue/1"}, []Item{{ID: "issue/2"}})
+	if err == nil || !strings.Contains(err.Error(), "does not match schema") {
+		t.Fatalf("Rerank() error = %v, want schema error", err)
+	}
+	if calls != 2 || len(sleeps) != 1 || sleeps[0] != 3*time.Second {
+		t.Fatalf("calls = %d sleeps = %v, want one retry after 3s", calls, sleeps)
+	}
+}
+
+func TestGitHubModelsJudge_StopsOnNonRetryableStatus(t *testing.T) {
+	t.Helper()
+
+	for _, tc := range []struct {
+		status    int
+		wantCalls int
+	}{
+		{status: http.StatusUnauthorized, wantCalls: 1},
+		{status: http.StatusNotFound, wantCalls: 1},
+		{status: http.StatusBadGateway, wantCalls: 3},
+	} {
+		calls := 0
+		client := &http.Client{
+			Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				_ = r
+				calls++
+				return jsonResponse(tc.status, `{"error":"nope"}`), nil
+			}),
+		}
+		judge, err := NewGitHubModelsJudge(Config{
+			Token:      "token-123",
+			MaxRetries: 2,
+			HTTPClient: client,
+			Sleep:      func(time.Duration) {},
+		})
+		if err != nil {
+			t.Fatalf("NewGitHubModelsJudge() error = %v", err)
+		}
+
+		_, err = judge.Rerank(context.Background(), Item{ID: "issue/1"}, []Item{{ID: "issue/2"}, {ID: "issue/3"}, {ID: "issue/4"}})
+		if err == nil || !strings.Contains(err.Error(), "status="+strconv.Itoa(tc.status)) {
+			t.Fatalf("Rerank() error = %v, want status %d", err, tc.status)
+		}
+		if calls != tc.wantCalls {
+			t.Fatalf("status %d: calls = %d, want %d", tc.status, calls, tc.wantCalls)
+		}
+	}
+}
+
+func TestDecodeVerdict_RejectsOutOfRangeAndMissingFields(t *testing.T) {
+	t.Helper()
+
+	for _, content := range []string{
+		`{"duplicate":true,"confidence":1.5,"reason":"x"}`,
+		`{"duplicate":true,"reason":"x"}`,
+		`not json`,
+	} {
+		raw, _ := json.Marshal(map[string]any{"choices": []any{map[string]any{"message": map[string]any{"content": content}}}})
+		if _, err := decodeVerdict(raw); err == nil {
+			t.Fatalf("decodeVerdict(%s) expected error", content)
+		}
+	}
+}
+
+func TestNew_SelectsReranker(t *testing.T) {
+	t.Helper()
+
+	if r, err := New("", Config{}); err != nil || r.Name() != NameHeuristic {
+		t.Fatalf("New(\"\") = %v, %v; want heuristic", r, err)
+	}
+	if r, err := New(NameNone, Config{}); err != nil || r != nil {
+		t.Fatalf("New(none) = %v, %v; want nil", r, err)
+	}
+	if _, err := New(NameGitHubModels, Config{}); err == nil {
+		t.Fatalf("expected github-models without a token to fail")
+	}
+	if _, err := New("cohere", Config{}); err == nil {
+		t.Fatalf("expected unknown reranker to fail")
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func jsonResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
@@ -0,0 +1,66 @@
+package rerank
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	NameHeuristic    = "heuristic"
+	NameGitHubModels = "github-models"
+	NameNone         = "none"
+
+	// DefaultThreshold is the rerank score a candidate needs to stay a duplicate.
+	DefaultThreshold = 0.5
+	// DefaultTopN is how many fused candidates are rescored.
+	DefaultTopN = 5
+)
+
+// Item is the text of one issue or pull request in a rerank pair.
+type Item struct {
+	ID    string
+	Title string
+	Body  string
+}
+
+// Reranker rescores (query, candidate) pairs after fusion. Scores are in
+// [0, 1], one per candidate in order, and estimate how likely the pair
+// reports the same problem.
+type Reranker interface {
+	Name() string
+	Rerank(ctx context.Context, query Item, candidates []Item) ([]float64, error)
+}
+
+// Config carries the settings of the rerankers that call a model.
+type Config struct {
+	Token      string
+	Endpoint   string
+	Model      string
+	Timeout    time.Duration
+	MaxRetries int
+	HTTPClient *http.Client
+	Sleep      func(time.Duration)
+}
+
+// Names lists the names accepted by New.
+func Names() []string {
+	return []string{NameHeuristic, NameGitHubModels, NameNone}
+}
+
+// New builds a reranker by name. "none" returns a nil Reranker, which turns
+// the rerank stage off.
+func New(name string, cfg Config) (Reranker, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", NameHeuristic:
+		return Heuristic{}, nil
+	case NameGitHubModels:
+		return NewGitHubModelsJudge(cfg)
+	case NameNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown reranker %q (supported: %s)", name, strings.Join(Names(), ", "))
+	}
+}
@@ -24,8 +24,28 @@ func (f Formatter) Format(event gh.Event, results []store.FusedResult) string {
 	b.WriteString(gh.CommentMarker)
 	b.WriteString("\n### 🔍 Triage Report\n\n")
 
+	if isKeywordOnly(results) {
+		b.WriteString("> [!NOTE]\n")
+		b.WriteString("> Semantic search was unavailable for this run, so these are **keyword-only** matches.\n\n")
+	}
+
 	duplicate := findTopDuplicate(results, thresholdOrDefault(f.DuplicateThreshold, 0.92))
-	if duplicate != nil {
+	if duplicate != nil && duplicate.Confirmed {
+		b.WriteString("> [!WARNING]\n")
+		b.WriteString(fmt.Sprintf("> **Confirmed duplicate** of #%d (marked by a maintainer)\n", duplicate.Number))
+		b.WriteString(">\n")
+		b.WriteString(fmt.Sprintf("> %s\n\n", duplicate.Title))
+	} else if duplicate != nil && duplicate.NearExact {
+		b.WriteString("> [!WARNING]\n")
+		b.WriteString(fmt.Sprintf("> **Near-exact copy** of #%d (%s of the text matches)\n", duplicate.Number, formatPercent(duplicate.NearScore)))
+		b.WriteString(">\n")
+		b.WriteString(fmt.Sprintf("> %s\n\n", duplicate.Title))
+	} else if duplicate != nil && duplicate.Calibrated {
+		b.WriteString("> [!WARNING]\n")
+		b.WriteString(fmt.Sprintf("> **Possible duplicate** of #%d (%s likely, %s similar)\n", duplicate.Number, formatPercent(duplicate.DuplicateProbability), formatPercent(duplicate.DisplaySimilarity)))
+		b.WriteString(">\n")
+		b.WriteString(fmt.Sprintf("> %s\n\n", duplicate.Title))
+	} else if duplicate != nil {
 		b.WriteString("> [!WARNING]\n")
 		b.WriteString(fmt.Sprintf("> **Possible duplicate** of #%d (%s similar)\n", duplicate.Number, formatPercent(duplicate.DisplaySimilarity)))
 		b.WriteString(">\n")
@@ -33,14 +53,15 @@ func (f Formatter) Format(event gh.Event, results []store.FusedResult) string {
 	}
 
 	b.WriteString(fmt.Sprintf("<details><summary>📋 Similar items found (%d)</summary>\n\n", len(results)))
-	b.WriteString("| # | Title | Similarity | Status |\n")
-	b.WriteString("|---|-------|-----------|--------|\n")
+	b.WriteString("| # | Title | Similarity | Status | Why |\n")
+	b.WriteString("|---|-------|-----------|--------|-----|\n")
 	for _, result := range results {
-		b.WriteString(fmt.Sprintf("| #%d | %s | %s | %s |\n",
+		b.WriteString(fmt.Sprintf("| #%d | %s | %s | %s | %s |\n",
 			result.Number,
 			escapePipe(result.Title),
-			formatPercent(result.DisplaySimilarity),
+			similarityCell(result),
 			statusIcon(result.State)+" "+result.State,
+			whyCell(result),
 		))
 	}
 	b.WriteString("\n</details>\n\n")
@@ -54,10 +75,17 @@ func findTopDuplicate(results []store.FusedResult, duplicateThreshold float64) *
 	var best *store.FusedResult
 	for i := range results {
 		candidate := results[i]
-		if candidate.DisplaySimilarity < duplicateThreshold {
+		// A calibrated IsDuplicate already applied the threshold to the probability,
+		// and a reranked one already had the reranker's verdict applied.
+		qualifies := candidate.IsDuplicate || (!candidate.KeywordOnly && !candidate.Calibrated && !candidate.Reranked && candidate.DisplaySimilarity >= duplicateThreshold)
+		if !qualifies {
 			continue
 		}
-		if best == nil || candidate.DisplaySimilarity > best.DisplaySimilarity {
+		better := best == nil ||
+			(candidate.Confirmed && !best.Confirmed) ||
+			(candidate.Confirmed == best.Confirmed && candidate.NearExact && !best.NearExact) ||
+			(candidate.Confirmed == best.Confirmed && candidate.NearExact == best.NearExact && candidate.DisplaySimilarity > best.DisplaySimilarity)
+		if better {
 			copyCandidate := candidate
 			best = &copyCandidate
 		}
@@ -65,6 +93,15 @@ func findTopDuplicate(results []store.FusedResult, duplicateThreshold float64) *
 	return best
 }
 
+func isKeywordOnly(results []store.FusedResult) bool {
+	for _, result := range results {
+		if !result.KeywordOnly {
+			return false
+		}
+	}
+	return len(results) > 0
+}
+
 func thresholdOrDefault(value, fallback float64) float64 {
 	if value <= 0 {
 		return fallback
@@ -82,6 +119,47 @@ func formatPercent(score float64) string {
 	return fmt.Sprintf("%d%%", int(math.Round(score*100)))
 }
 
+func similarityCell(result store.FusedResult) string {
+	if result.Confirmed {
+		return "✅ confirmed"
+	}
+	return formatPercent(result.DisplaySimilarity)
+}
+
+// whyCell lists the per-source scores behind a result, followed by the
+// recency multipliers when they moved its rank.
+func whyCell(result store.FusedResult) string {
+	var parts []string
+	if result.VecScore > 0 {
+		parts = append(parts, "vector "+formatPercent(result.VecScore))
+	}
+	if result.FTSScore > 0 {
+		parts = append(parts, "keyword "+formatPercent(result.FTSScore))
+	}
+	if result.NearScore > 0 {
+		parts = append(parts, "text overlap "+formatPercent(result.NearScore))
+	}
+	if result.Reranked {
+		parts = append(parts, "rerank "+formatPercent(result.RerankScore))
+	}
+	if isAdjusted(result.RecencyDecay) {
+		parts = append(parts, fmt.Sprintf("recency ×%.2f", result.RecencyDecay))
+	}
+	if isAdjusted(result.OpenBoost) {
+		parts = append(parts, fmt.Sprintf("open ×%.2f", result.OpenBoost))
+	}
+	if len(parts) == 0 {
+		return "—"
+	}
+	return strings.Join(parts, " · ")
+}
+
+// isAdjusted reports whether a ranking multiplier did anything; 1 is neutral
+// and 0 means it was never set.
+func isAdjusted(multiplier float64) bool {
+	return multiplier != 0 && multiplier != 1
+}
+
 func statusIcon(state string) string {
 	switch strings.ToLower(strings.TrimSpace(state)) {
 	case "open":
@@ -63,3 +63,88 @@ func TestFormatter_RoundsPercentAndSupportsMerged(t *testing.T) {
 		t.Fatalf("expected merged icon:\n%s", got)
 	}
 }
+
+func TestFormatter_KeywordOnlyNoteAndNoDuplicateWarning(t *testing.T) {
+	t.Helper()
+	f := Formatter{DuplicateThreshold: 0.92}
+	got := f.Format(gh.Event{}, []store.FusedResult{{Number: 3, Title: "Login", DisplaySimilarity: 0.96, State: "open", KeywordOnly: true}})
+	if !strings.Contains(got, "keyword-only") {
+		t.Fatalf("expected keyword-only note:\n%s", got)
+	}
+	if strings.Contains(got, "Possible duplicate") {
+		t.Fatalf("keyword-only results must not be flagged as duplicates:\n%s", got)
+	}
+}
+
+func TestFormatter_ConfirmedDuplicateWinsWarning(t *testing.T) {
+	t.Helper()
+	f := Formatter{DuplicateThreshold: 0.92}
+	got := f.Format(gh.Event{}, []store.FusedResult{
+		{Number: 3, Title: "Confirmed", IsDuplicate: true, Confirmed: true, State: "closed"},
+		{Number: 5, Title: "Close match", DisplaySimilarity: 0.97, IsDuplicate: true, State: "open"},
+	})
+
+	if !strings.Contains(got, "**Confirmed duplicate** of #3") {
+		t.Fatalf("expected confirmed duplicate warning:\n%s", got)
+	}
+	if !strings.Contains(got, "✅ confirmed") {
+		t.Fatalf("expected confirmed similarity cell:\n%s", got)
+	}
+}
+
+func TestFormatter_NearExactCopyWarning(t *testing.T) {
+	t.Helper()
+	f := Formatter{DuplicateThreshold: 0.92}
+	got := f.Format(gh.Event{}, []store.FusedResult{
+		{Number: 5, Title: "Close match", DisplaySimilarity: 0.97, IsDuplicate: true, State: "open"},
+		{Number: 8, Title: "Copy", DisplaySimilarity: 0.94, NearScore: 0.94, IsDuplicate: true, NearExact: true, State: "open", KeywordOnly: true},
+	})
+
+	if !strings.Contains(got, "**Near-exact copy** of #8 (94% of the text matches)") {
+		t.Fatalf("expected near-exact copy warning:\n%s", got)
+	}
+}
+
+func TestFormatter_CalibratedDuplicateShowsProbability(t *testing.T) {
+	t.Helper()
+	f := Formatter{DuplicateThreshold: 0.9}
+	got := f.Format(gh.Event{}, []store.FusedResult{
+		{Number: 4, Title: "Keyword twin", DisplaySimilarity: 0.95, DuplicateProbability: 0.2, Calibrated: true, State: "open"},
+		{Number: 6, Title: "Real dup", DisplaySimilarity: 0.88, DuplicateProbability: 0.93, Calibrated: true, IsDuplicate: true, State: "open"},
+	})
+
+	if !strings.Contains(got, "**Possible duplicate** of #6 (93% likely, 88% similar)") {
+		t.Fatalf("expected calibrated duplicate warning for #6:\n%s", got)
+	}
+}
+
+func TestFormatter_RerankRejectionSuppressesWarning(t *testing.T) {
+	t.Helper()
+	f := Formatter{DuplicateThreshold: 0.9}
+	got := f.Format(gh.Event{}, []store.FusedResult{
+		{Number: 4, Title: "Same component", DisplaySimilarity: 0.95, RerankScore: 0.1, Reranked: true, State: "open"},
+	})
+
+	if strings.Contains(got, "Possible duplicate") {
+		t.Fatalf("did not expect a duplicate warning after the reranker disagreed:\n%s", got)
+	}
+}
+
+func TestFormatter_WhyShowsSourceScoresAndRecency(t *testing.T) {
+	t.Helper()
+	f := Formatter{DuplicateThreshold: 0.99}
+	got := f.Format(gh.Event{}, []store.FusedResult{
+		{Number: 2, Title: "Old report", DisplaySimilarity: 0.8, VecScore: 0.8, FTSScore: 0.4, RecencyDecay: 0.5, OpenBoost: 1, State: "closed"},
+		{Number: 3, Title: "Fresh report", DisplaySimilarity: 0.7, VecScore: 0.7, RecencyDecay: 1, OpenBoost: 1.2, State: "open"},
+	})
+
+	if !strings.Contains(got, "| vector 80% · keyword 40% · recency ×0.50 |") {
+		t.Fatalf("missing decayed why cell:\n%s", got)
+	}
+	if !strings.Contains(got, "| vector 70% · open ×1.20 |") {
+		t.Fatalf("missing boosted why cell:\n%s", got)
+	}
+	if strings.Contains(got, "open ×1.00") || strings.Contains(got, "recency ×1.00") {
+		t.Fatalf("neutral multipliers should be hidden:\n%s", got)
+	}
+}
@@ -0,0 +1,43 @@
+// Package retry holds the backoff rules shared by every HTTP client that
+// retries, so embedding and rerank requests back off the same way.
+package retry
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxBackoff caps Backoff so a long retry chain never stalls a run for minutes.
+const MaxBackoff = 30 * time.Second
+
+// ParseRetryAfter reads a Retry-After header given in seconds or as an HTTP
+// date. It returns 0 when the header is missing, malformed or in the past.
+func ParseRetryAfter(raw string) time.Duration {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(raw); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(raw); err == nil {
+		if d := time.Until(when); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// Backoff is the wait before retrying after the zero-based attempt when the
+// server sent no Retry-After: 1s, 2s, 4s and so on, capped at MaxBackoff.
+func Backoff(attempt int) time.Duration {
+	if attempt < 0 {
+		attempt = 0
+	}
+	if attempt >= 5 {
+		return MaxBackoff
+	}
+	return time.Duration(1<<attempt) * time.Second
+}
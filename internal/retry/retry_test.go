@@ -0,0 +1,48 @@
+package retry
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Helper()
+
+	tests := []struct {
+		raw  string
+		want time.Duration
+	}{
+		{raw: "", want: 0},
+		{raw: "3", want: 3 * time.Second},
+		{raw: " 10 ", want: 10 * time.Second},
+		{raw: "0", want: 0},
+		{raw: "-2", want: 0},
+		{raw: "soon", want: 0},
+		{raw: time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), want: 0},
+	}
+	for _, tt := range tests {
+		if got := ParseRetryAfter(tt.raw); got != tt.want {
+			t.Fatalf("ParseRetryAfter(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := ParseRetryAfter(future); got <= 0 || got > time.Minute {
+		t.Fatalf("ParseRetryAfter(date) = %v, want within a minute", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	t.Helper()
+
+	want := []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, MaxBackoff, MaxBackoff}
+	for attempt, w := range want {
+		if got := Backoff(attempt); got != w {
+			t.Fatalf("Backoff(%d) = %v, want %v", attempt, got, w)
+		}
+	}
+	if got := Backoff(100); got != MaxBackoff {
+		t.Fatalf("Backoff(100) = %v, want %v", got, MaxBackoff)
+	}
+}
@@ -0,0 +1,280 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CalibrationKey holds the fitted calibration model as JSON in index_meta.
+const CalibrationKey = "calibration"
+
+const (
+	CalibrationPlatt    = "platt"
+	CalibrationIsotonic = "isotonic"
+
+	// minCalibrationPairs is the fewest labeled pairs per class worth fitting on.
+	minCalibrationPairs = 5
+)
+
+// CalibrationSample is one labeled candidate: the per-source scores and the
+// fused similarity FuseResults gave it, plus the maintainer's verdict.
+type CalibrationSample struct {
+	VecScore   float64
+	FTSScore   float64
+	NearScore  float64
+	Similarity float64
+	Duplicate  bool
+}
+
+// IsotonicPoint is one knot of an isotonic calibration curve.
+type IsotonicPoint struct {
+	Similarity  float64 `json:"similarity"`
+	Probability float64 `json:"probability"`
+}
+
+// Calibration maps fusion scores to the probability that a candidate is a
+// duplicate. Platt is a logistic model over the vector, FTS and near-duplicate
+// scores, so it learns what each scale means; isotonic is a monotone curve
+// over the fused similarity.
+type Calibration struct {
+	Method string `json:"method"`
+	// Coefficients are the Platt bias and the vector, FTS and near weights.
+	Coefficients []float64       `json:"coefficients,omitempty"`
+	Points       []IsotonicPoint `json:"points,omitempty"`
+
+	// Fusion is the strategy the fit was made with; isotonic curves only hold
+	// for its similarity. Re-embedding the index drops the calibration.
+	Fusion     string    `json:"fusion"`
+	Pairs      int       `json:"pairs"`
+	Duplicates int       `json:"duplicates"`
+	FittedAt   time.Time `json:"fitted_at"`
+}
+
+// CalibrationMethodNames lists the methods accepted by FitCalibration.
+func CalibrationMethodNames() []string {
+	return []string{CalibrationPlatt, CalibrationIsotonic}
+}
+
+// FitCalibration fits the named method on labeled samples. Both classes need
+// at least a handful of samples, otherwise the fit would only echo the prior.
+func FitCalibration(method string, samples []CalibrationSample) (Calibration, error) {
+	positives := 0
+	for _, sample := range samples {
+		if sample.Duplicate {
+			positives++
+		}
+	}
+	if positives < minCalibrationPairs || len(samples)-positives < minCalibrationPairs {
+		return Calibration{}, fmt.Errorf("calibration needs at least %d duplicate and %d non-duplicate pairs, have %d and %d",
+			minCalibrationPairs, minCalibrationPairs, positives, len(samples)-positives)
+	}
+
+	cal := Calibration{Pairs: len(samples), Duplicates: positives, FittedAt: time.Now().UTC()}
+	switch strings.ToLower(strings.TrimSpace(method)) {
+	case "", CalibrationPlatt:
+		cal.Method = CalibrationPlatt
+		cal.Coefficients = fitPlatt(samples, positives)
+	case CalibrationIsotonic:
+		cal.Method = CalibrationIsotonic
+		cal.Points = fitIsotonic(samples)
+	default:
+		return Calibration{}, fmt.Errorf("unknown calibration method %q (supported: %s)", method, strings.Join(CalibrationMethodNames(), ", "))
+	}
+	return cal, nil
+}
+
+// Probability returns the calibrated duplicate probability of one candidate.
+func (c *Calibration) Probability(vec, fts, near, similarity float64) float64 {
+	switch c.Method {
+	case CalibrationPlatt:
+		if len(c.Coefficients) != 4 {
+			return similarity
+		}
+		return sigmoid(dot(c.Coefficients, plattFeatures(vec, fts, near)))
+	case CalibrationIsotonic:
+		return interpolateIsotonic(c.Points, similarity)
+	default:
+		return similarity
+	}
+}
+
+func plattFeatures(vec, fts, near float64) []float64 {
+	return []float64{1, clamp01(vec), clamp01(fts), clamp01(near)}
+}
+
+// fitPlatt runs Newton's method on the L2-regularized logistic loss, using
+// Platt's smoothed targets so a perfectly separable set cannot diverge.
+func fitPlatt(samples []CalibrationSample, positives int) []float64 {
+	const (
+		iterations = 50
+		lambda     = 1e-3
+	)
+	hiTarget := float64(positives+1) / float64(positives+2)
+	loTarget := 1 / float64(len(samples)-positives+2)
+
+	w := make([]float64, 4)
+	for iter := 0; iter < iterations; iter++ {
+		grad := make([]float64, 4)
+		hess := make([][]float64, 4)
+		for i := range hess {
+			hess[i] = make([]float64, 4)
+			hess[i][i] = lambda
+			grad[i] = lambda * w[i]
+		}
+		for _, sample := range samples {
+			x := plattFeatures(sample.VecScore, sample.FTSScore, sample.NearScore)
+			p := sigmoid(dot(w, x))
+			target := loTarget
+			if sample.Duplicate {
+				target = hiTarget
+			}
+			for i := range x {
+				grad[i] += (p - target) * x[i]
+				for j := range x {
+					hess[i][j] += p * (1 - p) * x[i] * x[j]
+				}
+			}
+		}
+
+		step, ok := solveLinear(hess, grad)
+		if !ok {
+			break
+		}
+		change := 0.0
+		for i := range w {
+			w[i] -= step[i]
+			change = math.Max(change, math.Abs(step[i]))
+		}
+		if change < 1e-9 {
+			break
+		}
+	}
+	return w
+}
+
+// solveLinear solves a*x = b by Gaussian elimination with partial pivoting.
+func solveLinear(a [][]float64, b []float64) ([]float64, bool) {
+	n := len(b)
+	m := make([][]float64, n)
+	for i := range a {
+		m[i] = append(append([]float64{}, a[i]...), b[i])
+	}
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(m[row][col]) > math.Abs(m[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(m[pivot][col]) < 1e-12 {
+			return nil, false
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		for row := col + 1; row < n; row++ {
+			f := m[row][col] / m[col][col]
+			for k := col; k <= n; k++ {
+				m[row][k] -= f * m[col][k]
+			}
+		}
+	}
+	x := make([]float64, n)
+	for row := n - 1; row >= 0; row-- {
+		sum := m[row][n]
+		for k := row + 1; k < n; k++ {
+			sum -= m[row][k] * x[k]
+		}
+		x[row] = sum / m[row][row]
+	}
+	return x, true
+}
+
+// fitIsotonic runs pool-adjacent-violators over samples sorted by similarity
+// and keeps one knot per pooled block, at the block's mean similarity.
+func fitIsotonic(samples []CalibrationSample) []IsotonicPoint {
+	sorted := append([]CalibrationSample{}, samples...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Similarity < sorted[j].Similarity })
+
+	type block struct{ sumX, sumY, n float64 }
+	blocks := make([]block, 0, len(sorted))
+	for _, sample := range sorted {
+		y := 0.0
+		if sample.Duplicate {
+			y = 1
+		}
+		blocks = append(blocks, block{sumX: clamp01(sample.Similarity), sumY: y, n: 1})
+		for len(blocks) > 1 {
+			last, prev := blocks[len(blocks)-1], blocks[len(blocks)-2]
+			if prev.sumY/prev.n < last.sumY/last.n {
+				break
+			}
+			blocks = append(blocks[:len(blocks)-2], block{sumX: prev.sumX + last.sumX, sumY: prev.sumY + last.sumY, n: prev.n + last.n})
+		}
+	}
+
+	points := make([]IsotonicPoint, 0, len(blocks))
+	for _, b := range blocks {
+		points = append(points, IsotonicPoint{Similarity: b.sumX / b.n, Probability: b.sumY / b.n})
+	}
+	return points
+}
+
+// interpolateIsotonic reads the curve linearly between knots and flat past
+// the first and last one.
+func interpolateIsotonic(points []IsotonicPoint, similarity float64) float64 {
+	if len(points) == 0 {
+		return similarity
+	}
+	if similarity <= points[0].Similarity {
+		return points[0].Probability
+	}
+	for i := 1; i < len(points); i++ {
+		if similarity <= points[i].Similarity {
+			lo, hi := points[i-1], points[i]
+			t := (similarity - lo.Similarity) / (hi.Similarity - lo.Similarity)
+			return lo.Probability + t*(hi.Probability-lo.Probability)
+		}
+	}
+	return points[len(points)-1].Probability
+}
+
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
+
+func dot(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// SaveCalibration stores the fitted model in index_meta.
+func (s *Store) SaveCalibration(ctx context.Context, cal Calibration) error {
+	raw, err := json.Marshal(cal)
+	if err != nil {
+		return fmt.Errorf("marshal calibration: %w", err)
+	}
+	return s.SetMeta(ctx, CalibrationKey, string(raw))
+}
+
+// LoadCalibration reads the stored model. found=false means none was fitted.
+func (s *Store) LoadCalibration(ctx context.Context) (cal Calibration, found bool, err error) {
+	raw, found, err := s.GetMeta(ctx, CalibrationKey)
+	if err != nil || !found {
+		return Calibration{}, false, err
+	}
+	if err := json.Unmarshal([]byte(raw), &cal); err != nil {
+		return Calibration{}, false, fmt.Errorf("decode calibration: %w", err)
+	}
+	if cal.Method != CalibrationPlatt && cal.Method != CalibrationIsotonic {
+		return Calibration{}, false, errors.New("stored calibration has an unknown method")
+	}
+	return cal, true, nil
+}
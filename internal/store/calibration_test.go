@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func calibrationFixture() []CalibrationSample {
+	var samples []CalibrationSample
+	for i := 0; i < 10; i++ {
+		step := float64(i) / 100
+		// Duplicates agree semantically; distinct items only share common words.
+		samples = append(samples,
+			CalibrationSample{VecScore: 0.88 + step, FTSScore: 0.40, Similarity: 0.88 + step, Duplicate: true},
+			CalibrationSample{VecScore: 0.55 + step, FTSScore: 0.85 + step, Similarity: 0.85 + step},
+		)
+	}
+	return samples
+}
+
+func TestFitCalibration_PlattSeparatesScoreScales(t *testing.T) {
+	t.Helper()
+
+	cal, err := FitCalibration(CalibrationPlatt, calibrationFixture())
+	if err != nil {
+		t.Fatalf("FitCalibration() error = %v", err)
+	}
+	if len(cal.Coefficients) != 4 || cal.Pairs != 20 || cal.Duplicates != 10 {
+		t.Fatalf("unexpected calibration: %+v", cal)
+	}
+	semantic := cal.Probability(0.92, 0.40, 0, 0.92)
+	keyword := cal.Probability(0.58, 0.92, 0, 0.92)
+	if semantic < 0.8 || keyword > 0.2 {
+		t.Fatalf("Probability() semantic = %.3f, keyword = %.3f", semantic, keyword)
+	}
+}
+
+func TestFitCalibration_IsotonicIsMonotone(t *testing.T) {
+	t.Helper()
+
+	cal, err := FitCalibration(CalibrationIsotonic, calibrationFixture())
+	if err != nil {
+		t.Fatalf("FitCalibration() error = %v", err)
+	}
+	prev := -1.0
+	for sim := 0.0; sim <= 1.0; sim += 0.01 {
+		p := cal.Probability(0, 0, 0, sim)
+		if p < prev || p < 0 || p > 1 {
+			t.Fatalf("Probability(%.2f) = %.3f after %.3f", sim, p, prev)
+		}
+		prev = p
+	}
+	if low, high := cal.Probability(0, 0, 0, 0.5), cal.Probability(0, 0, 0, 0.99); low != 0 || high != 1 {
+		t.Fatalf("isotonic ends = %.3f/%.3f, want 0/1", low, high)
+	}
+
+	if _, err := FitCalibration("beta", calibrationFixture()); err == nil {
+		t.Fatalf("expected error for unknown method")
+	}
+	if _, err := FitCalibration(CalibrationPlatt, calibrationFixture()[:6]); err == nil {
+		t.Fatalf("expected error for too few samples")
+	}
+}
+
+func TestCalibration_SaveLoadAndFuse(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	s, err := Open(ctx, filepath.Join(t.TempDir(), "calibration.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() {
+		if cerr := s.Close(); cerr != nil {
+			t.Fatalf("Close() error = %v", cerr)
+		}
+	}()
+
+	if _, found, err := s.LoadCalibration(ctx); err != nil || found {
+		t.Fatalf("LoadCalibration() on empty index = %v, %v", found, err)
+	}
+	cal, err := FitCalibration(CalibrationPlatt, calibrationFixture())
+	if err != nil {
+		t.Fatalf("FitCalibration() error = %v", err)
+	}
+	cal.Fusion = FusionRRF
+	if err := s.SaveCalibration(ctx, cal); err != nil {
+		t.Fatalf("SaveCalibration() error = %v", err)
+	}
+	loaded, found, err := s.LoadCalibration(ctx)
+	if err != nil || !found || loaded.Method != CalibrationPlatt || len(loaded.Coefficients) != 4 {
+		t.Fatalf("LoadCalibration() = %+v, %v, %v", loaded, found, err)
+	}
+
+	// Both candidates look 92% similar, but only the semantic match is a likely duplicate.
+	fused := FuseResults(
+		[]VectorResult{{ID: "issue/A", VecScore: 0.92}, {ID: "issue/B", VecScore: 0.58}},
+		[]FTSResult{{ID: "issue/B", FTSScore: 0.92}},
+		nil, "", FuseConfig{SimilarityThreshold: 0.5, DuplicateThreshold: 0.8, MaxResults: 5, Calibration: &loaded},
+	)
+	flagged := map[string]bool{}
+	for _, result := range fused {
+		if !result.Calibrated {
+			t.Fatalf("result not marked calibrated: %+v", result)
+		}
+		flagged[result.ID] = result.IsDuplicate
+	}
+	if len(fused) != 2 || !flagged["issue/A"] || flagged["issue/B"] {
+		t.Fatalf("calibrated duplicates = %v, want only issue/A", flagged)
+	}
+}
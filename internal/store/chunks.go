@@ -0,0 +1,157 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	sqlite_vec "github.com/asg017/sqlite-vec-go-bindings/cgo"
+)
+
+// Chunk ids are "<item id>#<index>", so a chunk hit maps back to its item
+// without a join.
+const chunkIDSeparator = "#"
+
+func chunkID(itemID string, index int) string {
+	return fmt.Sprintf("%s%s%d", itemID, chunkIDSeparator, index)
+}
+
+func chunkItemID(chunkID string) string {
+	if i := strings.LastIndex(chunkID, chunkIDSeparator); i > 0 {
+		return chunkID[:i]
+	}
+	return chunkID
+}
+
+// ReplaceChunkVectors stores the chunk vectors of a long item in
+// items_chunks_vec, replacing whatever chunks it had. An empty slice just
+// removes them, which is what a shortened item needs.
+func (s *Store) ReplaceChunkVectors(ctx context.Context, itemID string, chunks [][]float32) (err error) {
+	if s == nil || s.db == nil {
+		return errors.New("store is not initialized")
+	}
+	if strings.TrimSpace(itemID) == "" {
+		return errors.New("item id is required")
+	}
+
+	serialized := make([][]byte, len(chunks))
+	for i, chunk := range chunks {
+		if len(chunk) == 0 {
+			return fmt.Errorf("chunk %d embedding is required", i)
+		}
+		if s.space.Dimensions > 0 && len(chunk) != s.space.Dimensions {
+			return fmt.Errorf("chunk %d embedding has %d dimensions, index expects %d for %s", i, len(chunk), s.space.Dimensions, s.space.Model)
+		}
+		if serialized[i], err = sqlite_vec.SerializeFloat32(chunk); err != nil {
+			return fmt.Errorf("serialize chunk embedding: %w", err)
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin replace chunks: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = deleteItemChunks(ctx, tx, itemID); err != nil {
+		return err
+	}
+	for i, blob := range serialized {
+		id := chunkID(itemID, i)
+		if err = insertVectorRow(ctx, tx, "items_chunks_vec", "chunk_id", id, itemID, blob); err != nil {
+			return fmt.Errorf("insert chunk vector %s: %w", id, err)
+		}
+		if _, err = tx.ExecContext(ctx, `INSERT INTO item_chunks(chunk_id, item_id, chunk_index) VALUES(?, ?, ?);`, id, itemID, i); err != nil {
+			return fmt.Errorf("insert chunk %s: %w", id, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit replace chunks: %w", err)
+	}
+	return nil
+}
+
+// LookupChunkVectors returns the stored chunk vectors of an item in chunk order.
+func (s *Store) LookupChunkVectors(ctx context.Context, itemID string) ([][]float32, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store is not initialized")
+	}
+
+	const query = `
+SELECT v.embedding
+FROM item_chunks c
+JOIN items_chunks_vec v ON v.chunk_id = c.chunk_id
+WHERE c.item_id = ?
+ORDER BY c.chunk_index ASC;
+`
+	rows, err := s.db.QueryContext(ctx, query, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("lookup chunk vectors: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([][]float32, 0)
+	for rows.Next() {
+		var blob []byte
+		if err := rows.Scan(&blob); err != nil {
+			return nil, fmt.Errorf("scan chunk vector: %w", err)
+		}
+		vec, err := decodeFloat32Vector(blob)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, vec)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate chunk vectors: %w", err)
+	}
+
+	return out, nil
+}
+
+// deleteItemChunks removes an item's chunk vectors. Rows are deleted by
+// primary key because vec0 cannot filter on a subquery without a full scan.
+func deleteItemChunks(ctx context.Context, tx *sql.Tx, itemID string) error {
+	ids, err := listItemChunkIDs(ctx, tx, itemID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM items_chunks_vec WHERE chunk_id = ?;`, id); err != nil {
+			return fmt.Errorf("delete chunk vector %s: %w", id, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM item_chunks WHERE item_id = ?;`, itemID); err != nil {
+		return fmt.Errorf("delete chunks %s: %w", itemID, err)
+	}
+	return nil
+}
+
+func listItemChunkIDs(ctx context.Context, ex queryExecer, itemID string) ([]string, error) {
+	rows, err := ex.QueryContext(ctx, `SELECT chunk_id FROM item_chunks WHERE item_id = ?;`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("list chunks %s: %w", itemID, err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan chunk id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate chunk ids: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestSearchVector_CombinesChunkHitsPerItem(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	s, err := OpenWithEmbedding(ctx, filepath.Join(t.TempDir(), "chunks.db"), EmbeddingSpace{Model: "m", Dimensions: 3})
+	if err != nil {
+		t.Fatalf("OpenWithEmbedding() error = %v", err)
+	}
+	defer func() {
+		if cerr := s.Close(); cerr != nil {
+			t.Fatalf("Close() error = %v", cerr)
+		}
+	}()
+
+	for i, id := range []string{"issue/1", "issue/2"} {
+		if err := insertItemFixture(ctx, s, id, "issue", i+1, "item "+id); err != nil {
+			t.Fatalf("insertItemFixture() error = %v", err)
+		}
+	}
+	// issue/1's whole-item vector is off-topic, but one chunk deep in its body
+	// carries the shared stack trace.
+	if err := s.UpsertVector(ctx, "issue/1", []float32{1, 0, 0}); err != nil {
+		t.Fatalf("UpsertVector() error = %v", err)
+	}
+	if err := s.ReplaceChunkVectors(ctx, "issue/1", [][]float32{{1, 0, 0}, {0, 0.6, 0.8}, {0, 0, 1}}); err != nil {
+		t.Fatalf("ReplaceChunkVectors() error = %v", err)
+	}
+	if err := s.UpsertVector(ctx, "issue/2", []float32{0.6, 0.8, 0}); err != nil {
+		t.Fatalf("UpsertVector() error = %v", err)
+	}
+
+	hits, err := s.SearchVector(ctx, []float32{0, 0, 1}, "", 5, SearchFilter{})
+	if err != nil {
+		t.Fatalf("SearchVector() error = %v", err)
+	}
+	if len(hits) != 2 || hits[0].ID != "issue/1" || hits[0].VecScore < 0.99 {
+		t.Fatalf("max-sim hits = %+v, want issue/1 first via its chunk", hits)
+	}
+
+	// Top-k mean averages the best three: chunk 1.0, chunk 0.8, item 0.0.
+	s.SetChunkScoring(ChunkScoring{Mode: ChunkScoringTopKMean, TopK: 3})
+	hits, err = s.SearchVectors(ctx, [][]float32{{0, 0, 1}}, "", 5, SearchFilter{})
+	if err != nil {
+		t.Fatalf("SearchVectors() error = %v", err)
+	}
+	if got := hits[0].VecScore; hits[0].ID != "issue/1" || got < 0.59 || got > 0.61 {
+		t.Fatalf("top-k mean hits = %+v, want issue/1 at 0.6", hits)
+	}
+
+	// Replacing with fewer chunks drops the old ones; deleting the item drops the rest.
+	if err := s.ReplaceChunkVectors(ctx, "issue/1", [][]float32{{1, 0, 0}}); err != nil {
+		t.Fatalf("ReplaceChunkVectors() error = %v", err)
+	}
+	if chunks, err := s.LookupChunkVectors(ctx, "issue/1"); err != nil || len(chunks) != 1 {
+		t.Fatalf("LookupChunkVectors() = %v, %v; want 1 chunk", chunks, err)
+	}
+	if err := s.DeleteItem(ctx, "issue/1"); err != nil {
+		t.Fatalf("DeleteItem() error = %v", err)
+	}
+	var remaining int
+	if err := s.DB().QueryRowContext(ctx, `SELECT COUNT(*) FROM items_chunks_vec;`).Scan(&remaining); err != nil || remaining != 0 {
+		t.Fatalf("chunk vectors after delete = %d, %v", remaining, err)
+	}
+
+	if err := s.ReplaceChunkVectors(ctx, "issue/2", [][]float32{{1, 0}}); err == nil {
+		t.Fatalf("expected dimension mismatch error")
+	}
+}
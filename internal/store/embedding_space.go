@@ -0,0 +1,259 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// EmbeddingModelKey and EmbeddingDimensionsKey record which model produced
+	// the vectors in items_vec, so vectors from different models never mix.
+	EmbeddingModelKey      = "embedding.model"
+	EmbeddingDimensionsKey = "embedding.dimensions"
+
+	// Indexes created before the embedding space was recorded were always built
+	// by GitHub Models text-embedding-3-small at 1536 dimensions.
+	legacyEmbeddingModel   = "text-embedding-3-small"
+	legacyVectorDimensions = 1536
+)
+
+var vectorDimensionsPattern = regexp.MustCompile(`float\[(\d+)\]`)
+
+// EmbeddingSpace identifies the model and vector size an index was built with.
+type EmbeddingSpace struct {
+	Model      string
+	Dimensions int
+}
+
+func (e EmbeddingSpace) String() string {
+	return fmt.Sprintf("%s (%d dims)", e.Model, e.Dimensions)
+}
+
+// EmbeddingSpaceMismatchError reports that the index holds vectors from a
+// different model or dimension than the one configured.
+type EmbeddingSpaceMismatchError struct {
+	Stored     EmbeddingSpace
+	Configured EmbeddingSpace
+}
+
+func (e *EmbeddingSpaceMismatchError) Error() string {
+	return fmt.Sprintf("index was embedded with %s but %s is configured; vectors from different models cannot be compared, run the reindex command to re-embed the index or switch back to the original model",
+		e.Stored, e.Configured)
+}
+
+// OpenWithEmbedding opens the store and binds it to the configured embedding
+// space. A mismatch closes the store and returns *EmbeddingSpaceMismatchError.
+func OpenWithEmbedding(ctx context.Context, dbPath string, space EmbeddingSpace) (*Store, error) {
+	s, err := Open(ctx, dbPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.EnsureEmbeddingSpace(ctx, space); err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// EnsureEmbeddingSpace records the configured model and dimension on first use
+// and sizes items_vec to match. While items_vec is empty it is rebuilt freely;
+// once it holds vectors, a different model or dimension is a mismatch error.
+func (s *Store) EnsureEmbeddingSpace(ctx context.Context, configured EmbeddingSpace) error {
+	if s == nil || s.db == nil {
+		return errors.New("store is not initialized")
+	}
+	if strings.TrimSpace(configured.Model) == "" || configured.Dimensions <= 0 {
+		return errors.New("embedding model and dimensions are required")
+	}
+
+	stored, found, err := s.StoredEmbeddingSpace(ctx)
+	if err != nil {
+		return err
+	}
+	if found && stored == configured {
+		s.space = configured
+		return nil
+	}
+
+	vectors, err := s.countVectors(ctx)
+	if err != nil {
+		return err
+	}
+	if vectors == 0 {
+		return s.ResetEmbeddingSpace(ctx, configured)
+	}
+	if !found {
+		stored, err = s.inferLegacyEmbeddingSpace(ctx)
+		if err != nil {
+			return err
+		}
+		if err := s.writeEmbeddingSpace(ctx, s.db, stored); err != nil {
+			return err
+		}
+	}
+
+	if stored != configured {
+		return &EmbeddingSpaceMismatchError{Stored: stored, Configured: configured}
+	}
+	s.space = configured
+	return nil
+}
+
+// StoredEmbeddingSpace reads the recorded model and dimension, if any.
+func (s *Store) StoredEmbeddingSpace(ctx context.Context) (EmbeddingSpace, bool, error) {
+	model, foundModel, err := s.GetMeta(ctx, EmbeddingModelKey)
+	if err != nil {
+		return EmbeddingSpace{}, false, err
+	}
+	rawDims, foundDims, err := s.GetMeta(ctx, EmbeddingDimensionsKey)
+	if err != nil {
+		return EmbeddingSpace{}, false, err
+	}
+	if !foundModel || !foundDims {
+		return EmbeddingSpace{}, false, nil
+	}
+
+	dims, err := strconv.Atoi(strings.TrimSpace(rawDims))
+	if err != nil {
+		return EmbeddingSpace{}, false, fmt.Errorf("parse %s: %w", EmbeddingDimensionsKey, err)
+	}
+	return EmbeddingSpace{Model: model, Dimensions: dims}, true, nil
+}
+
+// ResetEmbeddingSpace drops every stored vector, recreates items_vec and
+// items_chunks_vec for the new space and queues all items for re-embedding. Item rows, FTS and pair
+// feedback are kept, so keyword search keeps working while vectors refill.
+func (s *Store) ResetEmbeddingSpace(ctx context.Context, space EmbeddingSpace) (err error) {
+	if s == nil || s.db == nil {
+		return errors.New("store is not initialized")
+	}
+	if strings.TrimSpace(space.Model) == "" || space.Dimensions <= 0 {
+		return errors.New("embedding model and dimensions are required")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin reset embedding space: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	queuedAt := time.Now().UTC().Format(time.RFC3339Nano)
+	stmts := []struct {
+		query string
+		args  []any
+	}{
+		{query: `DROP TABLE IF EXISTS items_vec;`},
+		{query: `DROP TABLE IF EXISTS items_chunks_vec;`},
+		{query: `DELETE FROM item_chunks;`},
+		{query: `UPDATE items SET embedding_model = '' WHERE embedding_model <> '';`},
+		{
+			query: `
+INSERT INTO pending_embeddings(id, reason, queued_at)
+SELECT id, ?, ? FROM items
+WHERE trim(title) <> '' OR trim(body) <> ''
+ON CONFLICT(id) DO NOTHING;
+`,
+			args: []any{"embedding model changed to " + space.Model, queuedAt},
+		},
+	}
+	for _, stmt := range stmts {
+		if _, err = tx.ExecContext(ctx, stmt.query, stmt.args...); err != nil {
+			return fmt.Errorf("reset embedding space: %w", err)
+		}
+	}
+	if err = deleteMetaKeys(ctx, tx, time.Now().UTC(), CalibrationKey); err != nil {
+		return err
+	}
+	if err = createVectorTables(ctx, tx, space.Dimensions); err != nil {
+		return fmt.Errorf("create vector table: %w", err)
+	}
+	if err = s.writeEmbeddingSpace(ctx, tx, space); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit reset embedding space: %w", err)
+	}
+
+	s.space = space
+	return nil
+}
+
+func (s *Store) writeEmbeddingSpace(ctx context.Context, ex execer, space EmbeddingSpace) error {
+	const stmt = `
+INSERT INTO index_meta(key, value, updated_at) VALUES(?, ?, ?)
+ON CONFLICT(key) DO UPDATE SET
+    value=excluded.value,
+    updated_at=excluded.updated_at;
+`
+	updatedAt := time.Now().UTC().Format(time.RFC3339Nano)
+	for key, value := range map[string]string{
+		EmbeddingModelKey:      space.Model,
+		EmbeddingDimensionsKey: strconv.Itoa(space.Dimensions),
+	} {
+		if _, err := ex.ExecContext(ctx, stmt, key, value, updatedAt); err != nil {
+			return fmt.Errorf("record embedding space: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) countVectors(ctx context.Context) (int, error) {
+	var count int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM items_vec;`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count vectors: %w", err)
+	}
+	return count, nil
+}
+
+// inferLegacyEmbeddingSpace describes vectors written before the space was
+// recorded, using the most common embedding_model and the table's dimension.
+func (s *Store) inferLegacyEmbeddingSpace(ctx context.Context) (EmbeddingSpace, error) {
+	space := EmbeddingSpace{Model: legacyEmbeddingModel, Dimensions: legacyVectorDimensions}
+
+	const modelQuery = `
+SELECT embedding_model FROM items
+WHERE embedding_model <> ''
+GROUP BY embedding_model
+ORDER BY COUNT(*) DESC, embedding_model ASC
+LIMIT 1;
+`
+	var model string
+	err := s.db.QueryRowContext(ctx, modelQuery).Scan(&model)
+	switch {
+	case err == nil:
+		space.Model = model
+	case !errors.Is(err, sql.ErrNoRows):
+		return EmbeddingSpace{}, fmt.Errorf("infer embedding model: %w", err)
+	}
+
+	var tableSQL string
+	if err := s.db.QueryRowContext(ctx, `SELECT sql FROM sqlite_master WHERE name = 'items_vec';`).Scan(&tableSQL); err != nil {
+		return EmbeddingSpace{}, fmt.Errorf("inspect vector table: %w", err)
+	}
+	if match := vectorDimensionsPattern.FindStringSubmatch(tableSQL); match != nil {
+		if dims, err := strconv.Atoi(match[1]); err == nil {
+			space.Dimensions = dims
+		}
+		return space, nil
+	}
+
+	// Fallback BLOB table: read the size of one stored vector.
+	var blob []byte
+	if err := s.db.QueryRowContext(ctx, `SELECT embedding FROM items_vec LIMIT 1;`).Scan(&blob); err != nil {
+		return EmbeddingSpace{}, fmt.Errorf("inspect stored vector: %w", err)
+	}
+	if len(blob) > 0 && len(blob)%4 == 0 {
+		space.Dimensions = len(blob) / 4
+	}
+	return space, nil
+}
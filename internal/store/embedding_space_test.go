@@ -0,0 +1,128 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnsureEmbeddingSpace_SizesEmptyIndexToModel(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "space.db")
+	small := EmbeddingSpace{Model: "nomic-embed-text", Dimensions: 4}
+
+	s, err := OpenWithEmbedding(ctx, path, small)
+	if err != nil {
+		t.Fatalf("OpenWithEmbedding() error = %v", err)
+	}
+	if err := insertItemFixture(ctx, s, "issue/1", "issue", 1, "login timeout"); err != nil {
+		t.Fatalf("insertItemFixture() error = %v", err)
+	}
+	if err := s.UpsertVector(ctx, "issue/1", []float32{1, 0, 0, 0}); err != nil {
+		t.Fatalf("UpsertVector() error = %v", err)
+	}
+	if err := s.UpsertVector(ctx, "issue/1", makeVec1536(1)); err == nil {
+		t.Fatalf("expected dimension mismatch on insert")
+	}
+	results, err := s.SearchVector(ctx, []float32{1, 0, 0, 0}, "", 5, SearchFilter{})
+	if err != nil {
+		t.Fatalf("SearchVector() error = %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "issue/1" {
+		t.Fatalf("unexpected search results: %+v", results)
+	}
+
+	stored, found, err := s.StoredEmbeddingSpace(ctx)
+	if err != nil || !found || stored != small {
+		t.Fatalf("StoredEmbeddingSpace() = %+v, %v, %v", stored, found, err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	_, err = OpenWithEmbedding(ctx, path, EmbeddingSpace{Model: "text-embedding-3-small", Dimensions: 1536})
+	var mismatch *EmbeddingSpaceMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("OpenWithEmbedding() error = %v, want mismatch", err)
+	}
+	if mismatch.Stored != small {
+		t.Fatalf("mismatch stored = %+v, want %+v", mismatch.Stored, small)
+	}
+}
+
+func TestResetEmbeddingSpace_DropsVectorsAndQueuesItems(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	s, err := OpenWithEmbedding(ctx, filepath.Join(t.TempDir(), "reset.db"), EmbeddingSpace{Model: "old", Dimensions: 1536})
+	if err != nil {
+		t.Fatalf("OpenWithEmbedding() error = %v", err)
+	}
+	defer func() {
+		if cerr := s.Close(); cerr != nil {
+			t.Fatalf("Close() error = %v", cerr)
+		}
+	}()
+
+	if err := insertItemFixture(ctx, s, "issue/1", "issue", 1, "login timeout"); err != nil {
+		t.Fatalf("insertItemFixture() error = %v", err)
+	}
+	if err := s.UpsertVector(ctx, "issue/1", makeVec1536(1)); err != nil {
+		t.Fatalf("UpsertVector() error = %v", err)
+	}
+
+	next := EmbeddingSpace{Model: "new", Dimensions: 8}
+	if err := s.EnsureEmbeddingSpace(ctx, next); err == nil {
+		t.Fatalf("expected mismatch while old vectors exist")
+	}
+	if err := s.ResetEmbeddingSpace(ctx, next); err != nil {
+		t.Fatalf("ResetEmbeddingSpace() error = %v", err)
+	}
+	if err := s.EnsureEmbeddingSpace(ctx, next); err != nil {
+		t.Fatalf("EnsureEmbeddingSpace() after reset error = %v", err)
+	}
+
+	pending, err := s.ListPendingEmbeddings(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListPendingEmbeddings() error = %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != "issue/1" {
+		t.Fatalf("expected issue/1 queued for re-embedding, got %+v", pending)
+	}
+	if err := s.UpsertVector(ctx, "issue/1", make([]float32, 8)); err != nil {
+		t.Fatalf("UpsertVector() with new dimension error = %v", err)
+	}
+}
+
+func TestEnsureEmbeddingSpace_InfersLegacyIndex(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	s, err := Open(ctx, filepath.Join(t.TempDir(), "legacy.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() {
+		if cerr := s.Close(); cerr != nil {
+			t.Fatalf("Close() error = %v", cerr)
+		}
+	}()
+
+	if err := insertItemFixture(ctx, s, "issue/1", "issue", 1, "login timeout"); err != nil {
+		t.Fatalf("insertItemFixture() error = %v", err)
+	}
+	if err := s.UpsertVector(ctx, "issue/1", makeVec1536(1)); err != nil {
+		t.Fatalf("UpsertVector() error = %v", err)
+	}
+
+	legacy := EmbeddingSpace{Model: legacyEmbeddingModel, Dimensions: legacyVectorDimensions}
+	if err := s.EnsureEmbeddingSpace(ctx, legacy); err != nil {
+		t.Fatalf("EnsureEmbeddingSpace(legacy) error = %v", err)
+	}
+	if err := s.EnsureEmbeddingSpace(ctx, EmbeddingSpace{Model: "hashed-ngram-v1", Dimensions: 1536}); err == nil {
+		t.Fatalf("expected mismatch for a different model with the same dimension")
+	}
+}
@@ -0,0 +1,169 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PairVerdict is a maintainer decision about whether two items are duplicates.
+type PairVerdict string
+
+const (
+	PairVerdictDuplicate    PairVerdict = "duplicate"
+	PairVerdictNotDuplicate PairVerdict = "not_duplicate"
+)
+
+// PairFeedback is one recorded verdict between two indexed items.
+type PairFeedback struct {
+	ItemA     string
+	ItemB     string
+	Verdict   PairVerdict
+	Actor     string
+	CreatedAt time.Time
+}
+
+// RecordPairFeedback stores a verdict for an unordered item pair. The latest verdict wins.
+func (s *Store) RecordPairFeedback(ctx context.Context, fb PairFeedback) error {
+	if s == nil || s.db == nil {
+		return errors.New("store is not initialized")
+	}
+	a, b := orderPair(strings.TrimSpace(fb.ItemA), strings.TrimSpace(fb.ItemB))
+	if a == "" || b == "" {
+		return errors.New("both item ids are required")
+	}
+	if a == b {
+		return errors.New("an item cannot be paired with itself")
+	}
+	switch fb.Verdict {
+	case PairVerdictDuplicate, PairVerdictNotDuplicate:
+	default:
+		return fmt.Errorf("unknown pair verdict %q", fb.Verdict)
+	}
+
+	createdAt := fb.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+
+	const stmt = `
+INSERT INTO pair_feedback(item_a, item_b, verdict, actor, created_at) VALUES(?, ?, ?, ?, ?)
+ON CONFLICT(item_a, item_b) DO UPDATE SET
+    verdict=excluded.verdict,
+    actor=excluded.actor,
+    created_at=excluded.created_at;
+`
+	if _, err := s.db.ExecContext(ctx, stmt, a, b, string(fb.Verdict), fb.Actor, createdAt.Format(time.RFC3339Nano)); err != nil {
+		return fmt.Errorf("record pair feedback: %w", err)
+	}
+	return nil
+}
+
+// PairVerdictsFor returns every recorded verdict involving id, keyed by the other item's id.
+func (s *Store) PairVerdictsFor(ctx context.Context, id string) (map[string]PairVerdict, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store is not initialized")
+	}
+
+	const query = `
+SELECT item_a, item_b, verdict
+FROM pair_feedback
+WHERE item_a = ? OR item_b = ?;
+`
+	rows, err := s.db.QueryContext(ctx, query, id, id)
+	if err != nil {
+		return nil, fmt.Errorf("query pair feedback: %w", err)
+	}
+	defer rows.Close()
+
+	out := map[string]PairVerdict{}
+	for rows.Next() {
+		var a, b, verdict string
+		if err := rows.Scan(&a, &b, &verdict); err != nil {
+			return nil, fmt.Errorf("scan pair feedback row: %w", err)
+		}
+		other := a
+		if a == id {
+			other = b
+		}
+		out[other] = PairVerdict(verdict)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate pair feedback rows: %w", err)
+	}
+
+	return out, nil
+}
+
+// LookupItemIDByNumber resolves "#123" to issue/123 or pr/123, whichever is indexed.
+func (s *Store) LookupItemIDByNumber(ctx context.Context, number int) (string, bool, error) {
+	if s == nil || s.db == nil {
+		return "", false, errors.New("store is not initialized")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM items WHERE number = ? ORDER BY id LIMIT 1;`, number)
+	if err != nil {
+		return "", false, fmt.Errorf("lookup item by number: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return "", false, rows.Err()
+	}
+	var id string
+	if err := rows.Scan(&id); err != nil {
+		return "", false, fmt.Errorf("scan item id: %w", err)
+	}
+	return id, true, nil
+}
+
+// ListPairFeedback returns every recorded verdict, oldest first, for evaluation and export.
+func (s *Store) ListPairFeedback(ctx context.Context) ([]PairFeedback, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store is not initialized")
+	}
+
+	const query = `
+SELECT item_a, item_b, verdict, actor, created_at
+FROM pair_feedback
+ORDER BY created_at ASC, item_a ASC, item_b ASC;
+`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query pair feedback: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]PairFeedback, 0)
+	for rows.Next() {
+		var (
+			fb        PairFeedback
+			verdict   string
+			createdAt string
+		)
+		if err := rows.Scan(&fb.ItemA, &fb.ItemB, &verdict, &fb.Actor, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan pair feedback row: %w", err)
+		}
+		fb.Verdict = PairVerdict(verdict)
+		if parsed, err := time.Parse(time.RFC3339Nano, createdAt); err == nil {
+			fb.CreatedAt = parsed
+		}
+		out = append(out, fb)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate pair feedback rows: %w", err)
+	}
+
+	return out, nil
+}
+
+func orderPair(a, b string) (string, string) {
+	if b < a {
+		return b, a
+	}
+	return a, b
+}
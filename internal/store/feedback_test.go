@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestPairFeedback_RecordIsSymmetricAndLatestWins(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	s, err := Open(ctx, filepath.Join(t.TempDir(), "feedback.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() {
+		if cerr := s.Close(); cerr != nil {
+			t.Fatalf("Close() error = %v", cerr)
+		}
+	}()
+
+	if err := s.RecordPairFeedback(ctx, PairFeedback{ItemA: "issue/9", ItemB: "issue/2", Verdict: PairVerdictDuplicate, Actor: "maint"}); err != nil {
+		t.Fatalf("RecordPairFeedback() error = %v", err)
+	}
+	if err := s.RecordPairFeedback(ctx, PairFeedback{ItemA: "issue/2", ItemB: "issue/9", Verdict: PairVerdictNotDuplicate, Actor: "maint"}); err != nil {
+		t.Fatalf("second RecordPairFeedback() error = %v", err)
+	}
+
+	verdicts, err := s.PairVerdictsFor(ctx, "issue/9")
+	if err != nil {
+		t.Fatalf("PairVerdictsFor() error = %v", err)
+	}
+	if len(verdicts) != 1 || verdicts["issue/2"] != PairVerdictNotDuplicate {
+		t.Fatalf("unexpected verdicts for issue/9: %+v", verdicts)
+	}
+
+	verdicts, err = s.PairVerdictsFor(ctx, "issue/2")
+	if err != nil {
+		t.Fatalf("PairVerdictsFor() error = %v", err)
+	}
+	if verdicts["issue/9"] != PairVerdictNotDuplicate {
+		t.Fatalf("unexpected verdicts for issue/2: %+v", verdicts)
+	}
+
+	all, err := s.ListPairFeedback(ctx)
+	if err != nil {
+		t.Fatalf("ListPairFeedback() error = %v", err)
+	}
+	if len(all) != 1 || all[0].ItemA != "issue/2" || all[0].ItemB != "issue/9" || all[0].Actor != "maint" || all[0].CreatedAt.IsZero() {
+		t.Fatalf("unexpected listed feedback: %+v", all)
+	}
+
+	if err := s.RecordPairFeedback(ctx, PairFeedback{ItemA: "issue/2", ItemB: "issue/2", Verdict: PairVerdictDuplicate}); err == nil {
+		t.Fatalf("expected self-pair validation error")
+	}
+	if err := s.RecordPairFeedback(ctx, PairFeedback{ItemA: "issue/2", ItemB: "issue/3", Verdict: "maybe"}); err == nil {
+		t.Fatalf("expected unknown verdict validation error")
+	}
+}
+
+func TestLookupItemIDByNumber(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	s, err := Open(ctx, filepath.Join(t.TempDir(), "lookup-number.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() {
+		if cerr := s.Close(); cerr != nil {
+			t.Fatalf("Close() error = %v", cerr)
+		}
+	}()
+
+	if err := insertItemFixture(ctx, s, "pr/45", "pr", 45, "fix"); err != nil {
+		t.Fatalf("insertItemFixture() error = %v", err)
+	}
+
+	id, found, err := s.LookupItemIDByNumber(ctx, 45)
+	if err != nil || !found || id != "pr/45" {
+		t.Fatalf("LookupItemIDByNumber(45) = %q, %v, %v", id, found, err)
+	}
+	if _, found, err := s.LookupItemIDByNumber(ctx, 46); err != nil || found {
+		t.Fatalf("LookupItemIDByNumber(46) found=%v err=%v, want not found", found, err)
+	}
+}
@@ -0,0 +1,217 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SearchFilter restricts which indexed items a search can return as
+// candidates. It is passed to each search, so one caller's filter never
+// leaks into another's. The zero value matches everything.
+type SearchFilter struct {
+	// Types keeps only these item types ("issue", "pr"); empty keeps all.
+	Types []string
+	// States keeps only items in these states; empty keeps all.
+	States []string
+	// IncludeLabels keeps only items carrying at least one of these labels.
+	IncludeLabels []string
+	// ExcludeLabels drops items carrying any of these labels.
+	ExcludeLabels []string
+	CreatedAfter  time.Time
+	UpdatedAfter  time.Time
+	// ClosedAfter drops items closed before it, judged by closed_at or, for
+	// rows indexed without one, updated_at. Open items are kept however old
+	// they are.
+	ClosedAfter time.Time
+}
+
+// activeUnixOpen is the active_unix of open items, so "closed after" filters
+// become a single range check vec0 can apply during k-NN.
+const activeUnixOpen = "9223372036854775807"
+
+// vectorMetaColumns are the item fields copied next to every vector, so
+// filters run inside the vec0 scan instead of after it.
+const vectorMetaColumns = `item_type, state, created_unix, updated_unix, active_unix`
+
+// vectorMetaSelect computes the vectorMetaColumns after item_type from an
+// items row aliased i, falling back to an open, undated item when it is missing.
+const vectorMetaSelect = `
+    COALESCE(i.state, 'open'),
+    COALESCE(unixepoch(i.created_at), 0),
+    COALESCE(unixepoch(i.updated_at), 0),
+    CASE WHEN COALESCE(i.state, 'open') = 'open' THEN ` + activeUnixOpen + ` ELSE ` + closedUnix + ` END`
+
+// closedUnix is when an items row aliased i was closed: closed_at, or
+// updated_at for rows indexed before closed_at was stored.
+const closedUnix = `COALESCE(unixepoch(NULLIF(i.closed_at, '')), unixepoch(i.updated_at), 0)`
+
+func (f SearchFilter) normalized() SearchFilter {
+	out := f
+	out.Types = nil
+	for _, kind := range f.Types {
+		out.Types = append(out.Types, normalizeFilterType(kind))
+	}
+	out.States = lowerAll(f.States)
+	out.IncludeLabels = lowerAll(f.IncludeLabels)
+	out.ExcludeLabels = lowerAll(f.ExcludeLabels)
+	return out
+}
+
+func normalizeFilterType(kind string) string {
+	switch kind = strings.ToLower(strings.TrimSpace(kind)); kind {
+	case "pull_request", "pull-request", "pull":
+		return "pr"
+	default:
+		return kind
+	}
+}
+
+func lowerAll(values []string) []string {
+	var out []string
+	for _, value := range values {
+		if value = strings.ToLower(strings.TrimSpace(value)); value != "" {
+			out = append(out, value)
+		}
+	}
+	return out
+}
+
+// vectorClause renders the constraints vec0 can evaluate on its metadata
+// columns as " AND ..." terms. Labels are not stored with vectors; they are
+// checked when the hits are joined back to items.
+func (f SearchFilter) vectorClause() (string, []any) {
+	var b strings.Builder
+	var args []any
+	writeIn(&b, &args, "item_type", f.Types)
+	writeIn(&b, &args, "state", f.States)
+	writeSince(&b, &args, "created_unix", f.CreatedAfter)
+	writeSince(&b, &args, "updated_unix", f.UpdatedAfter)
+	writeSince(&b, &args, "active_unix", f.ClosedAfter)
+	return b.String(), args
+}
+
+// itemClause renders every constraint against an items row as " AND ..."
+// terms. alias is the table alias, or "" for the bare table.
+func (f SearchFilter) itemClause(alias string) (string, []any) {
+	col := func(name string) string {
+		if alias == "" {
+			return name
+		}
+		return alias + "." + name
+	}
+
+	var b strings.Builder
+	var args []any
+	writeIn(&b, &args, col("type"), f.Types)
+	writeIn(&b, &args, col("state"), f.States)
+	writeSince(&b, &args, "unixepoch("+col("created_at")+")", f.CreatedAfter)
+	writeSince(&b, &args, "unixepoch("+col("updated_at")+")", f.UpdatedAfter)
+	if !f.ClosedAfter.IsZero() {
+		closed := fmt.Sprintf("COALESCE(unixepoch(NULLIF(%s, '')), unixepoch(%s), 0)", col("closed_at"), col("updated_at"))
+		fmt.Fprintf(&b, " AND (%s = 'open' OR %s >= ?)", col("state"), closed)
+		args = append(args, f.ClosedAfter.Unix())
+	}
+	labels := fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(%s) WHERE lower(json_each.value) IN (", col("labels"))
+	if len(f.IncludeLabels) > 0 {
+		b.WriteString(" AND " + labels + placeholders(len(f.IncludeLabels)) + "))")
+		args = appendStrings(args, f.IncludeLabels)
+	}
+	if len(f.ExcludeLabels) > 0 {
+		b.WriteString(" AND NOT " + labels + placeholders(len(f.ExcludeLabels)) + "))")
+		args = appendStrings(args, f.ExcludeLabels)
+	}
+	return b.String(), args
+}
+
+func writeIn(b *strings.Builder, args *[]any, column string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	fmt.Fprintf(b, " AND %s IN (%s)", column, placeholders(len(values)))
+	*args = appendStrings(*args, values)
+}
+
+func writeSince(b *strings.Builder, args *[]any, column string, since time.Time) {
+	if since.IsZero() {
+		return
+	}
+	fmt.Fprintf(b, " AND %s >= ?", column)
+	*args = append(*args, since.Unix())
+}
+
+func placeholders(n int) string {
+	return "?" + strings.Repeat(", ?", n-1)
+}
+
+func appendStrings(args []any, values []string) []any {
+	for _, value := range values {
+		args = append(args, value)
+	}
+	return args
+}
+
+// itemTypeFromID recovers the item type from ids built by BuildItemID, for
+// vectors written before their item row.
+func itemTypeFromID(id string) string {
+	kind, _, _ := strings.Cut(id, "/")
+	return kind
+}
+
+// insertVectorRow writes one vector together with the filter metadata of the
+// item it belongs to.
+func insertVectorRow(ctx context.Context, ex execer, table, keyColumn, key, itemID string, blob []byte) error {
+	query := fmt.Sprintf(`
+INSERT INTO %s(%s, embedding, %s)
+SELECT ?, ?, COALESCE(i.type, ?),%s
+FROM (SELECT ? AS id) k
+LEFT JOIN items i ON i.id = k.id;
+`, table, keyColumn, vectorMetaColumns, vectorMetaSelect)
+	_, err := ex.ExecContext(ctx, query, key, blob, itemTypeFromID(itemID), itemID)
+	return err
+}
+
+type queryExecer interface {
+	execer
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// syncVectorMeta copies an item's current state and timestamps onto its
+// whole-item and chunk vectors. The type never changes for an id.
+func syncVectorMeta(ctx context.Context, ex queryExecer, itemID string) error {
+	var state string
+	var created, updated, active int64
+	err := ex.QueryRowContext(ctx, `SELECT`+vectorMetaSelect+`
+FROM items i WHERE i.id = ?;`, itemID).Scan(&state, &created, &updated, &active)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read vector metadata %s: %w", itemID, err)
+	}
+
+	chunkIDs, err := listItemChunkIDs(ctx, ex, itemID)
+	if err != nil {
+		return err
+	}
+	update := func(table, keyColumn, key string) error {
+		query := fmt.Sprintf(`UPDATE %s SET state = ?, created_unix = ?, updated_unix = ?, active_unix = ? WHERE %s = ?;`, table, keyColumn)
+		if _, err := ex.ExecContext(ctx, query, state, created, updated, active, key); err != nil {
+			return fmt.Errorf("update vector metadata %s: %w", key, err)
+		}
+		return nil
+	}
+	if err := update("items_vec", "id", itemID); err != nil {
+		return err
+	}
+	for _, id := range chunkIDs {
+		if err := update("items_chunks_vec", "chunk_id", id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
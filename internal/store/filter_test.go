@@ -0,0 +1,196 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sqlite_vec "github.com/asg017/sqlite-vec-go-bindings/cgo"
+)
+
+func TestSearchFilter_AppliesToEverySearchBackend(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	s, err := OpenWithEmbedding(ctx, filepath.Join(t.TempDir(), "filter.db"), EmbeddingSpace{Model: "m", Dimensions: 3})
+	if err != nil {
+		t.Fatalf("OpenWithEmbedding() error = %v", err)
+	}
+	defer func() {
+		if cerr := s.Close(); cerr != nil {
+			t.Fatalf("Close() error = %v", cerr)
+		}
+	}()
+
+	now := time.Now().UTC()
+	old := now.AddDate(-2, 0, 0)
+	body := "the login page hangs forever after submitting valid credentials on slow networks"
+	items := []ItemRecord{
+		{ID: "issue/2", Type: "issue", Number: 2, State: "open", Labels: []string{"bug"}},
+		{ID: "pr/3", Type: "pr", Number: 3, State: "open"},
+		{ID: "issue/4", Type: "issue", Number: 4, State: "closed", CreatedAt: old, UpdatedAt: old},
+		{ID: "issue/5", Type: "issue", Number: 5, State: "open", Labels: []string{"WontFix"}},
+		{ID: "issue/6", Type: "issue", Number: 6, State: "open", CreatedAt: old, UpdatedAt: old},
+		// Closed long ago but touched since: the close time decides.
+		{ID: "issue/7", Type: "issue", Number: 7, State: "closed", CreatedAt: old, UpdatedAt: now, ClosedAt: old},
+	}
+	for _, rec := range items {
+		rec.Title = "login hangs"
+		rec.Body = body
+		if err := s.UpsertItem(ctx, rec); err != nil {
+			t.Fatalf("UpsertItem(%s) error = %v", rec.ID, err)
+		}
+		if err := s.UpsertVector(ctx, rec.ID, []float32{1, 0, 0}); err != nil {
+			t.Fatalf("UpsertVector(%s) error = %v", rec.ID, err)
+		}
+	}
+
+	filter := SearchFilter{
+		Types:         []string{"issue"},
+		ExcludeLabels: []string{"wontfix"},
+		ClosedAfter:   now.AddDate(-1, 0, 0),
+	}
+	want := []string{"issue/2", "issue/6"}
+
+	vecHits, err := s.SearchVector(ctx, []float32{1, 0, 0}, "issue/1", 10, filter)
+	if err != nil {
+		t.Fatalf("SearchVector() error = %v", err)
+	}
+	requireIDs(t, "vector", vectorIDs(vecHits), want)
+
+	ftsHits, err := s.SearchFTS(ctx, "login hangs", "issue/1", 10, filter)
+	if err != nil {
+		t.Fatalf("SearchFTS() error = %v", err)
+	}
+	ids := make([]string, 0, len(ftsHits))
+	for _, hit := range ftsHits {
+		ids = append(ids, hit.ID)
+	}
+	requireIDs(t, "fts", ids, want)
+
+	nearHits, err := s.SearchNearDuplicates(ctx, "login hangs", body, "issue/1", 10, filter)
+	if err != nil {
+		t.Fatalf("SearchNearDuplicates() error = %v", err)
+	}
+	ids = ids[:0]
+	for _, hit := range nearHits {
+		ids = append(ids, hit.ID)
+	}
+	requireIDs(t, "near", ids, want)
+
+	// The brute-force path filters on the same metadata columns.
+	bruteHits, err := s.vectorOnlySearchBruteForce(ctx, "items_vec", "id", []float32{1, 0, 0}, 10, filter)
+	if err != nil {
+		t.Fatalf("vectorOnlySearchBruteForce() error = %v", err)
+	}
+	ids = ids[:0]
+	for _, hit := range bruteHits {
+		ids = append(ids, hit.ID)
+	}
+	requireIDs(t, "brute force", ids, []string{"issue/2", "issue/5", "issue/6"})
+
+	// Closing an item long ago moves its vector out of the filtered k-NN scan.
+	if _, err := s.UpdateItemMetadata(ctx, ItemRecord{ID: "issue/6", State: "closed", UpdatedAt: old, ClosedAt: old}); err != nil {
+		t.Fatalf("UpdateItemMetadata() error = %v", err)
+	}
+	knnHits, err := s.vectorOnlySearch(ctx, "items_vec", "id", []float32{1, 0, 0}, 10, filter)
+	if err != nil {
+		t.Fatalf("vectorOnlySearch() error = %v", err)
+	}
+	ids = ids[:0]
+	for _, hit := range knnHits {
+		ids = append(ids, hit.ID)
+	}
+	requireIDs(t, "after close", ids, []string{"issue/2", "issue/5"})
+
+	vecHits, err = s.SearchVector(ctx, []float32{1, 0, 0}, "issue/1", 10, SearchFilter{})
+	if err != nil {
+		t.Fatalf("SearchVector() error = %v", err)
+	}
+	if len(vecHits) != len(items) {
+		t.Fatalf("unfiltered hits = %d, want %d", len(vecHits), len(items))
+	}
+}
+
+func TestMigrateV10_CopiesVectorsWithMetadata(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "v9.db"))
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer func() {
+		if cerr := db.Close(); cerr != nil {
+			t.Fatalf("db.Close() error = %v", cerr)
+		}
+	}()
+
+	if err := ensureSchemaVersionTable(ctx, db); err != nil {
+		t.Fatalf("ensureSchemaVersionTable() error = %v", err)
+	}
+	for _, m := range migrations[:9] {
+		if err := applyMigration(ctx, db, m); err != nil {
+			t.Fatalf("apply v%d error = %v", m.version, err)
+		}
+	}
+	if _, err := db.ExecContext(ctx, `
+INSERT INTO items(id, type, number, title, body, state, created_at, updated_at)
+VALUES('pr/7', 'pr', 7, 't', 'b', 'closed', '2024-01-02T03:04:05.123456789Z', '2024-02-03T04:05:06Z');`); err != nil {
+		t.Fatalf("insert item error = %v", err)
+	}
+	blob, err := sqlite_vec.SerializeFloat32(make([]float32, legacyVectorDimensions))
+	if err != nil {
+		t.Fatalf("SerializeFloat32() error = %v", err)
+	}
+	// Recreate items_vec the way v9 binaries built it, without metadata columns.
+	if _, err := db.ExecContext(ctx, `DROP TABLE items_vec;`); err != nil {
+		t.Fatalf("drop items_vec error = %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `CREATE VIRTUAL TABLE items_vec USING vec0(id TEXT PRIMARY KEY, embedding float[1536] distance_metric=cosine);`); err != nil {
+		t.Fatalf("create v9 items_vec error = %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO items_vec(id, embedding) VALUES('pr/7', ?);`, blob); err != nil {
+		t.Fatalf("insert v9 vector error = %v", err)
+	}
+
+	if err := ApplyMigrations(ctx, db); err != nil {
+		t.Fatalf("ApplyMigrations() error = %v", err)
+	}
+
+	var kind, state string
+	var created, active int64
+	err = db.QueryRowContext(ctx, `SELECT item_type, state, created_unix, active_unix FROM items_vec WHERE id = 'pr/7';`).Scan(&kind, &state, &created, &active)
+	if err != nil {
+		t.Fatalf("read migrated vector error = %v", err)
+	}
+	if kind != "pr" || state != "closed" || created != 1704164645 || active != 1706933106 {
+		t.Fatalf("migrated metadata = %s %s %d %d", kind, state, created, active)
+	}
+}
+
+func vectorIDs(hits []VectorResult) []string {
+	ids := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func requireIDs(t *testing.T, label string, got, want []string) {
+	t.Helper()
+	seen := map[string]bool{}
+	for _, id := range got {
+		seen[id] = true
+	}
+	if len(got) != len(want) {
+		t.Fatalf("%s ids = %v, want %v", label, got, want)
+	}
+	for _, id := range want {
+		if !seen[id] {
+			t.Fatalf("%s ids = %v, want %v", label, got, want)
+		}
+	}
+}
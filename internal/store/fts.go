@@ -7,6 +7,7 @@ import (
 	"math"
 	"sort"
 	"strings"
+	"time"
 	"unicode"
 )
 
@@ -20,6 +21,9 @@ type FTSResult struct {
 	URL      string
 	RawBM25  float64
 	FTSScore float64
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
 var ftsStopWords = map[string]struct{}{
@@ -28,10 +32,13 @@ var ftsStopWords = map[string]struct{}{
 	"the": {}, "to": {}, "was": {}, "were": {}, "will": {}, "with": {}, "this": {}, "these": {}, "those": {},
 }
 
-func (s *Store) SearchFTS(ctx context.Context, query string, excludeID string, limit int) ([]FTSResult, error) {
+// SearchFTS ranks items by BM25 keyword match, keeping only those filter
+// matches.
+func (s *Store) SearchFTS(ctx context.Context, query string, excludeID string, limit int, filter SearchFilter) ([]FTSResult, error) {
 	if s == nil || s.db == nil {
 		return nil, errors.New("store is not initialized")
 	}
+	filter = filter.normalized()
 
 	if limit <= 0 {
 		return []FTSResult{}, nil
@@ -43,7 +50,7 @@ func (s *Store) SearchFTS(ctx context.Context, query string, excludeID string, l
 	}
 
 	ftsQuery := buildFTS5Query(query)
-	results, err := s.searchFTSNative(ctx, ftsQuery, excludeID, limit)
+	results, err := s.searchFTSNative(ctx, ftsQuery, excludeID, limit, filter)
 	if err == nil {
 		return results, nil
 	}
@@ -52,7 +59,7 @@ func (s *Store) SearchFTS(ctx context.Context, query string, excludeID string, l
 		return nil, fmt.Errorf("fts query failed: %w", err)
 	}
 
-	return s.searchFTSFallback(ctx, terms, excludeID, limit)
+	return s.searchFTSFallback(ctx, terms, excludeID, limit, filter)
 }
 
 func buildFTS5Query(input string) string {
@@ -75,20 +82,22 @@ func normalizeBM25(rawBM25 float64) float64 {
 	return abs / (1.0 + abs)
 }
 
-func (s *Store) searchFTSNative(ctx context.Context, ftsQuery, excludeID string, limit int) ([]FTSResult, error) {
-	const query = `
+func (s *Store) searchFTSNative(ctx context.Context, ftsQuery, excludeID string, limit int, filter SearchFilter) ([]FTSResult, error) {
+	filterSQL, filterArgs := filter.itemClause("i")
+	query := `
 SELECT
-    i.id, i.type, i.number, i.title, i.state, i.url,
+    i.id, i.type, i.number, i.title, i.state, i.url, i.created_at, i.updated_at,
     bm25(items_fts, 10.0, 1.0) AS score
 FROM items_fts f
 JOIN items i ON i.rowid = f.rowid
 WHERE items_fts MATCH ?
-  AND i.id != ?
+  AND i.id != ?` + filterSQL + `
 ORDER BY score ASC
 LIMIT ?;
 `
 
-	rows, err := s.db.QueryContext(ctx, query, ftsQuery, excludeID, limit)
+	args := append([]any{ftsQuery, excludeID}, filterArgs...)
+	rows, err := s.db.QueryContext(ctx, query, append(args, limit)...)
 	if err != nil {
 		return nil, err
 	}
@@ -97,6 +106,7 @@ LIMIT ?;
 	results := make([]FTSResult, 0, limit)
 	for rows.Next() {
 		var out FTSResult
+		var createdAt, updatedAt string
 		if err := rows.Scan(
 			&out.ID,
 			&out.Type,
@@ -104,10 +114,14 @@ LIMIT ?;
 			&out.Title,
 			&out.State,
 			&out.URL,
+			&createdAt,
+			&updatedAt,
 			&out.RawBM25,
 		); err != nil {
 			return nil, fmt.Errorf("scan fts row: %w", err)
 		}
+		out.CreatedAt = parseItemTime(createdAt)
+		out.UpdatedAt = parseItemTime(updatedAt)
 
 		out.FTSScore = normalizeBM25(out.RawBM25)
 		results = append(results, out)
@@ -127,10 +141,12 @@ type fallbackFTSRow struct {
 	Title     string
 	State     string
 	URL       string
+	CreatedAt string
+	UpdatedAt string
 	LowerText string
 }
 
-func (s *Store) searchFTSFallback(ctx context.Context, terms []string, excludeID string, limit int) ([]FTSResult, error) {
+func (s *Store) searchFTSFallback(ctx context.Context, terms []string, excludeID string, limit int, filter SearchFilter) ([]FTSResult, error) {
 	candidateLimit := limit * 3
 	if candidateLimit < 1 {
 		candidateLimit = 1
@@ -138,12 +154,16 @@ func (s *Store) searchFTSFallback(ctx context.Context, terms []string, excludeID
 
 	var b strings.Builder
 	b.WriteString(`
-SELECT id, type, number, title, state, url, lower(title || ' ' || body) as text_blob
+SELECT id, type, number, title, state, url, created_at, updated_at, lower(title || ' ' || body) as text_blob
 FROM items
 WHERE id != ?`)
 
-	args := make([]any, 0, 1+len(terms)*2+1)
+	filterSQL, filterArgs := filter.itemClause("")
+	b.WriteString(filterSQL)
+
+	args := make([]any, 0, 1+len(filterArgs)+len(terms)*2+1)
 	args = append(args, excludeID)
+	args = append(args, filterArgs...)
 
 	for _, term := range terms {
 		b.WriteString(`
@@ -165,7 +185,7 @@ LIMIT ?;`)
 	rawRows := make([]fallbackFTSRow, 0, candidateLimit)
 	for rows.Next() {
 		var row fallbackFTSRow
-		if err := rows.Scan(&row.ID, &row.Type, &row.Number, &row.Title, &row.State, &row.URL, &row.LowerText); err != nil {
+		if err := rows.Scan(&row.ID, &row.Type, &row.Number, &row.Title, &row.State, &row.URL, &row.CreatedAt, &row.UpdatedAt, &row.LowerText); err != nil {
 			return nil, fmt.Errorf("scan fallback fts row: %w", err)
 		}
 		rawRows = append(rawRows, row)
@@ -200,6 +220,9 @@ LIMIT ?;`)
 			URL:      row.URL,
 			RawBM25:  raw,
 			FTSScore: normalizeBM25(raw),
+
+			CreatedAt: parseItemTime(row.CreatedAt),
+			UpdatedAt: parseItemTime(row.UpdatedAt),
 		})
 	}
 
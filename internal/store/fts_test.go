@@ -93,7 +93,7 @@ func TestSearchFTS_ExcludesCurrentItem(t *testing.T) {
 		t.Fatalf("insert item issue/3 error = %v", err)
 	}
 
-	results, err := s.SearchFTS(ctx, "fix login timeout", "issue/1", 5)
+	results, err := s.SearchFTS(ctx, "fix login timeout", "issue/1", 5, SearchFilter{})
 	if err != nil {
 		t.Fatalf("SearchFTS() error = %v", err)
 	}
@@ -125,7 +125,7 @@ func TestSearchFTS_EmptyQueryReturnsNoResults(t *testing.T) {
 		}
 	}()
 
-	results, err := s.SearchFTS(ctx, "the and in to", "", 5)
+	results, err := s.SearchFTS(ctx, "the and in to", "", 5, SearchFilter{})
 	if err != nil {
 		t.Fatalf("SearchFTS() error = %v", err)
 	}
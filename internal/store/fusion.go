@@ -0,0 +1,249 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FusionSource names one ranked list fed into fusion.
+type FusionSource string
+
+const (
+	FusionSourceVector FusionSource = "vector"
+	FusionSourceFTS    FusionSource = "fts"
+	FusionSourceNear   FusionSource = "near"
+)
+
+var fusionSources = []FusionSource{FusionSourceVector, FusionSourceFTS, FusionSourceNear}
+
+const (
+	FusionRRF     = "rrf"
+	FusionCombSUM = "combsum"
+	FusionCombMNZ = "combmnz"
+	FusionLinear  = "linear"
+)
+
+// RankedHit is one candidate in a source's list, best first. Scores are
+// normalized to [0, 1] by the backend that produced them.
+type RankedHit struct {
+	ID    string
+	Score float64
+}
+
+// FusionScore is the outcome of fusion for one candidate: Rank orders the
+// results and Similarity is the user-facing score the thresholds apply to.
+type FusionScore struct {
+	Rank       float64
+	Similarity float64
+}
+
+// FusionStrategy merges the per-source ranked lists into one score per
+// candidate. Lists are deduplicated and already exclude the queried item.
+type FusionStrategy interface {
+	Name() string
+	Fuse(lists map[FusionSource][]RankedHit) map[string]FusionScore
+}
+
+// FusionWeights scales each source's contribution. Sources left out weigh 1;
+// a weight of 0 ignores that source entirely.
+type FusionWeights map[FusionSource]float64
+
+func (w FusionWeights) weight(source FusionSource) float64 {
+	if v, ok := w[source]; ok {
+		return v
+	}
+	return 1
+}
+
+// weightedMax is the best single-source score, each scaled by its weight
+// relative to the heaviest source. With equal weights it is max(vec, fts, near).
+func (w FusionWeights) weightedMax(scores map[FusionSource]float64) float64 {
+	heaviest := 0.0
+	for _, source := range fusionSources {
+		heaviest = maxFloat(heaviest, w.weight(source))
+	}
+	if heaviest <= 0 {
+		return 0
+	}
+	best := 0.0
+	for source, score := range scores {
+		best = maxFloat(best, w.weight(source)/heaviest*score)
+	}
+	return clamp01(best)
+}
+
+// ParseFusionWeights reads "vector=1,fts=0.5" style weights.
+func ParseFusionWeights(raw string) (FusionWeights, error) {
+	weights := FusionWeights{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("fusion weight %q must look like source=weight", part)
+		}
+		source := FusionSource(strings.ToLower(strings.TrimSpace(name)))
+		known := false
+		for _, s := range fusionSources {
+			known = known || s == source
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown fusion source %q (supported: vector, fts, near)", name)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse fusion weight %q: %w", part, err)
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("fusion weight %q must not be negative", part)
+		}
+		weights[source] = weight
+	}
+	return weights, nil
+}
+
+// FusionStrategyNames lists the names accepted by NewFusionStrategy.
+func FusionStrategyNames() []string {
+	return []string{FusionRRF, FusionCombSUM, FusionCombMNZ, FusionLinear}
+}
+
+// NewFusionStrategy builds a strategy by name. rrfK only applies to rrf;
+// zero selects the standard k = 60.
+func NewFusionStrategy(name string, weights FusionWeights, rrfK int) (FusionStrategy, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", FusionRRF:
+		return WeightedRRF{K: rrfK, Weights: weights}, nil
+	case FusionCombSUM:
+		return CombSUM{Weights: weights}, nil
+	case FusionCombMNZ:
+		return CombMNZ{Weights: weights}, nil
+	case FusionLinear:
+		return LinearBlend{Weights: weights}, nil
+	default:
+		return nil, fmt.Errorf("unknown fusion strategy %q (supported: %s)", name, strings.Join(FusionStrategyNames(), ", "))
+	}
+}
+
+// candidateScores regroups the lists as per-candidate source scores.
+func candidateScores(lists map[FusionSource][]RankedHit) map[string]map[FusionSource]float64 {
+	out := map[string]map[FusionSource]float64{}
+	for source, hits := range lists {
+		for _, hit := range hits {
+			scores, ok := out[hit.ID]
+			if !ok {
+				scores = map[FusionSource]float64{}
+				out[hit.ID] = scores
+			}
+			scores[source] = clamp01(hit.Score)
+		}
+	}
+	return out
+}
+
+// WeightedRRF is reciprocal rank fusion: each list adds weight/(k+rank) for
+// the candidates it returns. It ignores raw scores, so a noisy backend only
+// matters through its ordering.
+type WeightedRRF struct {
+	K       int
+	Weights FusionWeights
+}
+
+func (WeightedRRF) Name() string { return FusionRRF }
+
+func (r WeightedRRF) Fuse(lists map[FusionSource][]RankedHit) map[string]FusionScore {
+	k := r.K
+	if k <= 0 {
+		k = rrfK
+	}
+	out := map[string]FusionScore{}
+	for id, scores := range candidateScores(lists) {
+		out[id] = FusionScore{Similarity: r.Weights.weightedMax(scores)}
+	}
+	for _, source := range fusionSources {
+		weight := r.Weights.weight(source)
+		for rank, hit := range lists[source] {
+			score := out[hit.ID]
+			score.Rank += weight / float64(k+rank+1)
+			out[hit.ID] = score
+		}
+	}
+	return out
+}
+
+// CombSUM ranks by the weighted sum of the normalized source scores.
+type CombSUM struct {
+	Weights FusionWeights
+}
+
+func (CombSUM) Name() string { return FusionCombSUM }
+
+func (c CombSUM) Fuse(lists map[FusionSource][]RankedHit) map[string]FusionScore {
+	out := map[string]FusionScore{}
+	for id, scores := range candidateScores(lists) {
+		out[id] = FusionScore{Rank: weightedSum(c.Weights, scores), Similarity: c.Weights.weightedMax(scores)}
+	}
+	return out
+}
+
+// CombMNZ is CombSUM multiplied by the number of sources that returned the
+// candidate, rewarding agreement between backends.
+type CombMNZ struct {
+	Weights FusionWeights
+}
+
+func (CombMNZ) Name() string { return FusionCombMNZ }
+
+func (c CombMNZ) Fuse(lists map[FusionSource][]RankedHit) map[string]FusionScore {
+	out := map[string]FusionScore{}
+	for id, scores := range candidateScores(lists) {
+		hits := 0
+		for source := range scores {
+			if c.Weights.weight(source) > 0 {
+				hits++
+			}
+		}
+		out[id] = FusionScore{Rank: weightedSum(c.Weights, scores) * float64(hits), Similarity: c.Weights.weightedMax(scores)}
+	}
+	return out
+}
+
+// LinearBlend is the weighted mean of the source scores over every source
+// that returned results, so a candidate missing from a list scores 0 there.
+// The blend is also the displayed similarity.
+type LinearBlend struct {
+	Weights FusionWeights
+}
+
+func (LinearBlend) Name() string { return FusionLinear }
+
+func (l LinearBlend) Fuse(lists map[FusionSource][]RankedHit) map[string]FusionScore {
+	total := 0.0
+	for _, source := range fusionSources {
+		if len(lists[source]) > 0 {
+			total += l.Weights.weight(source)
+		}
+	}
+	out := map[string]FusionScore{}
+	for id, scores := range candidateScores(lists) {
+		blend := 0.0
+		if total > 0 {
+			blend = clamp01(weightedSum(l.Weights, scores) / total)
+		}
+		out[id] = FusionScore{Rank: blend, Similarity: blend}
+	}
+	return out
+}
+
+func weightedSum(weights FusionWeights, scores map[FusionSource]float64) float64 {
+	// Fixed source order keeps float sums, and so tie-breaks, deterministic.
+	sum := 0.0
+	for _, source := range fusionSources {
+		if score, ok := scores[source]; ok {
+			sum += weights.weight(source) * score
+		}
+	}
+	return sum
+}
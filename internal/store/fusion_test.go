@@ -0,0 +1,108 @@
+package store
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestFuseResults_StrategiesOnNoisyKeywordFixture(t *testing.T) {
+	t.Helper()
+
+	// issue/C only matches on common words, but BM25 still scores it highly.
+	vecResults := []VectorResult{
+		{ID: "issue/A", VecScore: 0.93, Type: "issue", Number: 1, Title: "A"},
+		{ID: "issue/B", VecScore: 0.80, Type: "issue", Number: 2, Title: "B"},
+	}
+	ftsResults := []FTSResult{
+		{ID: "issue/C", FTSScore: 0.90, Type: "issue", Number: 3, Title: "C"},
+		{ID: "issue/B", FTSScore: 0.60, Type: "issue", Number: 2, Title: "B"},
+	}
+	quietFTS := FusionWeights{FusionSourceFTS: 0.25}
+	blendWeights := FusionWeights{FusionSourceVector: 0.75, FusionSourceFTS: 0.25}
+
+	cases := []struct {
+		name      string
+		strategy  FusionStrategy
+		wantOrder string
+	}{
+		{name: "default rrf", strategy: nil, wantOrder: "issue/B,issue/A,issue/C"},
+		{name: "weighted rrf", strategy: WeightedRRF{Weights: quietFTS}, wantOrder: "issue/B,issue/A"},
+		{name: "combsum", strategy: CombSUM{}, wantOrder: "issue/B,issue/A,issue/C"},
+		{name: "combmnz", strategy: CombMNZ{Weights: quietFTS}, wantOrder: "issue/B,issue/A"},
+		{name: "linear", strategy: LinearBlend{Weights: blendWeights}, wantOrder: "issue/B,issue/A"},
+	}
+	for _, tc := range cases {
+		fused := FuseResults(vecResults, ftsResults, nil, "", FuseConfig{
+			SimilarityThreshold: 0.50,
+			DuplicateThreshold:  0.95,
+			MaxResults:          10,
+			Strategy:            tc.strategy,
+		})
+		ids := make([]string, 0, len(fused))
+		for _, result := range fused {
+			ids = append(ids, result.ID)
+		}
+		if got := strings.Join(ids, ","); got != tc.wantOrder {
+			t.Fatalf("%s: FuseResults() order = %s, want %s", tc.name, got, tc.wantOrder)
+		}
+	}
+}
+
+func TestFusionStrategies_Scores(t *testing.T) {
+	t.Helper()
+
+	lists := map[FusionSource][]RankedHit{
+		FusionSourceVector: {{ID: "a", Score: 0.9}, {ID: "b", Score: 0.8}},
+		FusionSourceFTS:    {{ID: "b", Score: 0.6}},
+	}
+	near := func(got, want float64) bool { return math.Abs(got-want) < 1e-9 }
+
+	rrf := WeightedRRF{}.Fuse(lists)
+	if !near(rrf["b"].Rank, 1.0/62+1.0/61) || !near(rrf["b"].Similarity, 0.8) {
+		t.Fatalf("WeightedRRF b = %+v", rrf["b"])
+	}
+	if k10 := (WeightedRRF{K: 10}).Fuse(lists); !near(k10["a"].Rank, 1.0/11) {
+		t.Fatalf("WeightedRRF k=10 a = %+v", k10["a"])
+	}
+	if sum := (CombSUM{}).Fuse(lists); !near(sum["b"].Rank, 1.4) {
+		t.Fatalf("CombSUM b = %+v", sum["b"])
+	}
+	if mnz := (CombMNZ{}).Fuse(lists); !near(mnz["b"].Rank, 2.8) || !near(mnz["a"].Rank, 0.9) {
+		t.Fatalf("CombMNZ = %+v", mnz)
+	}
+	linear := LinearBlend{Weights: FusionWeights{FusionSourceFTS: 0.5}}.Fuse(lists)
+	if !near(linear["b"].Similarity, (0.8+0.3)/1.5) || !near(linear["a"].Similarity, 0.9/1.5) {
+		t.Fatalf("LinearBlend = %+v", linear)
+	}
+	if zero := (CombSUM{Weights: FusionWeights{FusionSourceFTS: 0}}).Fuse(lists); !near(zero["b"].Similarity, 0.8) {
+		t.Fatalf("zero-weight source should not raise similarity: %+v", zero["b"])
+	}
+}
+
+func TestNewFusionStrategyAndWeights(t *testing.T) {
+	t.Helper()
+
+	weights, err := ParseFusionWeights(" vector=1, FTS=0.3 ,near=0")
+	if err != nil {
+		t.Fatalf("ParseFusionWeights() error = %v", err)
+	}
+	if weights[FusionSourceFTS] != 0.3 || weights.weight(FusionSourceNear) != 0 || len(weights) != 3 {
+		t.Fatalf("ParseFusionWeights() = %v", weights)
+	}
+	for _, name := range FusionStrategyNames() {
+		strategy, err := NewFusionStrategy(name, weights, 0)
+		if err != nil || strategy.Name() != name {
+			t.Fatalf("NewFusionStrategy(%q) = %v, %v", name, strategy, err)
+		}
+	}
+
+	for _, raw := range []string{"vector", "title=1", "fts=-1", "fts=x"} {
+		if _, err := ParseFusionWeights(raw); err == nil {
+			t.Fatalf("ParseFusionWeights(%q) expected error", raw)
+		}
+	}
+	if _, err := NewFusionStrategy("borda", nil, 0); err == nil {
+		t.Fatalf("NewFusionStrategy(borda) expected error")
+	}
+}
@@ -24,7 +24,7 @@ func TestIntegrationSearchVector_FileBackedNearest(t *testing.T) {
 	seedIntegrationCorpus(t, ctx, s)
 	requireNativeVectorQuery(t, ctx, s)
 
-	results, err := s.SearchVector(ctx, makeVec1536(1, 0), "issue/1", 3)
+	results, err := s.SearchVector(ctx, makeVec1536(1, 0), "issue/1", 3, SearchFilter{})
 	if err != nil {
 		t.Fatalf("SearchVector() error = %v", err)
 	}
@@ -64,7 +64,7 @@ func TestIntegrationSearchVector_ClampsOppositeScore(t *testing.T) {
 	seedIntegrationCorpus(t, ctx, s)
 	requireNativeVectorQuery(t, ctx, s)
 
-	results, err := s.SearchVector(ctx, makeVec1536(1, 0), "", 10)
+	results, err := s.SearchVector(ctx, makeVec1536(1, 0), "", 10, SearchFilter{})
 	if err != nil {
 		t.Fatalf("SearchVector() error = %v", err)
 	}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -23,8 +24,22 @@ type ItemRecord struct {
 	Files  []string
 	URL    string
 
+	// StateReason is GitHub's close reason (completed, not_planned, duplicate).
+	StateReason string
+	Milestone   string
+	Assignees   []string
+	// Draft and BaseBranch only apply to pull requests.
+	Draft      bool
+	BaseBranch string
+
+	// ContentHash and EmbeddingModel identify the text and model behind the stored vector.
+	ContentHash    string
+	EmbeddingModel string
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// ClosedAt is zero while the item is open.
+	ClosedAt time.Time
 }
 
 func BuildItemID(kind string, number int) string {
@@ -39,7 +54,11 @@ func BuildItemID(kind string, number int) string {
 	}
 }
 
-func (s *Store) UpsertItem(ctx context.Context, rec ItemRecord) error {
+// UpsertItem inserts or replaces an item and refreshes its MinHash signature.
+// Missing timestamps default to now. An existing row keeps the earlier
+// created_at, so items first stored without GitHub's timestamp pick it up later,
+// and keeps its base branch when rec has none (issue-shaped payloads lack it).
+func (s *Store) UpsertItem(ctx context.Context, rec ItemRecord) (err error) {
 	if s == nil || s.db == nil {
 		return errors.New("store is not initialized")
 	}
@@ -61,6 +80,10 @@ func (s *Store) UpsertItem(ctx context.Context, rec ItemRecord) error {
 	if err != nil {
 		return fmt.Errorf("marshal files: %w", err)
 	}
+	assigneesJSON, err := json.Marshal(nonNilStrings(rec.Assignees))
+	if err != nil {
+		return fmt.Errorf("marshal assignees: %w", err)
+	}
 
 	now := time.Now().UTC()
 	createdAt := rec.CreatedAt
@@ -74,8 +97,10 @@ func (s *Store) UpsertItem(ctx context.Context, rec ItemRecord) error {
 
 	const stmt = `
 INSERT INTO items(
-    id, type, number, title, body, author, state, labels, files, url, created_at, updated_at
-) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    id, type, number, title, body, author, state, labels, files, url,
+    state_reason, milestone, assignees, draft, base_branch,
+    content_hash, embedding_model, created_at, updated_at, closed_at
+) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON CONFLICT(id) DO UPDATE SET
     type=excluded.type,
     number=excluded.number,
@@ -86,9 +111,31 @@ ON CONFLICT(id) DO UPDATE SET
     labels=excluded.labels,
     files=excluded.files,
     url=excluded.url,
-    updated_at=excluded.updated_at;
+    state_reason=excluded.state_reason,
+    milestone=excluded.milestone,
+    assignees=excluded.assignees,
+    draft=excluded.draft,
+    base_branch=CASE WHEN excluded.base_branch = '' THEN items.base_branch ELSE excluded.base_branch END,
+    content_hash=excluded.content_hash,
+    embedding_model=excluded.embedding_model,
+    created_at=CASE
+        WHEN unixepoch(excluded.created_at) < unixepoch(items.created_at) THEN excluded.created_at
+        ELSE items.created_at
+    END,
+    updated_at=excluded.updated_at,
+    closed_at=excluded.closed_at;
 `
-	_, err = s.db.ExecContext(ctx, stmt,
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin upsert item: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	_, err = tx.ExecContext(ctx, stmt,
 		rec.ID,
 		rec.Type,
 		rec.Number,
@@ -99,12 +146,34 @@ ON CONFLICT(id) DO UPDATE SET
 		string(labelsJSON),
 		string(filesJSON),
 		rec.URL,
+		rec.StateReason,
+		rec.Milestone,
+		string(assigneesJSON),
+		rec.Draft,
+		rec.BaseBranch,
+		rec.ContentHash,
+		rec.EmbeddingModel,
 		createdAt.Format(time.RFC3339Nano),
 		updatedAt.Format(time.RFC3339Nano),
+		formatItemTime(rec.ClosedAt),
 	)
 	if err != nil {
 		return fmt.Errorf("upsert item: %w", err)
 	}
+	if err = writeMinHash(ctx, tx, rec.ID, rec.Title, rec.Body); err != nil {
+		return err
+	}
+	if err = syncVectorMeta(ctx, tx, rec.ID); err != nil {
+		return err
+	}
+	// An item that comes back, e.g. transferred back in, is live again.
+	if _, err = tx.ExecContext(ctx, `DELETE FROM item_tombstones WHERE id = ?;`, rec.ID); err != nil {
+		return fmt.Errorf("clear tombstone: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit upsert item: %w", err)
+	}
 	return nil
 }
 
@@ -118,26 +187,357 @@ func (s *Store) UpsertVector(ctx context.Context, id string, embedding []float32
 	if len(embedding) == 0 {
 		return errors.New("embedding is required")
 	}
+	if s.space.Dimensions > 0 && len(embedding) != s.space.Dimensions {
+		return fmt.Errorf("embedding has %d dimensions, index expects %d for %s", len(embedding), s.space.Dimensions, s.space.Model)
+	}
 
 	serialized, err := sqlite_vec.SerializeFloat32(embedding)
 	if err != nil {
 		return fmt.Errorf("serialize embedding: %w", err)
 	}
 
-	const upsertStmt = `INSERT OR REPLACE INTO items_vec(id, embedding) VALUES(?, ?);`
-	if _, err := s.db.ExecContext(ctx, upsertStmt, id, serialized); err == nil {
-		return nil
-	} else if !strings.Contains(strings.ToLower(err.Error()), "unique constraint failed") {
-		return fmt.Errorf("upsert vector: %w", err)
+	// vec0 rejects INSERT OR REPLACE on existing ids, so replace by delete +
+	// insert in one transaction.
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin upsert vector: %w", err)
 	}
-
-	// Some sqlite-vec builds can reject INSERT OR REPLACE on existing IDs.
-	// Fallback to delete + insert to keep updates deterministic.
-	if _, err := s.db.ExecContext(ctx, `DELETE FROM items_vec WHERE id = ?;`, id); err != nil {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM items_vec WHERE id = ?;`, id); err != nil {
+		_ = tx.Rollback()
 		return fmt.Errorf("upsert vector delete existing: %w", err)
 	}
-	if _, err := s.db.ExecContext(ctx, `INSERT INTO items_vec(id, embedding) VALUES(?, ?);`, id, serialized); err != nil {
+	if err := insertVectorRow(ctx, tx, "items_vec", "id", id, id, serialized); err != nil {
+		_ = tx.Rollback()
 		return fmt.Errorf("upsert vector insert: %w", err)
 	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit upsert vector: %w", err)
+	}
+	return s.ClearPendingEmbedding(ctx, id)
+}
+
+// DeleteVector removes an item's whole-item and chunk vectors but keeps the
+// item, so it stays searchable by keyword. Used when the stored vector no
+// longer matches the item's content and a new one could not be computed.
+func (s *Store) DeleteVector(ctx context.Context, id string) (err error) {
+	if s == nil || s.db == nil {
+		return errors.New("store is not initialized")
+	}
+	if strings.TrimSpace(id) == "" {
+		return errors.New("item id is required")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin delete vector: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = deleteItemChunks(ctx, tx, id); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM items_vec WHERE id = ?;`, id); err != nil {
+		return fmt.Errorf("delete vector %s: %w", id, err)
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE items SET embedding_model = '' WHERE id = ?;`, id); err != nil {
+		return fmt.Errorf("clear embedding model %s: %w", id, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit delete vector: %w", err)
+	}
+	return nil
+}
+
+// SetEmbeddingFingerprint records the content hash and model behind an item's
+// stored vector. Call it only after the vector is written, so LookupEmbedding
+// never pairs a new fingerprint with an old vector.
+func (s *Store) SetEmbeddingFingerprint(ctx context.Context, id, contentHash, model string) error {
+	if s == nil || s.db == nil {
+		return errors.New("store is not initialized")
+	}
+	if strings.TrimSpace(id) == "" {
+		return errors.New("item id is required")
+	}
+
+	const stmt = `UPDATE items SET content_hash = ?, embedding_model = ? WHERE id = ?;`
+	if _, err := s.db.ExecContext(ctx, stmt, contentHash, model, id); err != nil {
+		return fmt.Errorf("set embedding fingerprint %s: %w", id, err)
+	}
 	return nil
 }
+
+// UpdateItemMetadata refreshes state, labels and other non-embedded fields of an
+// existing item without touching its title, body or embedding fingerprint.
+// found=false means the item is not indexed yet.
+func (s *Store) UpdateItemMetadata(ctx context.Context, rec ItemRecord) (found bool, err error) {
+	if s == nil || s.db == nil {
+		return false, errors.New("store is not initialized")
+	}
+	if strings.TrimSpace(rec.ID) == "" {
+		return false, errors.New("item id is required")
+	}
+
+	labelsJSON, err := json.Marshal(rec.Labels)
+	if err != nil {
+		return false, fmt.Errorf("marshal labels: %w", err)
+	}
+	assigneesJSON, err := json.Marshal(nonNilStrings(rec.Assignees))
+	if err != nil {
+		return false, fmt.Errorf("marshal assignees: %w", err)
+	}
+
+	updatedAt := rec.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = time.Now().UTC()
+	}
+
+	const stmt = `
+UPDATE items SET
+    state = ?,
+    labels = ?,
+    author = CASE WHEN ? = '' THEN author ELSE ? END,
+    url = CASE WHEN ? = '' THEN url ELSE ? END,
+    state_reason = ?,
+    milestone = ?,
+    assignees = ?,
+    draft = ?,
+    base_branch = CASE WHEN ? = '' THEN base_branch ELSE ? END,
+    updated_at = ?,
+    closed_at = ?
+WHERE id = ?;
+`
+	res, err := s.db.ExecContext(ctx, stmt,
+		rec.State,
+		string(labelsJSON),
+		rec.Author, rec.Author,
+		rec.URL, rec.URL,
+		rec.StateReason,
+		rec.Milestone,
+		string(assigneesJSON),
+		rec.Draft,
+		rec.BaseBranch, rec.BaseBranch,
+		updatedAt.Format(time.RFC3339Nano),
+		formatItemTime(rec.ClosedAt),
+		rec.ID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("update item metadata: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("update item metadata rows: %w", err)
+	}
+	if affected == 0 {
+		return false, nil
+	}
+	if err := syncVectorMeta(ctx, s.db, rec.ID); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// DeleteItem removes an item from items, items_fts (via trigger), items_vec,
+// items_chunks_vec and the MinHash tables, and leaves a tombstone so MergeFrom
+// does not bring it back from an older copy of the index.
+// Deleting a missing item is a no-op.
+func (s *Store) DeleteItem(ctx context.Context, id string) (err error) {
+	if s == nil || s.db == nil {
+		return errors.New("store is not initialized")
+	}
+	if strings.TrimSpace(id) == "" {
+		return errors.New("item id is required")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin delete item: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = deleteItemRows(ctx, tx, id); err != nil {
+		return err
+	}
+	if err = writeTombstone(ctx, tx, id, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit delete item: %w", err)
+	}
+	return nil
+}
+
+func deleteItemRows(ctx context.Context, tx *sql.Tx, id string) error {
+	if err := deleteItemChunks(ctx, tx, id); err != nil {
+		return err
+	}
+	if err := deleteMinHash(ctx, tx, id); err != nil {
+		return err
+	}
+
+	stmts := []string{
+		`DELETE FROM items_vec WHERE id = ?;`,
+		`DELETE FROM pending_embeddings WHERE id = ?;`,
+		`DELETE FROM items WHERE id = ?;`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.ExecContext(ctx, stmt, id); err != nil {
+			return fmt.Errorf("delete item %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// writeTombstone records when an item was deleted, keeping the later time if
+// it was already tombstoned.
+func writeTombstone(ctx context.Context, tx *sql.Tx, id string, deletedAt time.Time) error {
+	const stmt = `
+INSERT INTO item_tombstones(id, deleted_at) VALUES(?, ?)
+ON CONFLICT(id) DO UPDATE SET deleted_at=excluded.deleted_at
+WHERE unixepoch(excluded.deleted_at) > unixepoch(item_tombstones.deleted_at);
+`
+	if _, err := tx.ExecContext(ctx, stmt, id, deletedAt.Format(time.RFC3339Nano)); err != nil {
+		return fmt.Errorf("record tombstone for %s: %w", id, err)
+	}
+	return nil
+}
+
+// GetItem loads one stored item by id. Returns sql.ErrNoRows (wrapped) when missing.
+func (s *Store) GetItem(ctx context.Context, id string) (ItemRecord, error) {
+	if s == nil || s.db == nil {
+		return ItemRecord{}, errors.New("store is not initialized")
+	}
+
+	query := `SELECT ` + itemColumns + ` FROM items WHERE id = ?;`
+	rec, err := scanItemRecord(s.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return ItemRecord{}, fmt.Errorf("get item %s: %w", id, err)
+	}
+	return rec, nil
+}
+
+const itemColumns = `id, type, number, title, body, author, state, labels, files, url,
+       state_reason, milestone, assignees, draft, base_branch,
+       content_hash, embedding_model, created_at, updated_at, closed_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanItemRecord decodes one row selected with itemColumns.
+func scanItemRecord(row rowScanner) (ItemRecord, error) {
+	var (
+		rec           ItemRecord
+		labelsJSON    string
+		filesJSON     string
+		assigneesJSON string
+		createdAt     string
+		updatedAt     string
+		closedAt      string
+	)
+	err := row.Scan(
+		&rec.ID,
+		&rec.Type,
+		&rec.Number,
+		&rec.Title,
+		&rec.Body,
+		&rec.Author,
+		&rec.State,
+		&labelsJSON,
+		&filesJSON,
+		&rec.URL,
+		&rec.StateReason,
+		&rec.Milestone,
+		&assigneesJSON,
+		&rec.Draft,
+		&rec.BaseBranch,
+		&rec.ContentHash,
+		&rec.EmbeddingModel,
+		&createdAt,
+		&updatedAt,
+		&closedAt,
+	)
+	if err != nil {
+		return ItemRecord{}, err
+	}
+
+	if err := json.Unmarshal([]byte(labelsJSON), &rec.Labels); err != nil {
+		return ItemRecord{}, fmt.Errorf("decode labels for %s: %w", rec.ID, err)
+	}
+	if err := json.Unmarshal([]byte(filesJSON), &rec.Files); err != nil {
+		return ItemRecord{}, fmt.Errorf("decode files for %s: %w", rec.ID, err)
+	}
+	if err := json.Unmarshal([]byte(assigneesJSON), &rec.Assignees); err != nil {
+		return ItemRecord{}, fmt.Errorf("decode assignees for %s: %w", rec.ID, err)
+	}
+	rec.CreatedAt = parseItemTime(createdAt)
+	rec.UpdatedAt = parseItemTime(updatedAt)
+	rec.ClosedAt = parseItemTime(closedAt)
+
+	return rec, nil
+}
+
+// LookupEmbedding returns the stored vector for id when it was produced from the
+// same content hash and embedding model, so unchanged items skip re-embedding.
+func (s *Store) LookupEmbedding(ctx context.Context, id, contentHash, model string) ([]float32, bool, error) {
+	if s == nil || s.db == nil {
+		return nil, false, errors.New("store is not initialized")
+	}
+	if strings.TrimSpace(contentHash) == "" || strings.TrimSpace(model) == "" {
+		return nil, false, nil
+	}
+
+	const query = `
+SELECT v.embedding
+FROM items i
+JOIN items_vec v ON v.id = i.id
+WHERE i.id = ? AND i.content_hash = ? AND i.embedding_model = ?;
+`
+
+	var blob []byte
+	err := s.db.QueryRowContext(ctx, query, id, contentHash, model).Scan(&blob)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("lookup embedding: %w", err)
+	}
+
+	vec, err := decodeFloat32Vector(blob)
+	if err != nil || len(vec) == 0 {
+		return nil, false, nil
+	}
+	return vec, true, nil
+}
+
+// parseItemTime reads a stored items timestamp; unparseable values read as zero.
+func parseItemTime(raw string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, raw)
+	return t
+}
+
+// formatItemTime stores optional timestamps, such as closed_at, as an empty
+// string when unset.
+func formatItemTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// nonNilStrings keeps JSON list columns as [] rather than null.
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
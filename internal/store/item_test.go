@@ -2,6 +2,8 @@ package store
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"path/filepath"
 	"testing"
 	"time"
@@ -148,6 +150,281 @@ WHERE id = ?;
 	if updatedAt != "2026-01-01T01:02:00Z" {
 		t.Fatalf("updated_at = %q, want second upsert timestamp", updatedAt)
 	}
+	// A backfill that learns the real, earlier creation time replaces the stored one.
+	if err := s.UpsertItem(ctx, ItemRecord{
+		ID:        "issue/42",
+		Type:      "issue",
+		Number:    42,
+		Title:     "Updated title",
+		State:     "closed",
+		CreatedAt: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedAt: time.Date(2026, 1, 1, 1, 2, 0, 0, time.UTC),
+	}); err != nil {
+		t.Fatalf("third UpsertItem() error = %v", err)
+	}
+	if err := s.DB().QueryRowContext(ctx, `SELECT created_at FROM items WHERE id = ?;`, "issue/42").Scan(&createdAt); err != nil {
+		t.Fatalf("query created_at error = %v", err)
+	}
+	if createdAt != "2025-06-01T00:00:00Z" {
+		t.Fatalf("created_at = %q, want the earlier GitHub timestamp", createdAt)
+	}
+}
+
+func TestGetItemRoundTrip(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	s, err := Open(ctx, filepath.Join(t.TempDir(), "item-get.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() {
+		if cerr := s.Close(); cerr != nil {
+			t.Fatalf("Close() error = %v", cerr)
+		}
+	}()
+
+	want := ItemRecord{
+		ID:        "pr/8",
+		Type:      "pr",
+		Number:    8,
+		Title:     "Add retries",
+		Body:      "Retries auth",
+		Author:    "alice",
+		State:     "open",
+		Labels:    []string{"auth"},
+		Files:     []string{"auth.go"},
+		URL:       "https://example.com/pr/8",
+		Milestone: "v2.1",
+		Assignees: []string{"bob", "carol"},
+		Draft:     true,
+
+		BaseBranch: "release/2.x",
+		CreatedAt:  time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedAt:  time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC),
+	}
+	if err := s.UpsertItem(ctx, want); err != nil {
+		t.Fatalf("UpsertItem() error = %v", err)
+	}
+
+	got, err := s.GetItem(ctx, "pr/8")
+	if err != nil {
+		t.Fatalf("GetItem() error = %v", err)
+	}
+	if got.Title != want.Title || got.Body != want.Body || len(got.Files) != 1 || got.Files[0] != "auth.go" || len(got.Labels) != 1 {
+		t.Fatalf("GetItem() = %+v, want %+v", got, want)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) || !got.ClosedAt.IsZero() {
+		t.Fatalf("GetItem() timestamps = %v/%v/%v", got.CreatedAt, got.UpdatedAt, got.ClosedAt)
+	}
+	if got.Milestone != "v2.1" || len(got.Assignees) != 2 || got.Assignees[1] != "carol" || !got.Draft || got.BaseBranch != "release/2.x" {
+		t.Fatalf("GetItem() metadata = %+v", got)
+	}
+
+	if _, err := s.GetItem(ctx, "pr/404"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetItem(missing) error = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestLookupEmbeddingMatchesHashAndModel(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	s, err := Open(ctx, filepath.Join(t.TempDir(), "lookup-embedding.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() {
+		if cerr := s.Close(); cerr != nil {
+			t.Fatalf("Close() error = %v", cerr)
+		}
+	}()
+
+	if err := s.UpsertItem(ctx, ItemRecord{
+		ID: "issue/5", Type: "issue", Number: 5, Title: "t", Body: "b",
+		ContentHash: "hash-a", EmbeddingModel: "model-a",
+	}); err != nil {
+		t.Fatalf("UpsertItem() error = %v", err)
+	}
+
+	if _, found, err := s.LookupEmbedding(ctx, "issue/5", "hash-a", "model-a"); err != nil || found {
+		t.Fatalf("LookupEmbedding() without vector found=%v err=%v, want not found", found, err)
+	}
+
+	if err := s.UpsertVector(ctx, "issue/5", makeVec1536(0.5, 0.25)); err != nil {
+		t.Fatalf("UpsertVector() error = %v", err)
+	}
+
+	vec, found, err := s.LookupEmbedding(ctx, "issue/5", "hash-a", "model-a")
+	if err != nil || !found {
+		t.Fatalf("LookupEmbedding() found=%v err=%v, want found", found, err)
+	}
+	if len(vec) != 1536 || vec[0] != 0.5 || vec[1] != 0.25 {
+		t.Fatalf("LookupEmbedding() returned unexpected vector prefix")
+	}
+
+	if _, found, _ := s.LookupEmbedding(ctx, "issue/5", "hash-b", "model-a"); found {
+		t.Fatalf("expected miss for changed content hash")
+	}
+	if _, found, _ := s.LookupEmbedding(ctx, "issue/5", "hash-a", "model-b"); found {
+		t.Fatalf("expected miss for changed embedding model")
+	}
+}
+
+func TestDeleteVectorAndSetEmbeddingFingerprint(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	s, err := Open(ctx, filepath.Join(t.TempDir(), "vector-fingerprint.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() {
+		if cerr := s.Close(); cerr != nil {
+			t.Fatalf("Close() error = %v", cerr)
+		}
+	}()
+
+	if err := s.UpsertItem(ctx, ItemRecord{ID: "issue/6", Type: "issue", Number: 6, Title: "crash on save", Body: "stack"}); err != nil {
+		t.Fatalf("UpsertItem() error = %v", err)
+	}
+	if err := s.UpsertVector(ctx, "issue/6", makeVec1536(1, 0)); err != nil {
+		t.Fatalf("UpsertVector() error = %v", err)
+	}
+	if err := s.ReplaceChunkVectors(ctx, "issue/6", [][]float32{makeVec1536(0, 1)}); err != nil {
+		t.Fatalf("ReplaceChunkVectors() error = %v", err)
+	}
+	if err := s.SetEmbeddingFingerprint(ctx, "issue/6", "hash-a", "model-a"); err != nil {
+		t.Fatalf("SetEmbeddingFingerprint() error = %v", err)
+	}
+	if _, found, err := s.LookupEmbedding(ctx, "issue/6", "hash-a", "model-a"); err != nil || !found {
+		t.Fatalf("LookupEmbedding() found=%v err=%v, want found", found, err)
+	}
+
+	if err := s.DeleteVector(ctx, "issue/6"); err != nil {
+		t.Fatalf("DeleteVector() error = %v", err)
+	}
+	if _, found, err := s.LookupEmbedding(ctx, "issue/6", "hash-a", "model-a"); err != nil || found {
+		t.Fatalf("LookupEmbedding() after DeleteVector found=%v err=%v, want not found", found, err)
+	}
+	chunks, err := s.LookupChunkVectors(ctx, "issue/6")
+	if err != nil {
+		t.Fatalf("LookupChunkVectors() error = %v", err)
+	}
+	if len(chunks) != 0 {
+		t.Fatalf("chunks = %d after DeleteVector, want 0", len(chunks))
+	}
+	rec, err := s.GetItem(ctx, "issue/6")
+	if err != nil {
+		t.Fatalf("GetItem() error = %v", err)
+	}
+	if rec.EmbeddingModel != "" {
+		t.Fatalf("embedding model = %q after DeleteVector, want empty", rec.EmbeddingModel)
+	}
+	results, err := s.SearchFTS(ctx, "crash", "", 5, SearchFilter{})
+	if err != nil {
+		t.Fatalf("SearchFTS() error = %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("item without vector should stay keyword searchable, got %+v", results)
+	}
+}
+
+func TestUpdateItemMetadataKeepsContent(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	s, err := Open(ctx, filepath.Join(t.TempDir(), "item-metadata.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() {
+		if cerr := s.Close(); cerr != nil {
+			t.Fatalf("Close() error = %v", cerr)
+		}
+	}()
+
+	found, err := s.UpdateItemMetadata(ctx, ItemRecord{ID: "issue/9", State: "closed"})
+	if err != nil || found {
+		t.Fatalf("UpdateItemMetadata(missing) found=%v err=%v, want not found", found, err)
+	}
+
+	if err := s.UpsertItem(ctx, ItemRecord{
+		ID: "issue/9", Type: "issue", Number: 9, Title: "Login", Body: "hangs",
+		State: "open", ContentHash: "h", EmbeddingModel: "m",
+	}); err != nil {
+		t.Fatalf("UpsertItem() error = %v", err)
+	}
+
+	closedAt := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	found, err = s.UpdateItemMetadata(ctx, ItemRecord{
+		ID: "issue/9", State: "closed", Labels: []string{"wontfix"},
+		StateReason: "not_planned", Assignees: []string{"alice"}, ClosedAt: closedAt,
+	})
+	if err != nil || !found {
+		t.Fatalf("UpdateItemMetadata() found=%v err=%v, want found", found, err)
+	}
+
+	got, err := s.GetItem(ctx, "issue/9")
+	if err != nil {
+		t.Fatalf("GetItem() error = %v", err)
+	}
+	if got.State != "closed" || len(got.Labels) != 1 || got.Labels[0] != "wontfix" {
+		t.Fatalf("metadata not updated: %+v", got)
+	}
+	if got.StateReason != "not_planned" || len(got.Assignees) != 1 || !got.ClosedAt.Equal(closedAt) {
+		t.Fatalf("close metadata not updated: %+v", got)
+	}
+	if got.Title != "Login" || got.Body != "hangs" || got.ContentHash != "h" || got.EmbeddingModel != "m" {
+		t.Fatalf("content fields should be untouched: %+v", got)
+	}
+}
+
+func TestDeleteItemRemovesAllRows(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	s, err := Open(ctx, filepath.Join(t.TempDir(), "item-delete.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() {
+		if cerr := s.Close(); cerr != nil {
+			t.Fatalf("Close() error = %v", cerr)
+		}
+	}()
+
+	if err := s.UpsertItem(ctx, ItemRecord{ID: "issue/7", Type: "issue", Number: 7, Title: "gone soon", Body: "spam"}); err != nil {
+		t.Fatalf("UpsertItem() error = %v", err)
+	}
+	if err := s.UpsertVector(ctx, "issue/7", makeVec1536(1, 0)); err != nil {
+		t.Fatalf("UpsertVector() error = %v", err)
+	}
+
+	if err := s.DeleteItem(ctx, "issue/7"); err != nil {
+		t.Fatalf("DeleteItem() error = %v", err)
+	}
+	if err := s.DeleteItem(ctx, "issue/7"); err != nil {
+		t.Fatalf("second DeleteItem() error = %v", err)
+	}
+
+	for _, table := range []string{"items", "items_vec"} {
+		var count int
+		if err := s.DB().QueryRowContext(ctx, `SELECT COUNT(*) FROM `+table+` WHERE id = ?;`, "issue/7").Scan(&count); err != nil {
+			t.Fatalf("count %s error = %v", table, err)
+		}
+		if count != 0 {
+			t.Fatalf("%s still has %d rows for deleted item", table, count)
+		}
+	}
+
+	results, err := s.SearchFTS(ctx, "spam", "", 5, SearchFilter{})
+	if err != nil {
+		t.Fatalf("SearchFTS() error = %v", err)
+	}
+	if len(results) != 0 {
+		t.Fatalf("deleted item still searchable: %+v", results)
+	}
 }
 
 func TestUpsertVectorValidationAndReplace(t *testing.T) {
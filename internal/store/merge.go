@@ -0,0 +1,379 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MergeStats counts what MergeFrom took from the other index.
+type MergeStats struct {
+	Items    int
+	Vectors  int
+	Pending  int
+	Feedback int
+	// Deleted counts local items removed because the other index deleted them.
+	Deleted int
+	// Meta counts index_meta entries taken or removed, such as the calibration
+	// or the backfill checkpoint.
+	Meta int
+}
+
+// localMetaKeys describe this copy's own vectors, so MergeFrom never takes
+// them from another index.
+var localMetaKeys = []string{EmbeddingModelKey, EmbeddingDimensionsKey, ReindexModelKey, ReindexDimensionsKey}
+
+// MergeFrom folds another copy of the index into this one, so a run that lost
+// a push race keeps the winner's work. Items missing here, or updated later
+// there, replace the local row together with their vectors and chunks; when
+// the other index was embedded with a different model they are queued for
+// re-embedding instead. Newer pair verdicts and index_meta entries are copied
+// too, key by key. Deletions travel as tombstones: an item or meta key deleted
+// on either side stays deleted unless the other copy was updated after the
+// deletion.
+func (s *Store) MergeFrom(ctx context.Context, otherPath string) (stats MergeStats, err error) {
+	if s == nil || s.db == nil {
+		return MergeStats{}, errors.New("store is not initialized")
+	}
+
+	// Opening the other index migrates it to this schema first.
+	other, err := Open(ctx, otherPath)
+	if err != nil {
+		return MergeStats{}, fmt.Errorf("open merge source: %w", err)
+	}
+	otherVersion, err := currentSchemaVersion(ctx, other.db)
+	if err == nil && otherVersion > latestSchemaVersion {
+		err = fmt.Errorf("merge source has schema v%d, newer than v%d", otherVersion, latestSchemaVersion)
+	}
+	otherSpace, otherFound, spaceErr := other.StoredEmbeddingSpace(ctx)
+	if closeErr := other.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = spaceErr
+	}
+	if err != nil {
+		return MergeStats{}, fmt.Errorf("read merge source: %w", err)
+	}
+	space, found, err := s.StoredEmbeddingSpace(ctx)
+	if err != nil {
+		return MergeStats{}, err
+	}
+	sameSpace := found && otherFound && space == otherSpace
+
+	// ATTACH is per connection, so the whole merge runs on one.
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return MergeStats{}, fmt.Errorf("merge connection: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `ATTACH DATABASE ? AS merge_src;`, otherPath); err != nil {
+		return MergeStats{}, fmt.Errorf("attach merge source: %w", err)
+	}
+	defer func() {
+		if _, detachErr := conn.ExecContext(context.WithoutCancel(ctx), `DETACH DATABASE merge_src;`); detachErr != nil && err == nil {
+			err = fmt.Errorf("detach merge source: %w", detachErr)
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return MergeStats{}, fmt.Errorf("begin merge: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if stats.Deleted, err = mergeTombstones(ctx, tx); err != nil {
+		return MergeStats{}, err
+	}
+	ids, err := newerItemIDs(ctx, tx)
+	if err != nil {
+		return MergeStats{}, err
+	}
+	for _, id := range ids {
+		copied, pending, err := mergeItem(ctx, tx, id, sameSpace)
+		if err != nil {
+			return MergeStats{}, fmt.Errorf("merge item %s: %w", id, err)
+		}
+		stats.Items++
+		stats.Vectors += copied
+		if pending {
+			stats.Pending++
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, `
+INSERT INTO pair_feedback(item_a, item_b, verdict, actor, created_at)
+SELECT item_a, item_b, verdict, actor, created_at FROM merge_src.pair_feedback WHERE true
+ON CONFLICT(item_a, item_b) DO UPDATE SET
+    verdict=excluded.verdict,
+    actor=excluded.actor,
+    created_at=excluded.created_at
+WHERE unixepoch(excluded.created_at) > unixepoch(pair_feedback.created_at);
+`)
+	if err != nil {
+		return MergeStats{}, fmt.Errorf("merge pair feedback: %w", err)
+	}
+	feedback, err := res.RowsAffected()
+	if err != nil {
+		return MergeStats{}, fmt.Errorf("merge pair feedback rows: %w", err)
+	}
+	stats.Feedback = int(feedback)
+
+	if stats.Meta, err = mergeMeta(ctx, tx, sameSpace); err != nil {
+		return MergeStats{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return MergeStats{}, fmt.Errorf("commit merge: %w", err)
+	}
+	return stats, nil
+}
+
+// mergeMeta takes the other index's index_meta entries where they are newer
+// than the local ones and applies its meta tombstones. A calibration is only
+// taken from an index embedded with the same model, as it was fitted on
+// those vectors' scores.
+func mergeMeta(ctx context.Context, tx *sql.Tx, sameSpace bool) (int, error) {
+	skip := localMetaKeys
+	if !sameSpace {
+		skip = append(append([]string(nil), skip...), CalibrationKey)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(skip)), ", ")
+	args := make([]any, 0, len(skip))
+	for _, key := range skip {
+		args = append(args, key)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+INSERT INTO main.meta_tombstones(key, deleted_at)
+SELECT key, deleted_at FROM merge_src.meta_tombstones WHERE key NOT IN (`+placeholders+`)
+ON CONFLICT(key) DO UPDATE SET deleted_at=excluded.deleted_at
+WHERE unixepoch(excluded.deleted_at) > unixepoch(meta_tombstones.deleted_at);
+`, args...); err != nil {
+		return 0, fmt.Errorf("merge meta tombstones: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `
+DELETE FROM main.index_meta
+WHERE key NOT IN (`+placeholders+`)
+  AND EXISTS (
+    SELECT 1 FROM merge_src.meta_tombstones t
+    WHERE t.key = index_meta.key AND unixepoch(index_meta.updated_at) <= unixepoch(t.deleted_at)
+  );
+`, args...)
+	if err != nil {
+		return 0, fmt.Errorf("apply meta tombstones: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("apply meta tombstones rows: %w", err)
+	}
+
+	res, err = tx.ExecContext(ctx, `
+INSERT INTO main.index_meta(key, value, updated_at)
+SELECT o.key, o.value, o.updated_at
+FROM merge_src.index_meta o
+LEFT JOIN main.meta_tombstones t ON t.key = o.key
+WHERE o.key NOT IN (`+placeholders+`)
+  AND (t.key IS NULL OR unixepoch(o.updated_at) > unixepoch(t.deleted_at))
+ON CONFLICT(key) DO UPDATE SET
+    value=excluded.value,
+    updated_at=excluded.updated_at
+WHERE unixepoch(excluded.updated_at) > unixepoch(index_meta.updated_at);
+`, args...)
+	if err != nil {
+		return 0, fmt.Errorf("merge index meta: %w", err)
+	}
+	copied, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("merge index meta rows: %w", err)
+	}
+	return int(deleted + copied), nil
+}
+
+func newerItemIDs(ctx context.Context, tx *sql.Tx) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+SELECT o.id
+FROM merge_src.items o
+LEFT JOIN main.items l ON l.id = o.id
+LEFT JOIN main.item_tombstones t ON t.id = o.id
+WHERE (l.id IS NULL OR unixepoch(o.updated_at) > unixepoch(l.updated_at))
+  AND (t.id IS NULL OR unixepoch(o.updated_at) > unixepoch(t.deleted_at))
+ORDER BY o.id ASC;
+`)
+	if err != nil {
+		return nil, fmt.Errorf("list newer items: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan newer item: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate newer items: %w", err)
+	}
+	return ids, nil
+}
+
+// mergeTombstones copies the other index's tombstones and deletes the local
+// items they cover, unless the local copy was updated after the deletion.
+func mergeTombstones(ctx context.Context, tx *sql.Tx) (int, error) {
+	rows, err := tx.QueryContext(ctx, `
+SELECT t.id, t.deleted_at, l.id IS NOT NULL
+FROM merge_src.item_tombstones t
+LEFT JOIN main.items l ON l.id = t.id
+WHERE l.id IS NULL OR unixepoch(l.updated_at) <= unixepoch(t.deleted_at)
+ORDER BY t.id ASC;
+`)
+	if err != nil {
+		return 0, fmt.Errorf("list merge source tombstones: %w", err)
+	}
+	type tombstone struct {
+		id        string
+		deletedAt string
+		local     bool
+	}
+	var tombstones []tombstone
+	for rows.Next() {
+		var t tombstone
+		if err := rows.Scan(&t.id, &t.deletedAt, &t.local); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan merge source tombstone: %w", err)
+		}
+		tombstones = append(tombstones, t)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, fmt.Errorf("iterate merge source tombstones: %w", err)
+	}
+	rows.Close()
+
+	deleted := 0
+	for _, t := range tombstones {
+		deletedAt, err := time.Parse(time.RFC3339Nano, t.deletedAt)
+		if err != nil {
+			return 0, fmt.Errorf("parse tombstone for %s: %w", t.id, err)
+		}
+		if t.local {
+			if err := deleteItemRows(ctx, tx, t.id); err != nil {
+				return 0, err
+			}
+			deleted++
+		}
+		if err := writeTombstone(ctx, tx, t.id, deletedAt); err != nil {
+			return 0, err
+		}
+	}
+	return deleted, nil
+}
+
+// mergeItem replaces the local item with the merge source's row. Deleting
+// before inserting keeps the FTS triggers in step with the new rowid.
+func mergeItem(ctx context.Context, tx *sql.Tx, id string, sameSpace bool) (vectors int, pending bool, err error) {
+	if err := deleteItemChunks(ctx, tx, id); err != nil {
+		return 0, false, err
+	}
+	for _, stmt := range []string{
+		`DELETE FROM main.items_vec WHERE id = ?;`,
+		`DELETE FROM main.pending_embeddings WHERE id = ?;`,
+		`DELETE FROM main.items WHERE id = ?;`,
+	} {
+		if _, err := tx.ExecContext(ctx, stmt, id); err != nil {
+			return 0, false, err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO main.items(`+itemColumns+`) SELECT `+itemColumns+` FROM merge_src.items WHERE id = ?;`, id); err != nil {
+		return 0, false, fmt.Errorf("copy item row: %w", err)
+	}
+
+	var title, body string
+	if err := tx.QueryRowContext(ctx, `SELECT title, body FROM main.items WHERE id = ?;`, id).Scan(&title, &body); err != nil {
+		return 0, false, fmt.Errorf("read merged item: %w", err)
+	}
+	if err := writeMinHash(ctx, tx, id, title, body); err != nil {
+		return 0, false, err
+	}
+
+	var blob []byte
+	err = tx.QueryRowContext(ctx, `SELECT embedding FROM merge_src.items_vec WHERE id = ?;`, id).Scan(&blob)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// Not embedded on the other side either; keep its pending entry, if any.
+		res, err := tx.ExecContext(ctx, `INSERT INTO main.pending_embeddings(id, reason, queued_at) SELECT id, reason, queued_at FROM merge_src.pending_embeddings WHERE id = ?;`, id)
+		if err != nil {
+			return 0, false, fmt.Errorf("copy pending embedding: %w", err)
+		}
+		n, err := res.RowsAffected()
+		return 0, n > 0, err
+	case err != nil:
+		return 0, false, fmt.Errorf("read merge source vector: %w", err)
+	case !sameSpace:
+		queuedAt := time.Now().UTC().Format(time.RFC3339Nano)
+		if _, err := tx.ExecContext(ctx, `INSERT INTO main.pending_embeddings(id, reason, queued_at) VALUES(?, ?, ?);`, id, "merged from an index with another embedding model", queuedAt); err != nil {
+			return 0, false, fmt.Errorf("queue merged item: %w", err)
+		}
+		return 0, true, nil
+	}
+
+	if err := insertVectorRow(ctx, tx, "items_vec", "id", id, id, blob); err != nil {
+		return 0, false, fmt.Errorf("copy vector: %w", err)
+	}
+	chunks, err := mergeChunks(ctx, tx, id)
+	if err != nil {
+		return 0, false, err
+	}
+	return 1 + chunks, false, nil
+}
+
+func mergeChunks(ctx context.Context, tx *sql.Tx, itemID string) (int, error) {
+	rows, err := tx.QueryContext(ctx, `
+SELECT c.chunk_id, c.chunk_index, v.embedding
+FROM merge_src.item_chunks c
+JOIN merge_src.items_chunks_vec v ON v.chunk_id = c.chunk_id
+WHERE c.item_id = ?
+ORDER BY c.chunk_index ASC;
+`, itemID)
+	if err != nil {
+		return 0, fmt.Errorf("list merge source chunks: %w", err)
+	}
+	type chunk struct {
+		id    string
+		index int
+		blob  []byte
+	}
+	var chunks []chunk
+	for rows.Next() {
+		var c chunk
+		if err := rows.Scan(&c.id, &c.index, &c.blob); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan merge source chunk: %w", err)
+		}
+		chunks = append(chunks, c)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, fmt.Errorf("iterate merge source chunks: %w", err)
+	}
+	rows.Close()
+
+	for _, c := range chunks {
+		if err := insertVectorRow(ctx, tx, "items_chunks_vec", "chunk_id", c.id, itemID, c.blob); err != nil {
+			return 0, fmt.Errorf("copy chunk vector %s: %w", c.id, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO main.item_chunks(chunk_id, item_id, chunk_index) VALUES(?, ?, ?);`, c.id, itemID, c.index); err != nil {
+			return 0, fmt.Errorf("copy chunk %s: %w", c.id, err)
+		}
+	}
+	return len(chunks), nil
+}
@@ -0,0 +1,324 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMergeFrom_TakesNewerItemsVectorsAndVerdicts(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	space := EmbeddingSpace{Model: "m", Dimensions: 3}
+	base := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	remotePath := filepath.Join(dir, "remote.db")
+	remote, err := OpenWithEmbedding(ctx, remotePath, space)
+	if err != nil {
+		t.Fatalf("OpenWithEmbedding(remote) error = %v", err)
+	}
+	for _, rec := range []ItemRecord{
+		{ID: "issue/1", Type: "issue", Number: 1, Title: "login timeout after upgrade", State: "closed", ContentHash: "h1", EmbeddingModel: "m", UpdatedAt: base.Add(time.Hour)},
+		{ID: "issue/3", Type: "issue", Number: 3, Title: "crash on save", State: "open", UpdatedAt: base},
+		{ID: "issue/4", Type: "issue", Number: 4, Title: "stale remote copy", State: "open", UpdatedAt: base.Add(-time.Hour)},
+	} {
+		if err := remote.UpsertItem(ctx, rec); err != nil {
+			t.Fatalf("remote UpsertItem(%s) error = %v", rec.ID, err)
+		}
+	}
+	if err := remote.UpsertVector(ctx, "issue/1", []float32{0, 1, 0}); err != nil {
+		t.Fatalf("remote UpsertVector() error = %v", err)
+	}
+	if err := remote.ReplaceChunkVectors(ctx, "issue/1", [][]float32{{0, 0, 1}}); err != nil {
+		t.Fatalf("remote ReplaceChunkVectors() error = %v", err)
+	}
+	if err := remote.MarkPendingEmbedding(ctx, "issue/3", "embedding failed"); err != nil {
+		t.Fatalf("remote MarkPendingEmbedding() error = %v", err)
+	}
+	if err := remote.RecordPairFeedback(ctx, PairFeedback{ItemA: "issue/1", ItemB: "issue/3", Verdict: PairVerdictNotDuplicate, CreatedAt: base}); err != nil {
+		t.Fatalf("remote RecordPairFeedback() error = %v", err)
+	}
+	if err := remote.Close(); err != nil {
+		t.Fatalf("remote Close() error = %v", err)
+	}
+
+	s, err := OpenWithEmbedding(ctx, filepath.Join(dir, "local.db"), space)
+	if err != nil {
+		t.Fatalf("OpenWithEmbedding(local) error = %v", err)
+	}
+	defer func() {
+		if cerr := s.Close(); cerr != nil {
+			t.Fatalf("Close() error = %v", cerr)
+		}
+	}()
+	for _, rec := range []ItemRecord{
+		{ID: "issue/1", Type: "issue", Number: 1, Title: "login timeout", State: "open", UpdatedAt: base},
+		{ID: "issue/2", Type: "issue", Number: 2, Title: "only here", State: "open", UpdatedAt: base},
+		{ID: "issue/4", Type: "issue", Number: 4, Title: "fresh local copy", State: "open", UpdatedAt: base},
+	} {
+		if err := s.UpsertItem(ctx, rec); err != nil {
+			t.Fatalf("UpsertItem(%s) error = %v", rec.ID, err)
+		}
+	}
+	if err := s.UpsertVector(ctx, "issue/1", []float32{1, 0, 0}); err != nil {
+		t.Fatalf("UpsertVector() error = %v", err)
+	}
+
+	stats, err := s.MergeFrom(ctx, remotePath)
+	if err != nil {
+		t.Fatalf("MergeFrom() error = %v", err)
+	}
+	if stats.Items != 2 || stats.Vectors != 2 || stats.Pending != 1 || stats.Feedback != 1 {
+		t.Fatalf("MergeFrom() stats = %+v", stats)
+	}
+
+	got, err := s.GetItem(ctx, "issue/1")
+	if err != nil || got.Title != "login timeout after upgrade" || got.State != "closed" {
+		t.Fatalf("issue/1 = %+v, %v; want the newer remote row", got, err)
+	}
+	if got, err := s.GetItem(ctx, "issue/4"); err != nil || got.Title != "fresh local copy" {
+		t.Fatalf("issue/4 = %+v, %v; want the newer local row", got, err)
+	}
+	if _, err := s.GetItem(ctx, "issue/2"); err != nil {
+		t.Fatalf("local-only issue/2 should be kept: %v", err)
+	}
+
+	vec, found, err := s.LookupEmbedding(ctx, "issue/1", "h1", "m")
+	if err != nil || !found || vec[1] != 1 {
+		t.Fatalf("issue/1 vector = %v, %v, %v; want the remote vector", vec, found, err)
+	}
+	chunks, err := s.LookupChunkVectors(ctx, "issue/1")
+	if err != nil || len(chunks) != 1 {
+		t.Fatalf("issue/1 chunks = %v, %v", chunks, err)
+	}
+	fts, err := s.SearchFTS(ctx, "upgrade", "", 5, SearchFilter{})
+	if err != nil || len(fts) != 1 || fts[0].ID != "issue/1" {
+		t.Fatalf("SearchFTS(upgrade) = %+v, %v", fts, err)
+	}
+	pending, err := s.ListPendingEmbeddings(ctx, 10)
+	if err != nil || len(pending) != 1 || pending[0].ID != "issue/3" {
+		t.Fatalf("pending = %+v, %v", pending, err)
+	}
+	verdicts, err := s.PairVerdictsFor(ctx, "issue/3")
+	if err != nil || verdicts["issue/1"] != PairVerdictNotDuplicate {
+		t.Fatalf("verdicts = %v, %v", verdicts, err)
+	}
+}
+
+func TestMergeFrom_QueuesVectorsFromAnotherModel(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	remotePath := filepath.Join(dir, "remote.db")
+	remote, err := OpenWithEmbedding(ctx, remotePath, EmbeddingSpace{Model: "other", Dimensions: 2})
+	if err != nil {
+		t.Fatalf("OpenWithEmbedding(remote) error = %v", err)
+	}
+	if err := remote.UpsertItem(ctx, ItemRecord{ID: "pr/5", Type: "pr", Number: 5, Title: "retry uploads", State: "open", ContentHash: "h5", EmbeddingModel: "other"}); err != nil {
+		t.Fatalf("remote UpsertItem() error = %v", err)
+	}
+	if err := remote.UpsertVector(ctx, "pr/5", []float32{1, 0}); err != nil {
+		t.Fatalf("remote UpsertVector() error = %v", err)
+	}
+	if err := remote.Close(); err != nil {
+		t.Fatalf("remote Close() error = %v", err)
+	}
+
+	s, err := OpenWithEmbedding(ctx, filepath.Join(dir, "local.db"), EmbeddingSpace{Model: "m", Dimensions: 3})
+	if err != nil {
+		t.Fatalf("OpenWithEmbedding(local) error = %v", err)
+	}
+	defer func() {
+		if cerr := s.Close(); cerr != nil {
+			t.Fatalf("Close() error = %v", cerr)
+		}
+	}()
+
+	stats, err := s.MergeFrom(ctx, remotePath)
+	if err != nil {
+		t.Fatalf("MergeFrom() error = %v", err)
+	}
+	if stats.Items != 1 || stats.Vectors != 0 || stats.Pending != 1 {
+		t.Fatalf("MergeFrom() stats = %+v", stats)
+	}
+	if _, found, err := s.LookupEmbedding(ctx, "pr/5", "h5", "other"); err != nil || found {
+		t.Fatalf("vector from another model should not be copied: found=%v err=%v", found, err)
+	}
+}
+
+func TestMergeFrom_KeepsDeletedItemsDeleted(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	space := EmbeddingSpace{Model: "m", Dimensions: 3}
+	base := time.Now().UTC().Add(-24 * time.Hour)
+
+	// The remote still has issue/1 as it was before this run deleted it, and
+	// has itself deleted issue/2, which this run still holds.
+	remotePath := filepath.Join(dir, "remote.db")
+	remote, err := OpenWithEmbedding(ctx, remotePath, space)
+	if err != nil {
+		t.Fatalf("OpenWithEmbedding(remote) error = %v", err)
+	}
+	for _, rec := range []ItemRecord{
+		{ID: "issue/1", Type: "issue", Number: 1, Title: "transferred away", State: "open", UpdatedAt: base},
+		{ID: "issue/2", Type: "issue", Number: 2, Title: "deleted remotely", State: "open", UpdatedAt: base},
+	} {
+		if err := remote.UpsertItem(ctx, rec); err != nil {
+			t.Fatalf("remote UpsertItem(%s) error = %v", rec.ID, err)
+		}
+	}
+	if err := remote.UpsertVector(ctx, "issue/1", []float32{1, 0, 0}); err != nil {
+		t.Fatalf("remote UpsertVector() error = %v", err)
+	}
+	if err := remote.DeleteItem(ctx, "issue/2"); err != nil {
+		t.Fatalf("remote DeleteItem() error = %v", err)
+	}
+	if err := remote.Close(); err != nil {
+		t.Fatalf("remote Close() error = %v", err)
+	}
+
+	s, err := OpenWithEmbedding(ctx, filepath.Join(dir, "local.db"), space)
+	if err != nil {
+		t.Fatalf("OpenWithEmbedding(local) error = %v", err)
+	}
+	defer func() {
+		if cerr := s.Close(); cerr != nil {
+			t.Fatalf("Close() error = %v", cerr)
+		}
+	}()
+	for _, rec := range []ItemRecord{
+		{ID: "issue/1", Type: "issue", Number: 1, Title: "transferred away", State: "open", UpdatedAt: base},
+		{ID: "issue/2", Type: "issue", Number: 2, Title: "deleted remotely", State: "open", UpdatedAt: base},
+	} {
+		if err := s.UpsertItem(ctx, rec); err != nil {
+			t.Fatalf("UpsertItem(%s) error = %v", rec.ID, err)
+		}
+	}
+	if err := s.DeleteItem(ctx, "issue/1"); err != nil {
+		t.Fatalf("DeleteItem() error = %v", err)
+	}
+
+	stats, err := s.MergeFrom(ctx, remotePath)
+	if err != nil {
+		t.Fatalf("MergeFrom() error = %v", err)
+	}
+	if stats.Items != 0 || stats.Deleted != 1 {
+		t.Fatalf("MergeFrom() stats = %+v, want nothing copied and one deletion", stats)
+	}
+	for _, id := range []string{"issue/1", "issue/2"} {
+		if got, err := s.GetItem(ctx, id); err == nil {
+			t.Fatalf("%s = %+v; want it to stay deleted", id, got)
+		}
+	}
+	if _, found, err := s.LookupEmbedding(ctx, "issue/1", "", "m"); err != nil || found {
+		t.Fatalf("issue/1 vector found = %v, %v; want none", found, err)
+	}
+
+	// A copy updated after the deletion, e.g. transferred back, is live again.
+	if err := s.UpsertItem(ctx, ItemRecord{ID: "issue/1", Type: "issue", Number: 1, Title: "transferred back", State: "open", UpdatedAt: time.Now().UTC()}); err != nil {
+		t.Fatalf("UpsertItem(issue/1) error = %v", err)
+	}
+	if stats, err := s.MergeFrom(ctx, remotePath); err != nil || stats.Items != 0 {
+		t.Fatalf("second MergeFrom() = %+v, %v", stats, err)
+	}
+	if got, err := s.GetItem(ctx, "issue/1"); err != nil || got.Title != "transferred back" {
+		t.Fatalf("issue/1 = %+v, %v; want the recreated row", got, err)
+	}
+}
+
+func TestMergeFrom_MergesIndexMetaByKey(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	space := EmbeddingSpace{Model: "m", Dimensions: 3}
+	base := time.Now().UTC().Add(-24 * time.Hour)
+	cal := Calibration{Method: CalibrationPlatt, Coefficients: []float64{-4, 6, 1, 1}, Fusion: FusionRRF, Pairs: 12, Duplicates: 6}
+
+	remotePath := filepath.Join(dir, "remote.db")
+	remote, err := OpenWithEmbedding(ctx, remotePath, space)
+	if err != nil {
+		t.Fatalf("OpenWithEmbedding(remote) error = %v", err)
+	}
+	if err := remote.SaveCalibration(ctx, cal); err != nil {
+		t.Fatalf("remote SaveCalibration() error = %v", err)
+	}
+	setMetaAt(t, remote, "backfill.checkpoint", "older", base)
+	setMetaAt(t, remote, "stale.key", "x", base)
+	if err := deleteMetaKeys(ctx, remote.db, base.Add(time.Hour), "stale.key"); err != nil {
+		t.Fatalf("remote deleteMetaKeys() error = %v", err)
+	}
+	if err := remote.Close(); err != nil {
+		t.Fatalf("remote Close() error = %v", err)
+	}
+
+	s, err := OpenWithEmbedding(ctx, filepath.Join(dir, "local.db"), space)
+	if err != nil {
+		t.Fatalf("OpenWithEmbedding(local) error = %v", err)
+	}
+	defer func() {
+		if cerr := s.Close(); cerr != nil {
+			t.Fatalf("Close() error = %v", cerr)
+		}
+	}()
+	setMetaAt(t, s, "backfill.checkpoint", "newer", base.Add(2*time.Hour))
+	setMetaAt(t, s, "stale.key", "x", base)
+
+	stats, err := s.MergeFrom(ctx, remotePath)
+	if err != nil {
+		t.Fatalf("MergeFrom() error = %v", err)
+	}
+	if stats.Meta != 2 {
+		t.Fatalf("MergeFrom() stats = %+v, want the calibration taken and one key deleted", stats)
+	}
+	got, found, err := s.LoadCalibration(ctx)
+	if err != nil || !found || got.Method != CalibrationPlatt || got.Pairs != 12 {
+		t.Fatalf("LoadCalibration() = %+v, %v, %v; want the remote calibration", got, found, err)
+	}
+	if value, _, err := s.GetMeta(ctx, "backfill.checkpoint"); err != nil || value != "newer" {
+		t.Fatalf("backfill.checkpoint = %q, %v; want the newer local value", value, err)
+	}
+	if _, found, err := s.GetMeta(ctx, "stale.key"); err != nil || found {
+		t.Fatalf("stale.key found = %v, %v; want it deleted by the remote tombstone", found, err)
+	}
+
+	// A calibration fitted on another model's scores is not taken.
+	otherPath := filepath.Join(dir, "other.db")
+	other, err := OpenWithEmbedding(ctx, otherPath, EmbeddingSpace{Model: "other", Dimensions: 3})
+	if err != nil {
+		t.Fatalf("OpenWithEmbedding(other) error = %v", err)
+	}
+	if err := other.SaveCalibration(ctx, Calibration{Method: CalibrationIsotonic, Fusion: FusionRRF}); err != nil {
+		t.Fatalf("other SaveCalibration() error = %v", err)
+	}
+	if err := other.Close(); err != nil {
+		t.Fatalf("other Close() error = %v", err)
+	}
+	if _, err := s.MergeFrom(ctx, otherPath); err != nil {
+		t.Fatalf("MergeFrom(other) error = %v", err)
+	}
+	if got, _, err := s.LoadCalibration(ctx); err != nil || got.Method != CalibrationPlatt {
+		t.Fatalf("LoadCalibration() = %+v, %v; want the same-model calibration kept", got, err)
+	}
+	if space, _, err := s.StoredEmbeddingSpace(ctx); err != nil || space.Model != "m" {
+		t.Fatalf("StoredEmbeddingSpace() = %+v, %v; want the local model kept", space, err)
+	}
+}
+
+func setMetaAt(t *testing.T, s *Store, key, value string, at time.Time) {
+	t.Helper()
+	_, err := s.db.ExecContext(context.Background(), `
+INSERT INTO index_meta(key, value, updated_at) VALUES(?, ?, ?)
+ON CONFLICT(key) DO UPDATE SET value=excluded.value, updated_at=excluded.updated_at;
+`, key, value, at.Format(time.RFC3339Nano))
+	if err != nil {
+		t.Fatalf("set meta %s error = %v", key, err)
+	}
+}
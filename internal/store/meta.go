@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GetMeta reads one value from the index_meta key/value table.
+// found=false means the key has never been written.
+func (s *Store) GetMeta(ctx context.Context, key string) (value string, found bool, err error) {
+	if s == nil || s.db == nil {
+		return "", false, errors.New("store is not initialized")
+	}
+	if strings.TrimSpace(key) == "" {
+		return "", false, errors.New("meta key is required")
+	}
+
+	err = s.db.QueryRowContext(ctx, `SELECT value FROM index_meta WHERE key = ?;`, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("get meta %s: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// SetMeta writes one value to the index_meta key/value table.
+func (s *Store) SetMeta(ctx context.Context, key, value string) error {
+	if s == nil || s.db == nil {
+		return errors.New("store is not initialized")
+	}
+	if strings.TrimSpace(key) == "" {
+		return errors.New("meta key is required")
+	}
+
+	const stmt = `
+INSERT INTO index_meta(key, value, updated_at) VALUES(?, ?, ?)
+ON CONFLICT(key) DO UPDATE SET
+    value=excluded.value,
+    updated_at=excluded.updated_at;
+`
+	updatedAt := time.Now().UTC().Format(time.RFC3339Nano)
+	if _, err := s.db.ExecContext(ctx, stmt, key, value, updatedAt); err != nil {
+		return fmt.Errorf("set meta %s: %w", key, err)
+	}
+	return nil
+}
+
+// DeleteMeta removes one key from the index_meta table. Missing keys are a
+// no-op. A tombstone keeps the key deleted when another index copy is merged.
+func (s *Store) DeleteMeta(ctx context.Context, key string) error {
+	if s == nil || s.db == nil {
+		return errors.New("store is not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin delete meta: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	if err = deleteMetaKeys(ctx, tx, time.Now().UTC(), key); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit delete meta %s: %w", key, err)
+	}
+	return nil
+}
+
+// deleteMetaKeys removes keys from index_meta and records when they were
+// deleted, so MergeFrom does not bring back an older copy of them. Keys that
+// were not stored get no tombstone, so resetting a fresh index cannot drop a
+// calibration another run fitted meanwhile.
+func deleteMetaKeys(ctx context.Context, ex execer, deletedAt time.Time, keys ...string) error {
+	const tombstone = `
+INSERT INTO meta_tombstones(key, deleted_at) VALUES(?, ?)
+ON CONFLICT(key) DO UPDATE SET deleted_at=excluded.deleted_at
+WHERE unixepoch(excluded.deleted_at) > unixepoch(meta_tombstones.deleted_at);
+`
+	for _, key := range keys {
+		res, err := ex.ExecContext(ctx, `DELETE FROM index_meta WHERE key = ?;`, key)
+		if err != nil {
+			return fmt.Errorf("delete meta %s: %w", key, err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("delete meta %s: %w", key, err)
+		}
+		if n == 0 {
+			continue
+		}
+		if _, err := ex.ExecContext(ctx, tombstone, key, deletedAt.Format(time.RFC3339Nano)); err != nil {
+			return fmt.Errorf("record meta tombstone for %s: %w", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestMetaSetGetDelete(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	s, err := Open(ctx, filepath.Join(t.TempDir(), "meta.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() {
+		if cerr := s.Close(); cerr != nil {
+			t.Fatalf("Close() error = %v", cerr)
+		}
+	}()
+
+	if _, found, err := s.GetMeta(ctx, "backfill.next_page"); err != nil || found {
+		t.Fatalf("GetMeta() on empty table found=%v err=%v, want not found", found, err)
+	}
+
+	if err := s.SetMeta(ctx, "backfill.next_page", "3"); err != nil {
+		t.Fatalf("SetMeta() error = %v", err)
+	}
+	if err := s.SetMeta(ctx, "backfill.next_page", "4"); err != nil {
+		t.Fatalf("second SetMeta() error = %v", err)
+	}
+
+	value, found, err := s.GetMeta(ctx, "backfill.next_page")
+	if err != nil || !found {
+		t.Fatalf("GetMeta() found=%v err=%v, want found", found, err)
+	}
+	if value != "4" {
+		t.Fatalf("GetMeta() value = %q, want 4", value)
+	}
+
+	if err := s.DeleteMeta(ctx, "backfill.next_page"); err != nil {
+		t.Fatalf("DeleteMeta() error = %v", err)
+	}
+	if _, found, err := s.GetMeta(ctx, "backfill.next_page"); err != nil || found {
+		t.Fatalf("GetMeta() after delete found=%v err=%v, want not found", found, err)
+	}
+
+	if err := s.SetMeta(ctx, " ", "x"); err == nil {
+		t.Fatalf("expected empty key validation error")
+	}
+}
@@ -5,11 +5,12 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
 
-const latestSchemaVersion = 2
+const latestSchemaVersion = 14
 
 type migration struct {
 	version int
@@ -20,6 +21,18 @@ type migration struct {
 var migrations = []migration{
 	{version: 1, name: "create_items", up: migrateV1},
 	{version: 2, name: "create_search_tables", up: migrateV2},
+	{version: 3, name: "create_index_meta", up: migrateV3},
+	{version: 4, name: "create_pending_embeddings", up: migrateV4},
+	{version: 5, name: "add_item_embedding_fingerprint", up: migrateV5},
+	{version: 6, name: "create_pair_feedback", up: migrateV6},
+	{version: 7, name: "create_reindex_staging", up: migrateV7},
+	{version: 8, name: "create_chunk_vectors", up: migrateV8},
+	{version: 9, name: "create_minhash_signatures", up: migrateV9},
+	{version: 10, name: "add_vector_filter_metadata", up: migrateV10},
+	{version: 11, name: "add_item_github_metadata", up: migrateV11},
+	{version: 12, name: "create_item_tombstones", up: migrateV12},
+	{version: 13, name: "filter_closed_items_by_closed_at", up: migrateV13},
+	{version: 14, name: "create_meta_tombstones", up: migrateV14},
 }
 
 func LatestSchemaVersion() int {
@@ -166,6 +179,256 @@ END;
 	return ensureVectorTable(ctx, tx)
 }
 
+func migrateV3(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`
+CREATE TABLE IF NOT EXISTS index_meta (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
+`,
+	}
+
+	return execStatements(ctx, tx, stmts)
+}
+
+func migrateV4(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`
+CREATE TABLE IF NOT EXISTS pending_embeddings (
+    id TEXT PRIMARY KEY,
+    reason TEXT NOT NULL DEFAULT '',
+    queued_at TEXT NOT NULL
+);
+`,
+	}
+
+	return execStatements(ctx, tx, stmts)
+}
+
+func migrateV5(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`ALTER TABLE items ADD COLUMN content_hash TEXT NOT NULL DEFAULT '';`,
+		`ALTER TABLE items ADD COLUMN embedding_model TEXT NOT NULL DEFAULT '';`,
+	}
+
+	return execStatements(ctx, tx, stmts)
+}
+
+func migrateV6(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`
+CREATE TABLE IF NOT EXISTS pair_feedback (
+    item_a TEXT NOT NULL,
+    item_b TEXT NOT NULL,
+    verdict TEXT NOT NULL,
+    actor TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (item_a, item_b)
+);
+`,
+		`CREATE INDEX IF NOT EXISTS idx_pair_feedback_item_b ON pair_feedback(item_b);`,
+	}
+
+	return execStatements(ctx, tx, stmts)
+}
+
+func migrateV7(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`
+CREATE TABLE IF NOT EXISTS reindex_vectors (
+    id TEXT PRIMARY KEY,
+    embedding BLOB NOT NULL,
+    content_hash TEXT NOT NULL DEFAULT '',
+    staged_at TEXT NOT NULL
+);
+`,
+	}
+
+	return execStatements(ctx, tx, stmts)
+}
+
+func migrateV8(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`
+CREATE TABLE IF NOT EXISTS item_chunks (
+    chunk_id TEXT PRIMARY KEY,
+    item_id TEXT NOT NULL,
+    chunk_index INTEGER NOT NULL
+);
+`,
+		`CREATE INDEX IF NOT EXISTS idx_item_chunks_item_id ON item_chunks(item_id);`,
+		`
+CREATE TABLE IF NOT EXISTS reindex_chunks (
+    chunk_id TEXT PRIMARY KEY,
+    item_id TEXT NOT NULL,
+    chunk_index INTEGER NOT NULL,
+    embedding BLOB NOT NULL,
+    content_hash TEXT NOT NULL DEFAULT ''
+);
+`,
+		`CREATE INDEX IF NOT EXISTS idx_reindex_chunks_item_id ON reindex_chunks(item_id);`,
+	}
+	if err := execStatements(ctx, tx, stmts); err != nil {
+		return err
+	}
+
+	// Size the chunk table like the existing items_vec so both hold one space.
+	return createChunkVectorTable(ctx, tx, existingVectorDimensions(ctx, tx))
+}
+
+// existingVectorDimensions reads the size of the current items_vec table.
+func existingVectorDimensions(ctx context.Context, tx *sql.Tx) int {
+	dimensions := legacyVectorDimensions
+	var tableSQL string
+	if err := tx.QueryRowContext(ctx, `SELECT sql FROM sqlite_master WHERE name = 'items_vec';`).Scan(&tableSQL); err == nil {
+		if match := vectorDimensionsPattern.FindStringSubmatch(tableSQL); match != nil {
+			if dims, err := strconv.Atoi(match[1]); err == nil {
+				dimensions = dims
+			}
+		}
+	}
+	return dimensions
+}
+
+func migrateV9(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`
+CREATE TABLE IF NOT EXISTS item_minhash (
+    item_id TEXT PRIMARY KEY,
+    signature BLOB NOT NULL
+);
+`,
+		`
+CREATE TABLE IF NOT EXISTS item_minhash_bands (
+    band_key INTEGER NOT NULL,
+    item_id TEXT NOT NULL
+);
+`,
+		`CREATE INDEX IF NOT EXISTS idx_item_minhash_bands_key ON item_minhash_bands(band_key);`,
+		`CREATE INDEX IF NOT EXISTS idx_item_minhash_bands_item_id ON item_minhash_bands(item_id);`,
+	}
+	if err := execStatements(ctx, tx, stmts); err != nil {
+		return err
+	}
+	return backfillMinHashes(ctx, tx)
+}
+
+// migrateV10 rebuilds both vector tables with the item metadata search
+// filters run on, copying every stored vector across.
+// vectorMetaSelectV10 is vectorMetaSelect as it stood at v10, before items
+// had closed_at; migrateV13 recomputes active_unix with the current one.
+const vectorMetaSelectV10 = `
+    COALESCE(i.state, 'open'),
+    COALESCE(unixepoch(i.created_at), 0),
+    COALESCE(unixepoch(i.updated_at), 0),
+    CASE WHEN COALESCE(i.state, 'open') = 'open' THEN ` + activeUnixOpen + ` ELSE COALESCE(unixepoch(i.updated_at), 0) END`
+
+func migrateV10(ctx context.Context, tx *sql.Tx) error {
+	dimensions := existingVectorDimensions(ctx, tx)
+	stmts := []string{
+		`CREATE TABLE migrate_items_vec AS SELECT id, embedding FROM items_vec;`,
+		`CREATE TABLE migrate_items_chunks_vec AS SELECT chunk_id, embedding FROM items_chunks_vec;`,
+		`DROP TABLE items_vec;`,
+		`DROP TABLE items_chunks_vec;`,
+	}
+	if err := execStatements(ctx, tx, stmts); err != nil {
+		return err
+	}
+	if err := createVectorTables(ctx, tx, dimensions); err != nil {
+		return err
+	}
+
+	stmts = []string{
+		`
+INSERT INTO items_vec(id, embedding, ` + vectorMetaColumns + `)
+SELECT m.id, m.embedding, COALESCE(i.type, substr(m.id, 1, instr(m.id, '/') - 1)),` + vectorMetaSelectV10 + `
+FROM migrate_items_vec m
+LEFT JOIN items i ON i.id = m.id;
+`,
+		`
+INSERT INTO items_chunks_vec(chunk_id, embedding, ` + vectorMetaColumns + `)
+SELECT m.chunk_id, m.embedding, COALESCE(i.type, substr(m.chunk_id, 1, instr(m.chunk_id, '/') - 1)),` + vectorMetaSelectV10 + `
+FROM migrate_items_chunks_vec m
+LEFT JOIN item_chunks c ON c.chunk_id = m.chunk_id
+LEFT JOIN items i ON i.id = c.item_id;
+`,
+		`DROP TABLE migrate_items_vec;`,
+		`DROP TABLE migrate_items_chunks_vec;`,
+	}
+	return execStatements(ctx, tx, stmts)
+}
+
+func migrateV11(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`ALTER TABLE items ADD COLUMN state_reason TEXT NOT NULL DEFAULT '';`,
+		`ALTER TABLE items ADD COLUMN milestone TEXT NOT NULL DEFAULT '';`,
+		`ALTER TABLE items ADD COLUMN assignees TEXT NOT NULL DEFAULT '[]';`,
+		`ALTER TABLE items ADD COLUMN draft INTEGER NOT NULL DEFAULT 0;`,
+		`ALTER TABLE items ADD COLUMN base_branch TEXT NOT NULL DEFAULT '';`,
+		`ALTER TABLE items ADD COLUMN closed_at TEXT NOT NULL DEFAULT '';`,
+	}
+
+	return execStatements(ctx, tx, stmts)
+}
+
+func migrateV12(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`
+CREATE TABLE IF NOT EXISTS item_tombstones (
+    id TEXT PRIMARY KEY,
+    deleted_at TEXT NOT NULL
+);
+`,
+	}
+
+	return execStatements(ctx, tx, stmts)
+}
+
+// migrateV13 recomputes the vector metadata of closed items, whose
+// active_unix now comes from closed_at instead of updated_at.
+func migrateV13(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM items WHERE state != 'open' AND closed_at != '';`)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if err := syncVectorMeta(ctx, tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateV14(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`
+CREATE TABLE IF NOT EXISTS meta_tombstones (
+    key TEXT PRIMARY KEY,
+    deleted_at TEXT NOT NULL
+);
+`,
+	}
+
+	return execStatements(ctx, tx, stmts)
+}
+
 func ensureFTSTable(ctx context.Context, tx *sql.Tx) error {
 	const ftsVirtualTable = `
 CREATE VIRTUAL TABLE IF NOT EXISTS items_fts USING fts5(
@@ -197,31 +460,68 @@ CREATE TABLE IF NOT EXISTS items_fts (
 }
 
 func ensureVectorTable(ctx context.Context, tx *sql.Tx) error {
-	const vectorVirtualTable = `
-CREATE VIRTUAL TABLE IF NOT EXISTS items_vec USING vec0(
-    id TEXT PRIMARY KEY,
-    embedding float[1536] distance_metric=cosine
+	return createVectorTable(ctx, tx, legacyVectorDimensions)
+}
+
+// createVectorTable creates items_vec for vectors of the given dimension.
+func createVectorTable(ctx context.Context, ex execer, dimensions int) error {
+	return createVec0Table(ctx, ex, "items_vec", "id", dimensions)
+}
+
+// createChunkVectorTable creates items_chunks_vec, which holds one vector per
+// chunk of long content, keyed by chunk id.
+func createChunkVectorTable(ctx context.Context, ex execer, dimensions int) error {
+	return createVec0Table(ctx, ex, "items_chunks_vec", "chunk_id", dimensions)
+}
+
+// createVectorTables (re)creates every vector table for one embedding space.
+func createVectorTables(ctx context.Context, ex execer, dimensions int) error {
+	if err := createVectorTable(ctx, ex, dimensions); err != nil {
+		return err
+	}
+	return createChunkVectorTable(ctx, ex, dimensions)
+}
+
+func createVec0Table(ctx context.Context, ex execer, table, keyColumn string, dimensions int) error {
+	vectorVirtualTable := fmt.Sprintf(`
+CREATE VIRTUAL TABLE IF NOT EXISTS %s USING vec0(
+    %s TEXT PRIMARY KEY,
+    item_type TEXT PARTITION KEY,
+    state TEXT,
+    created_unix INTEGER,
+    updated_unix INTEGER,
+    active_unix INTEGER,
+    embedding float[%d] distance_metric=cosine
 );
-`
+`, table, keyColumn, dimensions)
 
-	if _, err := tx.ExecContext(ctx, vectorVirtualTable); err == nil {
+	if _, err := ex.ExecContext(ctx, vectorVirtualTable); err == nil {
 		return nil
 	} else if !isModuleUnavailable(err, "vec0") {
 		return err
 	}
 
 	// Development fallback until sqlite-vec is wired in WP-002.
-	const vectorFallbackTable = `
-CREATE TABLE IF NOT EXISTS items_vec (
-    id TEXT PRIMARY KEY,
+	vectorFallbackTable := fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+    %s TEXT PRIMARY KEY,
+    item_type TEXT NOT NULL DEFAULT '',
+    state TEXT NOT NULL DEFAULT 'open',
+    created_unix INTEGER NOT NULL DEFAULT 0,
+    updated_unix INTEGER NOT NULL DEFAULT 0,
+    active_unix INTEGER NOT NULL DEFAULT 0,
     embedding BLOB NOT NULL
 );
-`
+`, table, keyColumn)
 
-	_, err := tx.ExecContext(ctx, vectorFallbackTable)
+	_, err := ex.ExecContext(ctx, vectorFallbackTable)
 	return err
 }
 
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
 func execStatements(ctx context.Context, tx *sql.Tx, stmts []string) error {
 	for _, stmt := range stmts {
 		if _, err := tx.ExecContext(ctx, stmt); err != nil {
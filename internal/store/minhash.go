@@ -0,0 +1,276 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// minHashShingle is the word n-gram size compared between items.
+	minHashShingle = 2
+	// minHashMinWords skips signatures for texts too short to tell a copy
+	// from a coincidence, such as a one-line title with no body.
+	minHashMinWords = 8
+	minHashSize     = 128
+	// minHashBands*minHashRows = minHashSize. Items share a band bucket with
+	// probability ~1 at Jaccard 0.8 and ~0.2 at 0.3, so candidates come from
+	// an index lookup and only plausible ones are scored.
+	minHashBands = 32
+	minHashRows  = minHashSize / minHashBands
+
+	// nearDuplicateMinJaccard is the lowest estimated overlap returned as a
+	// ranking signal.
+	nearDuplicateMinJaccard = 0.5
+	// NearExactJaccard is the estimated shingle overlap above which two items
+	// are treated as textual copies regardless of embedding similarity.
+	NearExactJaccard = 0.8
+)
+
+// NearDuplicateResult is one textual near-copy found by MinHash lookup.
+type NearDuplicateResult struct {
+	ID      string
+	Type    string
+	Number  int
+	Title   string
+	State   string
+	URL     string
+	Jaccard float64
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// minHashSignature holds the minimum of each hash permutation over the word
+// shingles of a text.
+type minHashSignature [minHashSize]uint32
+
+// computeMinHash signs an item's title and body. ok is false when the text is
+// too short for a reliable signature.
+func computeMinHash(title, body string) (sig minHashSignature, ok bool) {
+	words := strings.FieldsFunc(strings.ToLower(title+"\n"+body), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) < minHashMinWords {
+		return sig, false
+	}
+
+	for i := range sig {
+		sig[i] = ^uint32(0)
+	}
+	seen := map[uint64]struct{}{}
+	for i := 0; i+minHashShingle <= len(words); i++ {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(strings.Join(words[i:i+minHashShingle], " ")))
+		base := h.Sum64()
+		if _, dup := seen[base]; dup {
+			continue
+		}
+		seen[base] = struct{}{}
+		for p := range sig {
+			if v := uint32(mix64(base ^ minHashSeed(p))); v < sig[p] {
+				sig[p] = v
+			}
+		}
+	}
+	return sig, true
+}
+
+// jaccard estimates shingle overlap as the share of agreeing permutations.
+func (s minHashSignature) jaccard(other minHashSignature) float64 {
+	same := 0
+	for i := range s {
+		if s[i] == other[i] {
+			same++
+		}
+	}
+	return float64(same) / minHashSize
+}
+
+// bandKeys hashes each band of rows into one bucket key; the band index is
+// mixed in so equal rows in different bands never collide.
+func (s minHashSignature) bandKeys() []int64 {
+	keys := make([]int64, minHashBands)
+	for b := range keys {
+		key := mix64(uint64(b) + 1)
+		for _, v := range s[b*minHashRows : (b+1)*minHashRows] {
+			key = mix64(key ^ uint64(v))
+		}
+		keys[b] = int64(key)
+	}
+	return keys
+}
+
+func (s minHashSignature) encode() []byte {
+	buf := make([]byte, 4*minHashSize)
+	for i, v := range s {
+		binary.LittleEndian.PutUint32(buf[4*i:], v)
+	}
+	return buf
+}
+
+func decodeMinHash(blob []byte) (minHashSignature, error) {
+	var sig minHashSignature
+	if len(blob) != 4*minHashSize {
+		return sig, fmt.Errorf("minhash signature has %d bytes, want %d", len(blob), 4*minHashSize)
+	}
+	for i := range sig {
+		sig[i] = binary.LittleEndian.Uint32(blob[4*i:])
+	}
+	return sig, nil
+}
+
+func minHashSeed(p int) uint64 {
+	return mix64(uint64(p)*0x9e3779b97f4a7c15 + 0x632be59bd9b4e019)
+}
+
+// mix64 is the splitmix64 finalizer, used to derive independent permutations
+// from one FNV hash per shingle.
+func mix64(v uint64) uint64 {
+	v ^= v >> 30
+	v *= 0xbf58476d1ce4e5b9
+	v ^= v >> 27
+	v *= 0x94d049bb133111eb
+	v ^= v >> 31
+	return v
+}
+
+// writeMinHash stores or clears the signature and band buckets of one item.
+func writeMinHash(ctx context.Context, ex execer, itemID, title, body string) error {
+	if err := deleteMinHash(ctx, ex, itemID); err != nil {
+		return err
+	}
+	sig, ok := computeMinHash(title, body)
+	if !ok {
+		return nil
+	}
+
+	if _, err := ex.ExecContext(ctx, `INSERT INTO item_minhash(item_id, signature) VALUES(?, ?);`, itemID, sig.encode()); err != nil {
+		return fmt.Errorf("insert minhash %s: %w", itemID, err)
+	}
+	for _, key := range sig.bandKeys() {
+		if _, err := ex.ExecContext(ctx, `INSERT INTO item_minhash_bands(band_key, item_id) VALUES(?, ?);`, key, itemID); err != nil {
+			return fmt.Errorf("insert minhash band %s: %w", itemID, err)
+		}
+	}
+	return nil
+}
+
+func deleteMinHash(ctx context.Context, ex execer, itemID string) error {
+	if _, err := ex.ExecContext(ctx, `DELETE FROM item_minhash_bands WHERE item_id = ?;`, itemID); err != nil {
+		return fmt.Errorf("delete minhash bands %s: %w", itemID, err)
+	}
+	if _, err := ex.ExecContext(ctx, `DELETE FROM item_minhash WHERE item_id = ?;`, itemID); err != nil {
+		return fmt.Errorf("delete minhash %s: %w", itemID, err)
+	}
+	return nil
+}
+
+// SearchNearDuplicates finds items whose title and body overlap the given
+// text by at least half of their word shingles, best first. Candidates come
+// from indexed band buckets, so the lookup never scans every signature. Only
+// items filter matches are returned.
+func (s *Store) SearchNearDuplicates(ctx context.Context, title, body, excludeID string, limit int, filter SearchFilter) ([]NearDuplicateResult, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store is not initialized")
+	}
+	sig, ok := computeMinHash(title, body)
+	if !ok || limit <= 0 {
+		return []NearDuplicateResult{}, nil
+	}
+
+	keys := sig.bandKeys()
+	args := make([]any, 0, len(keys)+1)
+	for _, key := range keys {
+		args = append(args, key)
+	}
+	args = append(args, excludeID)
+	filterSQL, filterArgs := filter.normalized().itemClause("i")
+	args = append(args, filterArgs...)
+	query := `
+SELECT i.id, i.type, i.number, i.title, i.state, i.url, i.created_at, i.updated_at, m.signature
+FROM item_minhash m
+JOIN items i ON i.id = m.item_id
+WHERE m.item_id IN (
+    SELECT item_id FROM item_minhash_bands WHERE band_key IN (?` + strings.Repeat(", ?", len(keys)-1) + `)
+)
+  AND m.item_id != ?` + filterSQL + `;
+`
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("near-duplicate query: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]NearDuplicateResult, 0)
+	for rows.Next() {
+		var out NearDuplicateResult
+		var blob []byte
+		var createdAt, updatedAt string
+		if err := rows.Scan(&out.ID, &out.Type, &out.Number, &out.Title, &out.State, &out.URL, &createdAt, &updatedAt, &blob); err != nil {
+			return nil, fmt.Errorf("scan near-duplicate row: %w", err)
+		}
+		out.CreatedAt = parseItemTime(createdAt)
+		out.UpdatedAt = parseItemTime(updatedAt)
+		other, err := decodeMinHash(blob)
+		if err != nil {
+			return nil, fmt.Errorf("decode minhash %s: %w", out.ID, err)
+		}
+		out.Jaccard = sig.jaccard(other)
+		if out.Jaccard < nearDuplicateMinJaccard {
+			continue
+		}
+		results = append(results, out)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate near-duplicate rows: %w", err)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Jaccard == results[j].Jaccard {
+			return results[i].ID < results[j].ID
+		}
+		return results[i].Jaccard > results[j].Jaccard
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// backfillMinHashes signs items indexed before signatures existed.
+func backfillMinHashes(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, `SELECT id, title, body FROM items;`)
+	if err != nil {
+		return fmt.Errorf("list items: %w", err)
+	}
+	type itemText struct{ id, title, body string }
+	var items []itemText
+	for rows.Next() {
+		var it itemText
+		if err := rows.Scan(&it.id, &it.title, &it.body); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan item: %w", err)
+		}
+		items = append(items, it)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("iterate items: %w", err)
+	}
+	rows.Close()
+
+	for _, it := range items {
+		if err := writeMinHash(ctx, tx, it.id, it.title, it.body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
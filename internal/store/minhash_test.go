@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSearchNearDuplicates_FindsEditedCopies(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	s, err := OpenWithEmbedding(ctx, filepath.Join(t.TempDir(), "minhash.db"), EmbeddingSpace{Model: "m", Dimensions: 3})
+	if err != nil {
+		t.Fatalf("OpenWithEmbedding() error = %v", err)
+	}
+	defer func() {
+		if cerr := s.Close(); cerr != nil {
+			t.Fatalf("Close() error = %v", cerr)
+		}
+	}()
+
+	body := "Running backfill on a repository with five thousand issues crashes after ten minutes " +
+		"with a database locked error. Expected the backfill to finish and push the index branch. " +
+		"Version 1.2.0 on the ubuntu latest runner."
+	items := []ItemRecord{
+		{ID: "issue/1", Type: "issue", Number: 1, Title: "Backfill crashes with database locked", Body: body},
+		{ID: "issue/2", Type: "issue", Number: 2, Title: "Search returns results from another repository", Body: "The index branch is shared between two repositories in the same organization and search mixes them up."},
+		{ID: "issue/3", Type: "issue", Number: 3, Title: "Crash", Body: "locked"},
+	}
+	for _, item := range items {
+		if err := s.UpsertItem(ctx, item); err != nil {
+			t.Fatalf("UpsertItem() error = %v", err)
+		}
+	}
+
+	edited := strings.Replace(body, "1.2.0", "1.3.0", 1)
+	hits, err := s.SearchNearDuplicates(ctx, "Backfill crashes with database locked", edited, "issue/9", 5, SearchFilter{})
+	if err != nil {
+		t.Fatalf("SearchNearDuplicates() error = %v", err)
+	}
+	if len(hits) != 1 || hits[0].ID != "issue/1" || hits[0].Jaccard < NearExactJaccard {
+		t.Fatalf("SearchNearDuplicates() = %+v, want only issue/1 above %.2f", hits, NearExactJaccard)
+	}
+
+	if hits, err := s.SearchNearDuplicates(ctx, "Crash", "locked", "", 5, SearchFilter{}); err != nil || len(hits) != 0 {
+		t.Fatalf("short text should have no signature: %+v, %v", hits, err)
+	}
+
+	if err := s.DeleteItem(ctx, "issue/1"); err != nil {
+		t.Fatalf("DeleteItem() error = %v", err)
+	}
+	hits, err = s.SearchNearDuplicates(ctx, "Backfill crashes with database locked", edited, "", 5, SearchFilter{})
+	if err != nil || len(hits) != 0 {
+		t.Fatalf("deleted item still matched: %+v, %v", hits, err)
+	}
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PendingEmbedding is an indexed item whose vector could not be computed yet.
+type PendingEmbedding struct {
+	ID       string
+	Reason   string
+	QueuedAt time.Time
+}
+
+// MarkPendingEmbedding records that id was indexed without a vector.
+// UpsertVector clears the mark once a later run fills the vector in.
+func (s *Store) MarkPendingEmbedding(ctx context.Context, id, reason string) error {
+	if s == nil || s.db == nil {
+		return errors.New("store is not initialized")
+	}
+	if strings.TrimSpace(id) == "" {
+		return errors.New("item id is required")
+	}
+
+	const stmt = `
+INSERT INTO pending_embeddings(id, reason, queued_at) VALUES(?, ?, ?)
+ON CONFLICT(id) DO UPDATE SET
+    reason=excluded.reason;
+`
+	queuedAt := time.Now().UTC().Format(time.RFC3339Nano)
+	if _, err := s.db.ExecContext(ctx, stmt, id, reason, queuedAt); err != nil {
+		return fmt.Errorf("mark pending embedding: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) ClearPendingEmbedding(ctx context.Context, id string) error {
+	if s == nil || s.db == nil {
+		return errors.New("store is not initialized")
+	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM pending_embeddings WHERE id = ?;`, id); err != nil {
+		return fmt.Errorf("clear pending embedding: %w", err)
+	}
+	return nil
+}
+
+// ListPendingEmbeddings returns the oldest pending items first.
+func (s *Store) ListPendingEmbeddings(ctx context.Context, limit int) ([]PendingEmbedding, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store is not initialized")
+	}
+	if limit <= 0 {
+		return []PendingEmbedding{}, nil
+	}
+
+	const query = `
+SELECT id, reason, queued_at
+FROM pending_embeddings
+ORDER BY queued_at ASC, id ASC
+LIMIT ?;
+`
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list pending embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]PendingEmbedding, 0)
+	for rows.Next() {
+		var (
+			item     PendingEmbedding
+			queuedAt string
+		)
+		if err := rows.Scan(&item.ID, &item.Reason, &queuedAt); err != nil {
+			return nil, fmt.Errorf("scan pending embedding row: %w", err)
+		}
+		item.QueuedAt, _ = time.Parse(time.RFC3339Nano, queuedAt)
+		out = append(out, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate pending embedding rows: %w", err)
+	}
+
+	return out, nil
+}
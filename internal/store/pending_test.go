@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestPendingEmbeddings_MarkListAndClearOnVectorUpsert(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	s, err := Open(ctx, filepath.Join(t.TempDir(), "pending.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() {
+		if cerr := s.Close(); cerr != nil {
+			t.Fatalf("Close() error = %v", cerr)
+		}
+	}()
+
+	if err := insertItemFixture(ctx, s, "issue/1", "issue", 1, "one"); err != nil {
+		t.Fatalf("insertItemFixture() error = %v", err)
+	}
+	if err := s.MarkPendingEmbedding(ctx, "issue/1", "embed failed"); err != nil {
+		t.Fatalf("MarkPendingEmbedding() error = %v", err)
+	}
+	if err := s.MarkPendingEmbedding(ctx, "issue/1", "embed failed again"); err != nil {
+		t.Fatalf("second MarkPendingEmbedding() error = %v", err)
+	}
+
+	pending, err := s.ListPendingEmbeddings(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListPendingEmbeddings() error = %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != "issue/1" || pending[0].Reason != "embed failed again" {
+		t.Fatalf("unexpected pending list: %+v", pending)
+	}
+
+	if err := s.UpsertVector(ctx, "issue/1", makeVec1536(1, 0)); err != nil {
+		t.Fatalf("UpsertVector() error = %v", err)
+	}
+	pending, err = s.ListPendingEmbeddings(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListPendingEmbeddings() after vector error = %v", err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("expected pending mark cleared by UpsertVector, got %+v", pending)
+	}
+}
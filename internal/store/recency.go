@@ -0,0 +1,48 @@
+package store
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// Recency reweights fused candidates by how recently they were active, so a
+// fresh open report of a regression can outrank an old closed one. It only
+// changes the ranking; similarity and duplicate flags are left as they are.
+// The zero value is off.
+type Recency struct {
+	// HalfLife is the age at which a candidate's rank weight halves. Age is
+	// measured from the later of created_at and updated_at; zero disables decay.
+	HalfLife time.Duration
+	// OpenBoost raises the rank of open candidates by this fraction, e.g. 0.2
+	// for +20%; zero disables it.
+	OpenBoost float64
+	// Now is the reference time for ages; zero means time.Now.
+	Now time.Time
+}
+
+// weights returns the decay and open-boost multipliers for one candidate.
+// Candidates without timestamps are not decayed.
+func (r Recency) weights(state string, createdAt, updatedAt time.Time) (decay, boost float64) {
+	decay, boost = 1, 1
+	if r.OpenBoost > 0 && strings.EqualFold(state, "open") {
+		boost = 1 + r.OpenBoost
+	}
+
+	active := createdAt
+	if updatedAt.After(active) {
+		active = updatedAt
+	}
+	if r.HalfLife <= 0 || active.IsZero() {
+		return decay, boost
+	}
+	now := r.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	age := now.Sub(active)
+	if age <= 0 {
+		return decay, boost
+	}
+	return math.Exp2(-float64(age) / float64(r.HalfLife)), boost
+}
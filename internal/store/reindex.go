@@ -0,0 +1,262 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	sqlite_vec "github.com/asg017/sqlite-vec-go-bindings/cgo"
+)
+
+const (
+	// ReindexModelKey and ReindexDimensionsKey record the target space of a
+	// reindex in progress, so a resumed run only reuses vectors staged for it.
+	ReindexModelKey      = "reindex.model"
+	ReindexDimensionsKey = "reindex.dimensions"
+)
+
+// BeginReindex prepares the staging table for a rebuild into target. Vectors
+// already staged for the same target are kept so an interrupted reindex
+// resumes; vectors staged for a different target are discarded.
+func (s *Store) BeginReindex(ctx context.Context, target EmbeddingSpace) (resumed int, err error) {
+	if s == nil || s.db == nil {
+		return 0, errors.New("store is not initialized")
+	}
+	if strings.TrimSpace(target.Model) == "" || target.Dimensions <= 0 {
+		return 0, errors.New("embedding model and dimensions are required")
+	}
+
+	model, foundModel, err := s.GetMeta(ctx, ReindexModelKey)
+	if err != nil {
+		return 0, err
+	}
+	dims, foundDims, err := s.GetMeta(ctx, ReindexDimensionsKey)
+	if err != nil {
+		return 0, err
+	}
+
+	sameTarget := foundModel && foundDims && model == target.Model && dims == strconv.Itoa(target.Dimensions)
+	if !sameTarget {
+		for _, stmt := range []string{`DELETE FROM reindex_vectors;`, `DELETE FROM reindex_chunks;`} {
+			if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+				return 0, fmt.Errorf("clear reindex staging: %w", err)
+			}
+		}
+		if err := s.SetMeta(ctx, ReindexModelKey, target.Model); err != nil {
+			return 0, err
+		}
+		if err := s.SetMeta(ctx, ReindexDimensionsKey, strconv.Itoa(target.Dimensions)); err != nil {
+			return 0, err
+		}
+		return 0, nil
+	}
+
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM reindex_vectors;`).Scan(&resumed); err != nil {
+		return 0, fmt.Errorf("count staged vectors: %w", err)
+	}
+	return resumed, nil
+}
+
+// ListUnstagedItems returns up to limit items after afterID, ordered by id,
+// that have no staged vector for their current content. Paging on the last
+// returned id streams the whole items table.
+func (s *Store) ListUnstagedItems(ctx context.Context, afterID string, limit int) ([]ItemRecord, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store is not initialized")
+	}
+	if limit <= 0 {
+		return []ItemRecord{}, nil
+	}
+
+	query := `SELECT ` + itemColumns + `
+FROM items
+WHERE id > ? AND NOT EXISTS (
+    SELECT 1 FROM reindex_vectors r
+    WHERE r.id = items.id AND r.content_hash = items.content_hash
+)
+ORDER BY id ASC
+LIMIT ?;`
+	rows, err := s.db.QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list unstaged items: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]ItemRecord, 0, limit)
+	for rows.Next() {
+		rec, err := scanItemRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan item row: %w", err)
+		}
+		out = append(out, rec)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate item rows: %w", err)
+	}
+
+	return out, nil
+}
+
+// StageReindexVector stores one rebuilt vector outside the live index, tagged
+// with the content hash it was built from so later edits invalidate it.
+func (s *Store) StageReindexVector(ctx context.Context, id, contentHash string, embedding []float32) error {
+	if s == nil || s.db == nil {
+		return errors.New("store is not initialized")
+	}
+	if len(embedding) == 0 {
+		return errors.New("embedding is required")
+	}
+
+	serialized, err := sqlite_vec.SerializeFloat32(embedding)
+	if err != nil {
+		return fmt.Errorf("serialize embedding: %w", err)
+	}
+
+	const stmt = `
+INSERT INTO reindex_vectors(id, embedding, content_hash, staged_at) VALUES(?, ?, ?, ?)
+ON CONFLICT(id) DO UPDATE SET
+    embedding=excluded.embedding,
+    content_hash=excluded.content_hash,
+    staged_at=excluded.staged_at;
+`
+	if _, err := s.db.ExecContext(ctx, stmt, id, serialized, contentHash, time.Now().UTC().Format(time.RFC3339Nano)); err != nil {
+		return fmt.Errorf("stage vector %s: %w", id, err)
+	}
+	return nil
+}
+
+// StageReindexChunks stores the rebuilt chunk vectors of one item, replacing
+// any staged earlier. Stage chunks before the item vector: an item counts as
+// staged once its item vector is, so a resumed run redoes partial items.
+func (s *Store) StageReindexChunks(ctx context.Context, id, contentHash string, chunks [][]float32) (err error) {
+	if s == nil || s.db == nil {
+		return errors.New("store is not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin stage chunks: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM reindex_chunks WHERE item_id = ?;`, id); err != nil {
+		return fmt.Errorf("clear staged chunks %s: %w", id, err)
+	}
+	for i, chunk := range chunks {
+		var serialized []byte
+		if serialized, err = sqlite_vec.SerializeFloat32(chunk); err != nil {
+			return fmt.Errorf("serialize chunk embedding: %w", err)
+		}
+		const stmt = `INSERT INTO reindex_chunks(chunk_id, item_id, chunk_index, embedding, content_hash) VALUES(?, ?, ?, ?, ?);`
+		if _, err = tx.ExecContext(ctx, stmt, chunkID(id, i), id, i, serialized, contentHash); err != nil {
+			return fmt.Errorf("stage chunk %s: %w", chunkID(id, i), err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit stage chunks: %w", err)
+	}
+	return nil
+}
+
+// CommitReindex swaps the staged vectors in as the live index in a single
+// transaction: the old items_vec and items_chunks_vec are dropped, recreated
+// for target, filled from staging, and the recorded embedding space is
+// updated. Either the whole swap lands or none of it does, so the index never
+// mixes models.
+func (s *Store) CommitReindex(ctx context.Context, target EmbeddingSpace) (swapped int, err error) {
+	if s == nil || s.db == nil {
+		return 0, errors.New("store is not initialized")
+	}
+	if strings.TrimSpace(target.Model) == "" || target.Dimensions <= 0 {
+		return 0, errors.New("embedding model and dimensions are required")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin reindex swap: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for _, stmt := range []string{`DROP TABLE IF EXISTS items_vec;`, `DROP TABLE IF EXISTS items_chunks_vec;`, `DELETE FROM item_chunks;`} {
+		if _, err = tx.ExecContext(ctx, stmt); err != nil {
+			return 0, fmt.Errorf("drop vector tables: %w", err)
+		}
+	}
+	if err = createVectorTables(ctx, tx, target.Dimensions); err != nil {
+		return 0, fmt.Errorf("create vector tables: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+INSERT INTO items_vec(id, embedding, `+vectorMetaColumns+`)
+SELECT r.id, r.embedding, i.type,`+vectorMetaSelect+`
+FROM reindex_vectors r
+JOIN items i ON i.id = r.id AND i.content_hash = r.content_hash;
+`)
+	if err != nil {
+		return 0, fmt.Errorf("copy staged vectors: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("count swapped vectors: %w", err)
+	}
+
+	stmts := []struct {
+		query string
+		args  []any
+	}{
+		{
+			query: `UPDATE items SET embedding_model = CASE WHEN id IN (SELECT id FROM items_vec) THEN ? ELSE '' END;`,
+			args:  []any{target.Model},
+		},
+		{query: `DELETE FROM pending_embeddings WHERE id IN (SELECT id FROM items_vec);`},
+		{
+			query: `
+INSERT INTO items_chunks_vec(chunk_id, embedding, ` + vectorMetaColumns + `)
+SELECT c.chunk_id, c.embedding, i.type,` + vectorMetaSelect + `
+FROM reindex_chunks c
+JOIN items i ON i.id = c.item_id AND i.content_hash = c.content_hash
+WHERE c.item_id IN (SELECT id FROM items_vec);
+`,
+		},
+		{
+			query: `
+INSERT INTO item_chunks(chunk_id, item_id, chunk_index)
+SELECT chunk_id, item_id, chunk_index FROM reindex_chunks
+WHERE chunk_id IN (SELECT chunk_id FROM items_chunks_vec);
+`,
+		},
+		{query: `DELETE FROM reindex_vectors;`},
+		{query: `DELETE FROM reindex_chunks;`},
+	}
+	for _, stmt := range stmts {
+		if _, err = tx.ExecContext(ctx, stmt.query, stmt.args...); err != nil {
+			return 0, fmt.Errorf("finish reindex swap: %w", err)
+		}
+	}
+	// Scores from the new vectors need a fresh calibration.
+	if err = deleteMetaKeys(ctx, tx, time.Now().UTC(), ReindexModelKey, ReindexDimensionsKey, CalibrationKey); err != nil {
+		return 0, err
+	}
+	if err = s.writeEmbeddingSpace(ctx, tx, target); err != nil {
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit reindex swap: %w", err)
+	}
+
+	s.space = target
+	return int(affected), nil
+}
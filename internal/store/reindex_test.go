@@ -0,0 +1,113 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestReindex_StagesThenSwapsAtomically(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+	oldSpace := EmbeddingSpace{Model: "old-model", Dimensions: 1536}
+	s, err := OpenWithEmbedding(ctx, filepath.Join(t.TempDir(), "reindex.db"), oldSpace)
+	if err != nil {
+		t.Fatalf("OpenWithEmbedding() error = %v", err)
+	}
+	defer func() {
+		if cerr := s.Close(); cerr != nil {
+			t.Fatalf("Close() error = %v", cerr)
+		}
+	}()
+
+	for i, id := range []string{"issue/1", "issue/2"} {
+		if err := s.UpsertItem(ctx, ItemRecord{ID: id, Type: "issue", Number: i + 1, Title: "login timeout", Body: "hangs", ContentHash: "h" + id, EmbeddingModel: "old-model"}); err != nil {
+			t.Fatalf("UpsertItem() error = %v", err)
+		}
+		if err := s.UpsertVector(ctx, id, makeVec1536(1)); err != nil {
+			t.Fatalf("UpsertVector() error = %v", err)
+		}
+	}
+
+	target := EmbeddingSpace{Model: "new-model", Dimensions: 3}
+	if resumed, err := s.BeginReindex(ctx, target); err != nil || resumed != 0 {
+		t.Fatalf("BeginReindex() = %d, %v", resumed, err)
+	}
+	if err := s.StageReindexVector(ctx, "issue/1", "hissue/1", []float32{1, 0, 0}); err != nil {
+		t.Fatalf("StageReindexVector() error = %v", err)
+	}
+
+	// Mid-reindex the live index still answers with the old model only.
+	if hits, err := s.SearchVector(ctx, makeVec1536(1), "", 5, SearchFilter{}); err != nil || len(hits) != 2 {
+		t.Fatalf("SearchVector() during reindex = %d hits, %v", len(hits), err)
+	}
+
+	// A resumed run keeps matching staged vectors and re-lists the rest.
+	if resumed, err := s.BeginReindex(ctx, target); err != nil || resumed != 1 {
+		t.Fatalf("BeginReindex() resume = %d, %v", resumed, err)
+	}
+	unstaged, err := s.ListUnstagedItems(ctx, "", 10)
+	if err != nil {
+		t.Fatalf("ListUnstagedItems() error = %v", err)
+	}
+	if len(unstaged) != 1 || unstaged[0].ID != "issue/2" {
+		t.Fatalf("unstaged = %+v, want only issue/2", unstaged)
+	}
+
+	// Editing a staged item invalidates its staged vector.
+	if err := s.UpsertItem(ctx, ItemRecord{ID: "issue/1", Type: "issue", Number: 1, Title: "edited", ContentHash: "changed"}); err != nil {
+		t.Fatalf("UpsertItem() error = %v", err)
+	}
+	if unstaged, _ = s.ListUnstagedItems(ctx, "", 10); len(unstaged) != 2 {
+		t.Fatalf("unstaged after edit = %d, want 2", len(unstaged))
+	}
+	if err := s.StageReindexVector(ctx, "issue/1", "changed", []float32{1, 0, 0}); err != nil {
+		t.Fatalf("StageReindexVector() error = %v", err)
+	}
+	if err := s.StageReindexChunks(ctx, "issue/2", "hissue/2", [][]float32{{0, 0, 1}}); err != nil {
+		t.Fatalf("StageReindexChunks() error = %v", err)
+	}
+	if err := s.StageReindexVector(ctx, "issue/2", "hissue/2", []float32{0, 1, 0}); err != nil {
+		t.Fatalf("StageReindexVector() error = %v", err)
+	}
+
+	if err := s.SetMeta(ctx, CalibrationKey, `{"method":"platt"}`); err != nil {
+		t.Fatalf("SetMeta() error = %v", err)
+	}
+
+	swapped, err := s.CommitReindex(ctx, target)
+	if err != nil {
+		t.Fatalf("CommitReindex() error = %v", err)
+	}
+	if swapped != 2 {
+		t.Fatalf("swapped = %d, want 2", swapped)
+	}
+
+	hits, err := s.SearchVector(ctx, []float32{0, 1, 0}, "", 5, SearchFilter{})
+	if err != nil {
+		t.Fatalf("SearchVector() after swap error = %v", err)
+	}
+	if len(hits) != 2 || hits[0].ID != "issue/2" {
+		t.Fatalf("unexpected hits after swap: %+v", hits)
+	}
+	if chunks, err := s.LookupChunkVectors(ctx, "issue/2"); err != nil || len(chunks) != 1 {
+		t.Fatalf("LookupChunkVectors() after swap = %v, %v; want the staged chunk", chunks, err)
+	}
+	if hits, err := s.SearchVector(ctx, []float32{0, 0, 1}, "", 1, SearchFilter{}); err != nil || len(hits) != 1 || hits[0].ID != "issue/2" {
+		t.Fatalf("SearchVector(chunk) after swap = %+v, %v", hits, err)
+	}
+	if err := s.EnsureEmbeddingSpace(ctx, target); err != nil {
+		t.Fatalf("EnsureEmbeddingSpace(target) after swap error = %v", err)
+	}
+	rec, err := s.GetItem(ctx, "issue/2")
+	if err != nil || rec.EmbeddingModel != "new-model" {
+		t.Fatalf("GetItem() = %+v, %v; want embedding_model new-model", rec, err)
+	}
+	if _, found, _ := s.GetMeta(ctx, ReindexModelKey); found {
+		t.Fatalf("reindex target should be cleared after commit")
+	}
+	if _, found, _ := s.GetMeta(ctx, CalibrationKey); found {
+		t.Fatalf("calibration fitted on the old vectors should be cleared after commit")
+	}
+}
@@ -1,6 +1,9 @@
 package store
 
-import "sort"
+import (
+	"sort"
+	"time"
+)
 
 const (
 	defaultSimilarityThreshold = 0.75
@@ -14,9 +17,19 @@ type FuseConfig struct {
 	SimilarityThreshold float64
 	DuplicateThreshold  float64
 	MaxResults          int
+	// Overrides holds maintainer verdicts keyed by candidate item id.
+	Overrides map[string]PairVerdict
+	// Strategy orders candidates and picks their displayed similarity;
+	// nil means equally weighted RRF with k = 60 and max(vec, fts, near).
+	Strategy FusionStrategy
+	// Calibration, when set, turns scores into a duplicate probability and
+	// DuplicateThreshold applies to that probability instead of the similarity.
+	Calibration *Calibration
+	// Recency decays the rank of stale candidates and boosts open ones.
+	Recency Recency
 }
 
-// FusedResult is the merged ranking output from vector and FTS backends.
+// FusedResult is the merged ranking output from the vector, FTS and MinHash backends.
 type FusedResult struct {
 	ID                string
 	Type              string
@@ -24,23 +37,46 @@ type FusedResult struct {
 	Title             string
 	State             string
 	URL               string
-	RRFScore          float64
+	FusionScore       float64
 	VecScore          float64
 	FTSScore          float64
+	NearScore         float64
 	DisplaySimilarity float64
-	IsDuplicate       bool
+	// DuplicateProbability is the calibrated chance this is a duplicate; it is
+	// only meaningful when Calibrated is set.
+	DuplicateProbability float64
+	Calibrated           bool
+	// RerankScore is the reranker's duplicate score; it is only meaningful
+	// when Reranked is set.
+	RerankScore float64
+	Reranked    bool
+	IsDuplicate bool
+	// NearExact is set when the texts are near-exact copies by MinHash overlap.
+	NearExact bool
+	// KeywordOnly is set when the result came from FTS alone because embedding failed.
+	KeywordOnly bool
+	// Confirmed is set when a maintainer recorded this pair as a duplicate.
+	Confirmed bool
+	// RecencyDecay and OpenBoost are the multipliers Recency applied to
+	// FusionScore; both are 1 when it is off.
+	RecencyDecay float64
+	OpenBoost    float64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
 }
 
 type fusedAccumulator struct {
-	ID       string
-	Type     string
-	Number   int
-	Title    string
-	State    string
-	URL      string
-	RRFScore float64
-	VecScore float64
-	FTSScore float64
+	ID        string
+	Type      string
+	Number    int
+	Title     string
+	State     string
+	URL       string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	VecScore  float64
+	FTSScore  float64
+	NearScore float64
 }
 
 func (c FuseConfig) normalized() FuseConfig {
@@ -48,13 +84,24 @@ func (c FuseConfig) normalized() FuseConfig {
 		SimilarityThreshold: c.SimilarityThreshold,
 		DuplicateThreshold:  c.DuplicateThreshold,
 		MaxResults:          c.MaxResults,
+		Overrides:           c.Overrides,
+		Strategy:            c.Strategy,
+		Calibration:         c.Calibration,
+		Recency:             c.Recency,
+	}
+	if out.Strategy == nil {
+		out.Strategy = WeightedRRF{K: rrfK}
 	}
 
-	if c == (FuseConfig{}) {
+	if c.SimilarityThreshold == 0 && c.DuplicateThreshold == 0 && c.MaxResults == 0 {
 		return FuseConfig{
 			SimilarityThreshold: defaultSimilarityThreshold,
 			DuplicateThreshold:  defaultDuplicateThreshold,
 			MaxResults:          defaultMaxResults,
+			Overrides:           c.Overrides,
+			Strategy:            out.Strategy,
+			Calibration:         c.Calibration,
+			Recency:             c.Recency,
 		}
 	}
 
@@ -67,73 +114,111 @@ func (c FuseConfig) normalized() FuseConfig {
 	return out
 }
 
-// FuseResults applies RRF ordering while using max(vecScore, ftsScore) as user-facing similarity.
-func FuseResults(vecResults []VectorResult, ftsResults []FTSResult, excludeID string, config FuseConfig) []FusedResult {
+// FuseResults orders candidates and picks their similarity with cfg.Strategy (RRF with max similarity
+// by default). Near-exact textual copies are flagged as duplicates whatever the thresholds.
+// Candidates a maintainer marked not-duplicate are dropped; confirmed duplicates are flagged and pinned first.
+// cfg.Recency, when set, scales each FusionScore by its decay and open boost before sorting.
+func FuseResults(vecResults []VectorResult, ftsResults []FTSResult, nearResults []NearDuplicateResult, excludeID string, config FuseConfig) []FusedResult {
 	cfg := config.normalized()
 	acc := map[string]*fusedAccumulator{}
+	lists := map[FusionSource][]RankedHit{}
 
-	vecSeen := map[string]struct{}{}
-	for rank, item := range vecResults {
-		if item.ID == "" || item.ID == excludeID {
-			continue
+	// add records one list entry, skipping the queried item and repeats.
+	add := func(source FusionSource, seen map[string]struct{}, meta itemMeta, score float64) *fusedAccumulator {
+		if meta.ID == "" || meta.ID == excludeID {
+			return nil
 		}
-		if _, exists := vecSeen[item.ID]; exists {
-			continue
+		if _, exists := seen[meta.ID]; exists {
+			return nil
 		}
-		vecSeen[item.ID] = struct{}{}
+		seen[meta.ID] = struct{}{}
+		lists[source] = append(lists[source], RankedHit{ID: meta.ID, Score: score})
 
-		current := getOrCreateAccumulator(acc, item.ID)
-		mergeMetadata(current, item.Type, item.Number, item.Title, item.State, item.URL)
-		current.VecScore = maxFloat(current.VecScore, clamp01(item.VecScore))
-		current.RRFScore += 1.0 / float64(rrfK+rank+1)
+		current := getOrCreateAccumulator(acc, meta.ID)
+		mergeMetadata(current, meta)
+		return current
 	}
 
+	vecSeen := map[string]struct{}{}
+	for _, item := range vecResults {
+		meta := itemMeta{ID: item.ID, Type: item.Type, Number: item.Number, Title: item.Title, State: item.State, URL: item.URL, CreatedAt: item.CreatedAt, UpdatedAt: item.UpdatedAt}
+		if current := add(FusionSourceVector, vecSeen, meta, item.VecScore); current != nil {
+			current.VecScore = maxFloat(current.VecScore, clamp01(item.VecScore))
+		}
+	}
 	ftsSeen := map[string]struct{}{}
-	for rank, item := range ftsResults {
-		if item.ID == "" || item.ID == excludeID {
-			continue
+	for _, item := range ftsResults {
+		meta := itemMeta{ID: item.ID, Type: item.Type, Number: item.Number, Title: item.Title, State: item.State, URL: item.URL, CreatedAt: item.CreatedAt, UpdatedAt: item.UpdatedAt}
+		if current := add(FusionSourceFTS, ftsSeen, meta, item.FTSScore); current != nil {
+			current.FTSScore = maxFloat(current.FTSScore, clamp01(item.FTSScore))
 		}
-		if _, exists := ftsSeen[item.ID]; exists {
-			continue
+	}
+	nearSeen := map[string]struct{}{}
+	for _, item := range nearResults {
+		meta := itemMeta{ID: item.ID, Type: item.Type, Number: item.Number, Title: item.Title, State: item.State, URL: item.URL, CreatedAt: item.CreatedAt, UpdatedAt: item.UpdatedAt}
+		if current := add(FusionSourceNear, nearSeen, meta, item.Jaccard); current != nil {
+			current.NearScore = maxFloat(current.NearScore, clamp01(item.Jaccard))
 		}
-		ftsSeen[item.ID] = struct{}{}
-
-		current := getOrCreateAccumulator(acc, item.ID)
-		mergeMetadata(current, item.Type, item.Number, item.Title, item.State, item.URL)
-		current.FTSScore = maxFloat(current.FTSScore, clamp01(item.FTSScore))
-		current.RRFScore += 1.0 / float64(rrfK+rank+1)
 	}
 
+	scores := cfg.Strategy.Fuse(lists)
 	fused := make([]FusedResult, 0, len(acc))
 	for _, item := range acc {
-		displaySimilarity := maxFloat(item.VecScore, item.FTSScore)
-		if displaySimilarity < cfg.SimilarityThreshold {
+		verdict := cfg.Overrides[item.ID]
+		if verdict == PairVerdictNotDuplicate {
+			continue
+		}
+
+		score := scores[item.ID]
+		displaySimilarity := clamp01(score.Similarity)
+		confirmed := verdict == PairVerdictDuplicate
+		nearExact := item.NearScore >= NearExactJaccard
+		// Written as !(>=) so a NaN similarity is filtered out rather than kept.
+		if !(displaySimilarity >= cfg.SimilarityThreshold) && !confirmed && !nearExact {
 			continue
 		}
+		decay, boost := cfg.Recency.weights(item.State, item.CreatedAt, item.UpdatedAt)
+		duplicateScore, probability := displaySimilarity, 0.0
+		if cfg.Calibration != nil {
+			probability = cfg.Calibration.Probability(item.VecScore, item.FTSScore, item.NearScore, displaySimilarity)
+			duplicateScore = probability
+		}
 
 		fused = append(fused, FusedResult{
-			ID:                item.ID,
-			Type:              item.Type,
-			Number:            item.Number,
-			Title:             item.Title,
-			State:             item.State,
-			URL:               item.URL,
-			RRFScore:          item.RRFScore,
-			VecScore:          item.VecScore,
-			FTSScore:          item.FTSScore,
-			DisplaySimilarity: displaySimilarity,
-			IsDuplicate:       displaySimilarity >= cfg.DuplicateThreshold,
+			ID:                   item.ID,
+			Type:                 item.Type,
+			Number:               item.Number,
+			Title:                item.Title,
+			State:                item.State,
+			URL:                  item.URL,
+			FusionScore:          score.Rank * decay * boost,
+			VecScore:             item.VecScore,
+			FTSScore:             item.FTSScore,
+			NearScore:            item.NearScore,
+			DisplaySimilarity:    displaySimilarity,
+			DuplicateProbability: probability,
+			Calibrated:           cfg.Calibration != nil,
+			IsDuplicate:          confirmed || nearExact || duplicateScore >= cfg.DuplicateThreshold,
+			NearExact:            nearExact,
+			Confirmed:            confirmed,
+			RecencyDecay:         decay,
+			OpenBoost:            boost,
+			CreatedAt:            item.CreatedAt,
+			UpdatedAt:            item.UpdatedAt,
 		})
 	}
 
 	sort.Slice(fused, func(i, j int) bool {
-		if fused[i].RRFScore == fused[j].RRFScore {
+		if fused[i].Confirmed != fused[j].Confirmed {
+			return fused[i].Confirmed
+		}
+		if fused[i].FusionScore == fused[j].FusionScore {
 			if fused[i].DisplaySimilarity == fused[j].DisplaySimilarity {
 				return fused[i].ID < fused[j].ID
 			}
 			return fused[i].DisplaySimilarity > fused[j].DisplaySimilarity
 		}
-		return fused[i].RRFScore > fused[j].RRFScore
+		return fused[i].FusionScore > fused[j].FusionScore
 	})
 
 	if len(fused) > cfg.MaxResults {
@@ -154,21 +239,27 @@ func getOrCreateAccumulator(acc map[string]*fusedAccumulator, id string) *fusedA
 	return current
 }
 
-func mergeMetadata(target *fusedAccumulator, typ string, number int, title, state, url string) {
+func mergeMetadata(target *fusedAccumulator, meta itemMeta) {
 	if target.Type == "" {
-		target.Type = typ
+		target.Type = meta.Type
 	}
 	if target.Number == 0 {
-		target.Number = number
+		target.Number = meta.Number
 	}
 	if target.Title == "" {
-		target.Title = title
+		target.Title = meta.Title
 	}
 	if target.State == "" {
-		target.State = state
+		target.State = meta.State
 	}
 	if target.URL == "" {
-		target.URL = url
+		target.URL = meta.URL
+	}
+	if target.CreatedAt.IsZero() {
+		target.CreatedAt = meta.CreatedAt
+	}
+	if target.UpdatedAt.IsZero() {
+		target.UpdatedAt = meta.UpdatedAt
 	}
 }
 
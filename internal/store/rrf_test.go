@@ -1,6 +1,10 @@
 package store
 
-import "testing"
+import (
+	"math"
+	"testing"
+	"time"
+)
 
 func TestFuseResults_OrdersByRRFNotDisplaySimilarity(t *testing.T) {
 	t.Helper()
@@ -13,7 +17,7 @@ func TestFuseResults_OrdersByRRFNotDisplaySimilarity(t *testing.T) {
 		{ID: "issue/B", FTSScore: 0.70, Type: "issue", Number: 2, Title: "B"},
 	}
 
-	fused := FuseResults(vecResults, ftsResults, "", FuseConfig{
+	fused := FuseResults(vecResults, ftsResults, nil, "", FuseConfig{
 		SimilarityThreshold: 0.50,
 		DuplicateThreshold:  0.95,
 		MaxResults:          10,
@@ -42,7 +46,7 @@ func TestFuseResults_AppliesThresholdsAndMaxResults(t *testing.T) {
 		{ID: "issue/C", VecScore: 0.60, Type: "issue", Number: 3, Title: "C"},
 	}
 
-	fused := FuseResults(vecResults, nil, "", FuseConfig{
+	fused := FuseResults(vecResults, nil, nil, "", FuseConfig{
 		SimilarityThreshold: 0.75,
 		DuplicateThreshold:  0.92,
 		MaxResults:          2,
@@ -70,7 +74,7 @@ func TestFuseResults_DefensiveSelfExclusion(t *testing.T) {
 		{ID: "issue/self", FTSScore: 0.50, Type: "issue", Number: 10, Title: "self"},
 	}
 
-	fused := FuseResults(vecResults, ftsResults, "issue/self", FuseConfig{
+	fused := FuseResults(vecResults, ftsResults, nil, "issue/self", FuseConfig{
 		SimilarityThreshold: 0.75,
 		DuplicateThreshold:  0.90,
 		MaxResults:          5,
@@ -91,7 +95,7 @@ func TestFuseResults_UsesFTSOnlyScoreWhenVectorMissing(t *testing.T) {
 		{ID: "issue/fts", FTSScore: 0.81, Type: "issue", Number: 4, Title: "fts"},
 	}
 
-	fused := FuseResults(nil, ftsResults, "", FuseConfig{
+	fused := FuseResults(nil, ftsResults, nil, "", FuseConfig{
 		SimilarityThreshold: 0.75,
 		DuplicateThreshold:  0.92,
 		MaxResults:          5,
@@ -123,7 +127,7 @@ func TestFuseResults_ClampsScoresToUnitInterval(t *testing.T) {
 		{ID: "issue/low", FTSScore: -0.5, Type: "issue", Number: 2, Title: "low"},
 	}
 
-	fused := FuseResults(vecResults, ftsResults, "", FuseConfig{
+	fused := FuseResults(vecResults, ftsResults, nil, "", FuseConfig{
 		SimilarityThreshold: 0.1,
 		DuplicateThreshold:  0.9,
 		MaxResults:          10,
@@ -136,3 +140,111 @@ func TestFuseResults_ClampsScoresToUnitInterval(t *testing.T) {
 		t.Fatalf("DisplaySimilarity = %f, want 1", fused[0].DisplaySimilarity)
 	}
 }
+
+func TestFuseResults_DropsNaNScores(t *testing.T) {
+	t.Helper()
+
+	// vec0 returns a NaN distance for a zero vector.
+	vecResults := []VectorResult{
+		{ID: "issue/nan", VecScore: math.NaN(), Type: "issue", Number: 1, Title: "symbols only"},
+		{ID: "issue/ok", VecScore: 0.9, Type: "issue", Number: 2, Title: "real match"},
+	}
+
+	fused := FuseResults(vecResults, nil, nil, "", FuseConfig{
+		SimilarityThreshold: 0.5,
+		DuplicateThreshold:  0.95,
+		MaxResults:          10,
+	})
+
+	if len(fused) != 1 || fused[0].ID != "issue/ok" {
+		t.Fatalf("FuseResults() = %+v, want only the real match", fused)
+	}
+	if math.IsNaN(fused[0].FusionScore) || math.IsNaN(fused[0].DisplaySimilarity) {
+		t.Fatalf("NaN leaked into the fused scores: %+v", fused[0])
+	}
+}
+
+func TestFuseResults_RespectsPairOverrides(t *testing.T) {
+	t.Helper()
+
+	vecResults := []VectorResult{
+		{ID: "issue/A", VecScore: 0.97, Type: "issue", Number: 1, Title: "A"},
+		{ID: "issue/B", VecScore: 0.60, Type: "issue", Number: 2, Title: "B"},
+		{ID: "issue/C", VecScore: 0.90, Type: "issue", Number: 3, Title: "C"},
+	}
+
+	fused := FuseResults(vecResults, nil, nil, "", FuseConfig{
+		SimilarityThreshold: 0.75,
+		DuplicateThreshold:  0.92,
+		MaxResults:          5,
+		Overrides: map[string]PairVerdict{
+			"issue/A": PairVerdictNotDuplicate,
+			"issue/B": PairVerdictDuplicate,
+		},
+	})
+
+	if len(fused) != 2 {
+		t.Fatalf("FuseResults() len = %d, want 2: %+v", len(fused), fused)
+	}
+	if fused[0].ID != "issue/B" || !fused[0].IsDuplicate || !fused[0].Confirmed {
+		t.Fatalf("expected confirmed duplicate B pinned first and flagged: %+v", fused[0])
+	}
+	if fused[1].ID != "issue/C" || fused[1].Confirmed {
+		t.Fatalf("expected C ranked after the pinned pair: %+v", fused[1])
+	}
+}
+
+func TestFuseResults_FlagsNearExactCopiesBelowThresholds(t *testing.T) {
+	t.Helper()
+
+	vecResults := []VectorResult{{ID: "issue/A", VecScore: 0.70, Type: "issue", Number: 1, Title: "A"}}
+	nearResults := []NearDuplicateResult{
+		{ID: "issue/A", Jaccard: 0.91, Type: "issue", Number: 1, Title: "A"},
+		{ID: "issue/B", Jaccard: 0.55, Type: "issue", Number: 2, Title: "B"},
+	}
+
+	fused := FuseResults(vecResults, nil, nearResults, "", FuseConfig{
+		SimilarityThreshold: 0.95,
+		DuplicateThreshold:  0.99,
+		MaxResults:          5,
+	})
+
+	if len(fused) != 1 {
+		t.Fatalf("FuseResults() len = %d, want 1: %+v", len(fused), fused)
+	}
+	if fused[0].ID != "issue/A" || !fused[0].IsDuplicate || !fused[0].NearExact || fused[0].NearScore != 0.91 {
+		t.Fatalf("expected near-exact copy A flagged as duplicate: %+v", fused[0])
+	}
+}
+
+func TestFuseResults_RecencyDecaysStaleAndBoostsOpenCandidates(t *testing.T) {
+	t.Helper()
+
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	vecResults := []VectorResult{
+		{ID: "issue/old", VecScore: 0.95, Number: 1, State: "closed", CreatedAt: now.AddDate(-3, 0, 0), UpdatedAt: now.AddDate(-2, 0, 0)},
+		{ID: "issue/new", VecScore: 0.93, Number: 2, State: "open", CreatedAt: now.AddDate(0, 0, -3)},
+	}
+	cfg := FuseConfig{SimilarityThreshold: 0.5, DuplicateThreshold: 0.9, MaxResults: 5}
+
+	fused := FuseResults(vecResults, nil, nil, "", cfg)
+	if fused[0].ID != "issue/old" || fused[0].RecencyDecay != 1 || fused[0].OpenBoost != 1 {
+		t.Fatalf("without recency expected plain ranking, got %+v", fused)
+	}
+
+	cfg.Recency = Recency{HalfLife: 365 * 24 * time.Hour, OpenBoost: 0.2, Now: now}
+	fused = FuseResults(vecResults, nil, nil, "", cfg)
+	if len(fused) != 2 || fused[0].ID != "issue/new" {
+		t.Fatalf("expected the fresh open issue first, got %+v", fused)
+	}
+	old := fused[1]
+	if math.Abs(old.RecencyDecay-0.25) > 0.01 || old.OpenBoost != 1 || !old.UpdatedAt.Equal(now.AddDate(-2, 0, 0)) {
+		t.Fatalf("unexpected recency data for stale item: %+v", old)
+	}
+	if fused[0].OpenBoost != 1.2 {
+		t.Fatalf("OpenBoost = %v, want 1.2", fused[0].OpenBoost)
+	}
+	if !old.IsDuplicate || old.DisplaySimilarity != 0.95 {
+		t.Fatalf("recency must not change similarity or duplicate flags: %+v", old)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"math"
 	"sort"
 	"strings"
+	"time"
 
 	sqlite_vec "github.com/asg017/sqlite-vec-go-bindings/cgo"
 )
@@ -22,6 +23,9 @@ type VectorResult struct {
 	URL      string
 	Distance float64
 	VecScore float64
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
 type vectorHit struct {
@@ -29,32 +33,141 @@ type vectorHit struct {
 	Distance float64
 }
 
-func (s *Store) SearchVector(ctx context.Context, queryEmbedding []float32, excludeID string, limit int) ([]VectorResult, error) {
+// ChunkScoringMode selects how the similarities of an item's vectors (its
+// whole-item vector plus any chunk vectors) combine into one score.
+type ChunkScoringMode string
+
+const (
+	// ChunkScoringMax scores an item by its best-matching vector.
+	ChunkScoringMax ChunkScoringMode = "max"
+	// ChunkScoringTopKMean averages the item's TopK best matches, so one
+	// stray chunk counts for less than several agreeing ones.
+	ChunkScoringTopKMean ChunkScoringMode = "topk-mean"
+
+	defaultChunkTopK = 3
+	// chunkCandidateFactor widens the chunk k-NN query, since one long item
+	// can fill many of the nearest slots on its own.
+	chunkCandidateFactor = 4
+)
+
+// ChunkScoring configures how SearchVectors aggregates per-item similarities.
+type ChunkScoring struct {
+	Mode ChunkScoringMode
+	TopK int
+}
+
+// ParseChunkScoringMode validates a mode name; empty selects max.
+func ParseChunkScoringMode(raw string) (ChunkScoringMode, error) {
+	switch mode := ChunkScoringMode(strings.ToLower(strings.TrimSpace(raw))); mode {
+	case "", ChunkScoringMax:
+		return ChunkScoringMax, nil
+	case ChunkScoringTopKMean:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown chunk scoring mode %q (supported: %s, %s)", raw, ChunkScoringMax, ChunkScoringTopKMean)
+	}
+}
+
+// SetChunkScoring changes how chunk hits are combined. The default is max-sim.
+func (s *Store) SetChunkScoring(cfg ChunkScoring) {
+	s.chunkScoring = cfg
+}
+
+func (c ChunkScoring) score(sims []float64) float64 {
+	if len(sims) == 0 {
+		return 0
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(sims)))
+	if c.Mode != ChunkScoringTopKMean {
+		return sims[0]
+	}
+
+	k := c.TopK
+	if k <= 0 {
+		k = defaultChunkTopK
+	}
+	if k > len(sims) {
+		k = len(sims)
+	}
+	var sum float64
+	for _, sim := range sims[:k] {
+		sum += sim
+	}
+	return sum / float64(k)
+}
+
+// SearchVector ranks items by similarity to one query vector, keeping only
+// those filter matches.
+func (s *Store) SearchVector(ctx context.Context, queryEmbedding []float32, excludeID string, limit int, filter SearchFilter) ([]VectorResult, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store is not initialized")
+	}
+	if len(queryEmbedding) == 0 {
+		return []VectorResult{}, nil
+	}
+	return s.SearchVectors(ctx, [][]float32{queryEmbedding}, excludeID, limit, filter)
+}
+
+// SearchVectors ranks items against a set of query vectors, typically a whole
+// item vector plus its chunk vectors. Every query is matched against both
+// items_vec and items_chunks_vec, and each item's similarities are combined
+// with the configured ChunkScoring, so a stack trace shared deep in two long
+// bodies still surfaces the pair. Only items filter matches are returned.
+func (s *Store) SearchVectors(ctx context.Context, queries [][]float32, excludeID string, limit int, filter SearchFilter) ([]VectorResult, error) {
 	if s == nil || s.db == nil {
 		return nil, errors.New("store is not initialized")
 	}
+	filter = filter.normalized()
 
-	if len(queryEmbedding) == 0 || limit <= 0 {
+	if len(queries) == 0 || limit <= 0 {
 		return []VectorResult{}, nil
 	}
+	for _, query := range queries {
+		if s.space.Dimensions > 0 && len(query) != s.space.Dimensions {
+			return nil, fmt.Errorf("query embedding has %d dimensions, index expects %d for %s", len(query), s.space.Dimensions, s.space.Model)
+		}
+	}
 
 	candidateLimit := limit * 3
 	if candidateLimit < 1 {
 		candidateLimit = 1
 	}
 
-	hits, err := s.vectorOnlySearch(ctx, queryEmbedding, candidateLimit)
-	if err != nil {
-		return nil, err
+	sims := map[string][]float64{}
+	for _, query := range queries {
+		hits, err := s.vectorOnlySearch(ctx, "items_vec", "id", query, candidateLimit, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, hit := range hits {
+			sims[hit.ID] = append(sims[hit.ID], 1.0-hit.Distance)
+		}
+
+		chunkHits, err := s.vectorOnlySearch(ctx, "items_chunks_vec", "chunk_id", query, candidateLimit*chunkCandidateFactor, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, hit := range chunkHits {
+			id := chunkItemID(hit.ID)
+			sims[id] = append(sims[id], 1.0-hit.Distance)
+		}
 	}
+	delete(sims, excludeID)
 
-	results := make([]VectorResult, 0, limit)
-	for _, hit := range hits {
-		if hit.ID == excludeID {
-			continue
+	ranked := make([]vectorHit, 0, len(sims))
+	for id, itemSims := range sims {
+		ranked = append(ranked, vectorHit{ID: id, Distance: 1.0 - s.chunkScoring.score(itemSims)})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Distance == ranked[j].Distance {
+			return ranked[i].ID < ranked[j].ID
 		}
+		return ranked[i].Distance < ranked[j].Distance
+	})
 
-		item, err := s.lookupItemMeta(ctx, hit.ID)
+	results := make([]VectorResult, 0, limit)
+	for _, hit := range ranked {
+		item, err := s.lookupItemMeta(ctx, hit.ID, filter)
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				continue
@@ -71,6 +184,9 @@ func (s *Store) SearchVector(ctx context.Context, queryEmbedding []float32, excl
 			URL:      item.URL,
 			Distance: hit.Distance,
 			VecScore: clamp01(1.0 - hit.Distance),
+
+			CreatedAt: item.CreatedAt,
+			UpdatedAt: item.UpdatedAt,
 		})
 
 		if len(results) >= limit {
@@ -81,19 +197,23 @@ func (s *Store) SearchVector(ctx context.Context, queryEmbedding []float32, excl
 	return results, nil
 }
 
-func (s *Store) vectorOnlySearch(ctx context.Context, queryEmbedding []float32, candidateLimit int) ([]vectorHit, error) {
+// vectorOnlySearch runs a k-NN query against one vector table, falling back
+// to a brute-force scan when sqlite-vec is unavailable. The filter is applied
+// to the metadata columns inside the scan, so k counts only matches.
+func (s *Store) vectorOnlySearch(ctx context.Context, table, keyColumn string, queryEmbedding []float32, candidateLimit int, filter SearchFilter) ([]vectorHit, error) {
 	serialized, err := sqlite_vec.SerializeFloat32(queryEmbedding)
 	if err != nil {
 		return nil, fmt.Errorf("serialize query embedding: %w", err)
 	}
 
-	const sqliteVecQuery = `
-SELECT id, distance
-FROM items_vec
-WHERE embedding MATCH ? AND k = ?;
-`
+	filterSQL, filterArgs := filter.vectorClause()
+	sqliteVecQuery := fmt.Sprintf(`
+SELECT %s, distance
+FROM %s
+WHERE embedding MATCH ? AND k = ?%s;
+`, keyColumn, table, filterSQL)
 
-	rows, err := s.db.QueryContext(ctx, sqliteVecQuery, serialized, candidateLimit)
+	rows, err := s.db.QueryContext(ctx, sqliteVecQuery, append([]any{serialized, candidateLimit}, filterArgs...)...)
 	if err == nil {
 		defer rows.Close()
 		hits, scanErr := scanDistanceRows(rows)
@@ -107,7 +227,7 @@ WHERE embedding MATCH ? AND k = ?;
 		return nil, fmt.Errorf("vector query failed: %w", err)
 	}
 
-	return s.vectorOnlySearchBruteForce(ctx, queryEmbedding, candidateLimit)
+	return s.vectorOnlySearchBruteForce(ctx, table, keyColumn, queryEmbedding, candidateLimit, filter)
 }
 
 func scanDistanceRows(rows *sql.Rows) ([]vectorHit, error) {
@@ -127,13 +247,15 @@ func scanDistanceRows(rows *sql.Rows) ([]vectorHit, error) {
 	return hits, nil
 }
 
-func (s *Store) vectorOnlySearchBruteForce(ctx context.Context, queryEmbedding []float32, candidateLimit int) ([]vectorHit, error) {
-	const query = `
-SELECT id, embedding
-FROM items_vec;
-`
+func (s *Store) vectorOnlySearchBruteForce(ctx context.Context, table, keyColumn string, queryEmbedding []float32, candidateLimit int, filter SearchFilter) ([]vectorHit, error) {
+	filterSQL, filterArgs := filter.vectorClause()
+	query := fmt.Sprintf(`
+SELECT %s, embedding
+FROM %s
+WHERE 1 = 1%s;
+`, keyColumn, table, filterSQL)
 
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.db.QueryContext(ctx, query, filterArgs...)
 	if err != nil {
 		return nil, fmt.Errorf("fallback vector query failed: %w", err)
 	}
@@ -177,33 +299,43 @@ FROM items_vec;
 }
 
 type itemMeta struct {
-	ID     string
-	Type   string
-	Number int
-	Title  string
-	State  string
-	URL    string
+	ID        string
+	Type      string
+	Number    int
+	Title     string
+	State     string
+	URL       string
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
-func (s *Store) lookupItemMeta(ctx context.Context, id string) (itemMeta, error) {
-	const query = `
-SELECT id, type, number, title, state, url
+// lookupItemMeta loads a vector hit's item. Items filter rejects, such as
+// ones missing a required label, read as sql.ErrNoRows.
+func (s *Store) lookupItemMeta(ctx context.Context, id string, filter SearchFilter) (itemMeta, error) {
+	filterSQL, filterArgs := filter.itemClause("")
+	query := `
+SELECT id, type, number, title, state, url, created_at, updated_at
 FROM items
-WHERE id = ?;
+WHERE id = ?` + filterSQL + `;
 `
 
 	var out itemMeta
-	err := s.db.QueryRowContext(ctx, query, id).Scan(
+	var createdAt, updatedAt string
+	err := s.db.QueryRowContext(ctx, query, append([]any{id}, filterArgs...)...).Scan(
 		&out.ID,
 		&out.Type,
 		&out.Number,
 		&out.Title,
 		&out.State,
 		&out.URL,
+		&createdAt,
+		&updatedAt,
 	)
 	if err != nil {
 		return itemMeta{}, err
 	}
+	out.CreatedAt = parseItemTime(createdAt)
+	out.UpdatedAt = parseItemTime(updatedAt)
 
 	return out, nil
 }
@@ -277,8 +409,10 @@ func cosineDistance(a, b []float32) float64 {
 	return 1.0 - similarity
 }
 
+// clamp01 bounds a score to [0, 1]. NaN, which cosine distance yields for a
+// zero vector, maps to 0 so it can never pass a threshold comparison.
 func clamp01(v float64) float64 {
-	if v < 0 {
+	if v < 0 || math.IsNaN(v) {
 		return 0
 	}
 	if v > 1 {
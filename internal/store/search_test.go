@@ -43,7 +43,7 @@ func TestSearchVector_ExcludesCurrentItemAndUsesTwoStepLookup(t *testing.T) {
 		t.Fatalf("insertVectorFixture issue/3 error = %v", err)
 	}
 
-	results, err := s.SearchVector(ctx, makeVec1536(1, 0), "issue/1", 2)
+	results, err := s.SearchVector(ctx, makeVec1536(1, 0), "issue/1", 2, SearchFilter{})
 	if err != nil {
 		t.Fatalf("SearchVector() error = %v", err)
 	}
@@ -83,7 +83,7 @@ func TestSearchVector_ClampsVecScoreToUnitInterval(t *testing.T) {
 		t.Fatalf("insertVectorFixture issue/neg error = %v", err)
 	}
 
-	results, err := s.SearchVector(ctx, makeVec1536(1, 0), "", 1)
+	results, err := s.SearchVector(ctx, makeVec1536(1, 0), "", 1, SearchFilter{})
 	if err != nil {
 		t.Fatalf("SearchVector() error = %v", err)
 	}
@@ -123,7 +123,7 @@ func TestSearchVector_SkipsVectorHitsMissingMetadata(t *testing.T) {
 		t.Fatalf("insertVectorFixture issue/ok error = %v", err)
 	}
 
-	results, err := s.SearchVector(ctx, makeVec1536(1, 0), "", 2)
+	results, err := s.SearchVector(ctx, makeVec1536(1, 0), "", 2, SearchFilter{})
 	if err != nil {
 		t.Fatalf("SearchVector() error = %v", err)
 	}
@@ -155,9 +155,7 @@ func insertVectorFixture(ctx context.Context, s *Store, id string, vector []floa
 		return err
 	}
 
-	const stmt = `INSERT INTO items_vec(id, embedding) VALUES(?, ?);`
-	_, err = s.DB().ExecContext(ctx, stmt, id, serialized)
-	return err
+	return insertVectorRow(ctx, s.DB(), "items_vec", "id", id, id, serialized)
 }
 
 func makeVec1536(values ...float32) []float32 {
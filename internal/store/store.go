@@ -14,6 +14,10 @@ import (
 // Store wraps the database handle used for triage indexing.
 type Store struct {
 	db *sql.DB
+	// space is set once EnsureEmbeddingSpace has bound the store to a model.
+	space EmbeddingSpace
+	// chunkScoring rolls chunk similarities up into one item score; see SetChunkScoring.
+	chunkScoring ChunkScoring
 }
 
 var sqliteVecAutoOnce sync.Once